@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"github.com/jonstjohn/crdb-settings/pkg/api"
 	"log"
-	"net/http"
 	"os"
 )
 
@@ -36,17 +35,15 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	sh := api.SettingsHandler{Url: url}
-	http.HandleFunc("/", sh.ServeHTTP)
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-		log.Printf("Defaulting to port %s", port)
+	config := api.DefaultServerConfig()
+	if port := os.Getenv("PORT"); port != "" {
+		config.Listen = ":" + port
+	} else {
+		log.Printf("Defaulting to %s", config.Listen)
 	}
 
-	log.Printf("Listening on port %s", port)
-	if err := http.ListenAndServe(":"+port, nil); err != nil {
+	if err := api.Serve(url, config); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -5,14 +5,25 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var apiServeConfig = api.DefaultServerConfig()
+
 var apiServeCmd = &cobra.Command{
 	Use:   "api serve",
-	Short: "Run a local test server and output the settings",
+	Short: "Run the REST API web server",
 	Run: func(cmd *cobra.Command, args []string) {
-		api.Serve(urlArg)
+		if err := api.Serve(urlArg, apiServeConfig); err != nil {
+			panic(err)
+		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(apiServeCmd)
+	apiServeCmd.Flags().StringVar(&apiServeConfig.Listen, "listen", apiServeConfig.Listen, "Address to listen on")
+	apiServeCmd.Flags().StringVar(&apiServeConfig.TLSCertFile, "tls-cert", "", "TLS certificate file, serves HTTPS when set with --tls-key")
+	apiServeCmd.Flags().StringVar(&apiServeConfig.TLSKeyFile, "tls-key", "", "TLS key file")
+	apiServeCmd.Flags().DurationVar(&apiServeConfig.ReadTimeout, "read-timeout", apiServeConfig.ReadTimeout, "Maximum duration for reading a request")
+	apiServeCmd.Flags().DurationVar(&apiServeConfig.WriteTimeout, "write-timeout", apiServeConfig.WriteTimeout, "Maximum duration for writing a response")
+	apiServeCmd.Flags().DurationVar(&apiServeConfig.IdleTimeout, "idle-timeout", apiServeConfig.IdleTimeout, "Maximum time to wait for the next request on a keep-alive connection")
+	apiServeCmd.Flags().DurationVar(&apiServeConfig.ShutdownTimeout, "shutdown-timeout", apiServeConfig.ShutdownTimeout, "Maximum time to wait for in-flight requests on shutdown")
 }
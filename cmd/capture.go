@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jonstjohn/crdb-settings/pkg/capture"
+	"github.com/spf13/cobra"
+)
+
+var captureCmdReleaseFlag string
+var captureCmdNodesFlag int
+var captureCmdParallelismFlag int
+var captureCmdArtifactsFlag string
+
+var captureCmd = &cobra.Command{
+	Use:   "capture",
+	Short: "Capture settings, metrics, session variables, the catalog and keywords from one test cluster per release",
+	Run: func(cmd *cobra.Command, args []string) {
+		collectors, err := capture.CollectorsForNames(captureCmdArtifactsFlag)
+		if err != nil {
+			panic(err)
+		}
+		m, err := capture.NewManager(urlArg)
+		if err != nil {
+			panic(err)
+		}
+		m.Binaries = binaryProvider()
+		m.Parallelism = captureCmdParallelismFlag
+		m.Collectors = collectors
+		ctx, cancel := captureContext()
+		defer cancel()
+		exitOnCaptureError(m.CaptureReleases(ctx, captureCmdReleaseFlag, captureCmdNodesFlag))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(captureCmd)
+	captureCmd.Flags().StringVarP(&captureCmdReleaseFlag, "release", "r", "recent-10", "Release name (use 'all' or 'recent-N' for multiple)")
+	captureCmd.Flags().IntVar(&captureCmdNodesFlag, "nodes", 1, "Number of nodes in the test cluster (1 or 3)")
+	captureCmd.Flags().IntVar(&captureCmdParallelismFlag, "parallelism", 1, "Number of releases to capture at once, each with its own test cluster")
+	captureCmd.Flags().StringVar(&captureCmdArtifactsFlag, "artifacts", "", fmt.Sprintf("Comma separated artifacts to capture, every artifact if not set (%s)", strings.Join(capture.ArtifactNames(), ", ")))
+}
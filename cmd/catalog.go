@@ -0,0 +1,15 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+var catalogCmd = &cobra.Command{
+	Use:   "catalog",
+	Short: "Virtual table and builtin function catalog commands",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(catalogCmd)
+}
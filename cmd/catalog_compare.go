@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jonstjohn/crdb-settings/pkg/catalog"
+	"github.com/spf13/cobra"
+)
+
+var catalogCompareR1Flag string
+var catalogCompareR2Flag string
+
+var catalogCompareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "Compare the virtual tables and builtin functions of two releases",
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := catalog.NewManager(urlArg)
+		if err != nil {
+			panic(err)
+		}
+		c, err := m.CompareCatalogsForReleases(catalogCompareR1Flag, catalogCompareR2Flag)
+		if err != nil {
+			panic(err)
+		}
+		b, err := json.MarshalIndent(c, "", "  ")
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(b))
+	},
+}
+
+func init() {
+	catalogCmd.AddCommand(catalogCompareCmd)
+	catalogCompareCmd.Flags().StringVar(&catalogCompareR1Flag, "r1", "", "Release to compare from")
+	catalogCompareCmd.Flags().StringVar(&catalogCompareR2Flag, "r2", "", "Release to compare to")
+	catalogCompareCmd.MarkFlagRequired("r1")
+	catalogCompareCmd.MarkFlagRequired("r2")
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jonstjohn/crdb-settings/pkg/catalog"
+	"github.com/spf13/cobra"
+)
+
+var catalogListReleaseFlag string
+
+var catalogListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the virtual tables and builtin functions of a release",
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := catalog.NewManager(urlArg)
+		if err != nil {
+			panic(err)
+		}
+		c, err := m.GetCatalogForRelease(catalogListReleaseFlag)
+		if err != nil {
+			panic(err)
+		}
+		b, err := json.MarshalIndent(c, "", "  ")
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(b))
+	},
+}
+
+func init() {
+	catalogCmd.AddCommand(catalogListCmd)
+	catalogListCmd.Flags().StringVarP(&catalogListReleaseFlag, "release", "r", "", "Release name")
+	catalogListCmd.MarkFlagRequired("release")
+}
@@ -0,0 +1,15 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+var keywordsCmd = &cobra.Command{
+	Use:   "keywords",
+	Short: "SQL keyword commands",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(keywordsCmd)
+}
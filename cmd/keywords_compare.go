@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jonstjohn/crdb-settings/pkg/keywords"
+	"github.com/spf13/cobra"
+)
+
+var keywordsCompareR1Flag string
+var keywordsCompareR2Flag string
+
+var keywordsCompareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "Compare the SQL keywords of two releases, including the newly reserved keywords",
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := keywords.NewManager(urlArg)
+		if err != nil {
+			panic(err)
+		}
+		c, err := m.CompareKeywordsForReleases(keywordsCompareR1Flag, keywordsCompareR2Flag)
+		if err != nil {
+			panic(err)
+		}
+		b, err := json.MarshalIndent(c, "", "  ")
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(b))
+	},
+}
+
+func init() {
+	keywordsCmd.AddCommand(keywordsCompareCmd)
+	keywordsCompareCmd.Flags().StringVar(&keywordsCompareR1Flag, "r1", "", "Release to compare from")
+	keywordsCompareCmd.Flags().StringVar(&keywordsCompareR2Flag, "r2", "", "Release to compare to")
+	keywordsCompareCmd.MarkFlagRequired("r1")
+	keywordsCompareCmd.MarkFlagRequired("r2")
+}
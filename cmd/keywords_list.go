@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jonstjohn/crdb-settings/pkg/keywords"
+	"github.com/spf13/cobra"
+)
+
+var keywordsListReleaseFlag string
+
+var keywordsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the SQL keywords and their categories for a release",
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := keywords.NewManager(urlArg)
+		if err != nil {
+			panic(err)
+		}
+		kws, err := m.GetKeywordsForRelease(keywordsListReleaseFlag)
+		if err != nil {
+			panic(err)
+		}
+		b, err := json.MarshalIndent(kws, "", "  ")
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(b))
+	},
+}
+
+func init() {
+	keywordsCmd.AddCommand(keywordsListCmd)
+	keywordsListCmd.Flags().StringVarP(&keywordsListReleaseFlag, "release", "r", "", "Release name")
+	keywordsListCmd.MarkFlagRequired("release")
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jonstjohn/crdb-settings/pkg/metrics"
+	"github.com/spf13/cobra"
+)
+
+var metricDetailMetricFlag string
+
+var metricsDetailCmd = &cobra.Command{
+	Use:   "detail",
+	Short: "Show the help, type and releases of a metric",
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := metrics.NewManager(urlArg)
+		if err != nil {
+			panic(err)
+		}
+		detail, err := m.GetMetricDetail(metricDetailMetricFlag)
+		if err != nil {
+			panic(err)
+		}
+		b, err := json.MarshalIndent(detail, "", "  ")
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(b))
+	},
+}
+
+func init() {
+	metricsCmd.AddCommand(metricsDetailCmd)
+	metricsDetailCmd.Flags().StringVar(&metricDetailMetricFlag, "metric", "sys_uptime", "Metric to get details for")
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jonstjohn/crdb-settings/pkg/metrics"
+	"github.com/spf13/cobra"
+)
+
+var metricHistoryMetricFlag string
+
+var metricsHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show when a metric was added, changed help or type and removed across releases",
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := metrics.NewManager(urlArg)
+		if err != nil {
+			panic(err)
+		}
+		history, err := m.HistoryForMetric(metricHistoryMetricFlag)
+		if err != nil {
+			panic(err)
+		}
+		b, err := json.MarshalIndent(history, "", "  ")
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(b))
+	},
+}
+
+func init() {
+	metricsCmd.AddCommand(metricsHistoryCmd)
+	metricsHistoryCmd.Flags().StringVar(&metricHistoryMetricFlag, "metric", "sys_uptime", "Metric to get history for")
+}
@@ -7,7 +7,7 @@ import (
 
 var metricsSetupCmd = &cobra.Command{
 	Use:   "setup",
-	Short: "Setup metrics database and tables",
+	Short: "Setup metrics database and tables (same as migrate up)",
 	Run: func(cmd *cobra.Command, args []string) {
 		m, err := metrics.NewManager(urlArg)
 		if err != nil {
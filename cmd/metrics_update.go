@@ -6,6 +6,8 @@ import (
 )
 
 var updateMetricsCmdReleaseFlag string
+var updateMetricsCmdNodesFlag int
+var updateMetricsCmdParallelismFlag int
 
 var metricsUpdateCmd = &cobra.Command{
 	Use:   "update",
@@ -15,13 +17,17 @@ var metricsUpdateCmd = &cobra.Command{
 		if err != nil {
 			panic(err)
 		}
-		if err = m.SaveMetricsForRelease(updateMetricsCmdReleaseFlag); err != nil {
-			panic(err)
-		}
+		m.Binaries = binaryProvider()
+		m.Parallelism = updateMetricsCmdParallelismFlag
+		ctx, cancel := captureContext()
+		defer cancel()
+		exitOnCaptureError(m.SaveMetricsForRelease(ctx, updateMetricsCmdReleaseFlag, updateMetricsCmdNodesFlag))
 	},
 }
 
 func init() {
 	metricsCmd.AddCommand(metricsUpdateCmd)
 	metricsUpdateCmd.Flags().StringVarP(&updateMetricsCmdReleaseFlag, "release", "r", "recent-10", "Release name (use 'all' or 'recent-N' for multiple)")
+	metricsUpdateCmd.Flags().IntVar(&updateMetricsCmdNodesFlag, "nodes", 1, "Number of nodes in the test cluster, scraping metrics from every node (1 or 3)")
+	metricsUpdateCmd.Flags().IntVar(&updateMetricsCmdParallelismFlag, "parallelism", 1, "Number of releases to capture at once, each with its own test cluster")
 }
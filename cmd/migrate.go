@@ -0,0 +1,15 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Database schema migration commands",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+}
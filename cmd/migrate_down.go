@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"github.com/jonstjohn/crdb-settings/pkg/migrate"
+	"github.com/spf13/cobra"
+)
+
+var migrateDownStepsFlag int
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Roll back the most recently applied schema migrations",
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := migrate.NewManager(urlArg)
+		if err != nil {
+			panic(err)
+		}
+		if err = m.Down(migrateDownStepsFlag); err != nil {
+			panic(err)
+		}
+	},
+}
+
+func init() {
+	migrateCmd.AddCommand(migrateDownCmd)
+	migrateDownCmd.Flags().IntVar(&migrateDownStepsFlag, "steps", 1, "Number of migrations to roll back")
+}
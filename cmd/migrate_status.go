@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jonstjohn/crdb-settings/pkg/migrate"
+	"github.com/spf13/cobra"
+)
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show applied and pending schema migrations",
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := migrate.NewManager(urlArg)
+		if err != nil {
+			panic(err)
+		}
+		statuses, err := m.Status()
+		if err != nil {
+			panic(err)
+		}
+		b, err := json.MarshalIndent(statuses, "", "  ")
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(b))
+	},
+}
+
+func init() {
+	migrateCmd.AddCommand(migrateStatusCmd)
+}
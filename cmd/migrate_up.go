@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/jonstjohn/crdb-settings/pkg/migrate"
+	"github.com/spf13/cobra"
+)
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply all pending schema migrations",
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := migrate.NewManager(urlArg)
+		if err != nil {
+			panic(err)
+		}
+		if err = m.Up(); err != nil {
+			panic(err)
+		}
+	},
+}
+
+func init() {
+	migrateCmd.AddCommand(migrateUpCmd)
+}
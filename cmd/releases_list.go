@@ -8,6 +8,7 @@ import (
 )
 
 var releasesListCmdSourceArg string
+var releasesListCmdYamlArg string
 
 var releasesListCmd = &cobra.Command{
 	Use:   "list",
@@ -28,7 +29,10 @@ var releasesListCmd = &cobra.Command{
 			}
 			fmt.Println(string(b))
 		} else {
-			rp := releases.NewRemoteDataSource()
+			rp, err := releases.NewYamlDataSource(releasesListCmdYamlArg)
+			if err != nil {
+				panic(err)
+			}
 			releases, err := rp.GetReleases()
 			if err != nil {
 				panic(err)
@@ -44,6 +48,6 @@ var releasesListCmd = &cobra.Command{
 
 func init() {
 	releasesListCmd.Flags().StringVar(&releasesListCmdSourceArg, "source", "db", "Source for releases list command - 'yaml' or 'db'")
+	releasesListCmd.Flags().StringVar(&releasesListCmdYamlArg, "yaml", "", "Releases yaml location when source is 'yaml' - local file, directory or URL (defaults to the CockroachDB docs releases.yml)")
 	releasesCmd.AddCommand(releasesListCmd)
-
 }
@@ -5,15 +5,21 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var releasesUpdateCmdYamlArg string
+
 var releasesUpdateCmd = &cobra.Command{
 	Use:   "update",
-	Short: "Update db releases from remote yaml",
+	Short: "Update db releases from remote or local yaml",
 	Run: func(cmd *cobra.Command, args []string) {
 		rm, err := releases.NewReleasesManager(urlArg)
 		if err != nil {
 			panic(err)
 		}
-		err = rm.UpdateReleases()
+		p, err := releases.NewYamlDataSource(releasesUpdateCmdYamlArg)
+		if err != nil {
+			panic(err)
+		}
+		err = rm.UpdateReleasesFromProvider(p)
 		if err != nil {
 			panic(err)
 		}
@@ -21,5 +27,6 @@ var releasesUpdateCmd = &cobra.Command{
 }
 
 func init() {
+	releasesUpdateCmd.Flags().StringVar(&releasesUpdateCmdYamlArg, "yaml", "", "Releases yaml location - local file, directory or URL (defaults to the CockroachDB docs releases.yml)")
 	releasesCmd.AddCommand(releasesUpdateCmd)
 }
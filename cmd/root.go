@@ -4,12 +4,22 @@ Copyright © 2024 Jon St John <jon@element128.com>
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/jonstjohn/crdb-settings/pkg/crdbcluster"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 var urlArg string
+var cockroachBinaryArg string
+var binaryCacheDirArg string
+var binaryMirrorUrlArg string
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -31,10 +41,39 @@ func Execute() {
 	}
 }
 
+// captureContext returns a context that is canceled on interrupt, so that capture stops starting new releases
+func captureContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// exitOnCaptureError exits after logging each release that failed, or panics for errors that are not per release
+func exitOnCaptureError(err error) {
+	if err == nil {
+		return
+	}
+	var captureErr *crdbcluster.CaptureError
+	if !errors.As(err, &captureErr) {
+		panic(err)
+	}
+	for _, r := range captureErr.Failed {
+		logrus.Error(fmt.Sprintf("Could not capture '%s': %v", r.Release, r.Err))
+	}
+	os.Exit(1)
+}
+
+// binaryProvider returns the cockroach binary provider configured by the binary flags
+func binaryProvider() crdbcluster.BinaryProvider {
+	return crdbcluster.NewBinaryProvider(cockroachBinaryArg, binaryCacheDirArg, binaryMirrorUrlArg)
+}
+
 func init() {
 
 	rootCmd.PersistentFlags().StringVar(&urlArg, "url", os.Getenv("CRDB_SETTINGS_URL"), "Database URL")
 	rootCmd.MarkFlagRequired("url")
 
+	rootCmd.PersistentFlags().StringVar(&cockroachBinaryArg, "cockroach-binary", "", "Path to a cockroach binary, only used for the release it reports with 'cockroach version'")
+	rootCmd.PersistentFlags().StringVar(&binaryCacheDirArg, "binary-cache-dir", "", "Directory of cockroach binaries at <dir>/<release>/cockroach")
+	rootCmd.PersistentFlags().StringVar(&binaryMirrorUrlArg, "binary-mirror-url", "", "Mirror to download cockroach release archives from, fills the cache directory if one is set")
+
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jonstjohn/crdb-settings/pkg/search"
+	"github.com/spf13/cobra"
+)
+
+var searchLimitFlag int
+
+var searchCmd = &cobra.Command{
+	Use:   "search [terms]",
+	Short: "Search settings and metrics by name, description and help text",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := search.NewManager(urlArg)
+		if err != nil {
+			panic(err)
+		}
+		results, err := m.Search(strings.Join(args, " "), searchLimitFlag)
+		if err != nil {
+			panic(err)
+		}
+		b, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(b))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+	searchCmd.Flags().IntVar(&searchLimitFlag, "limit", search.DefaultLimit, fmt.Sprintf("Maximum number of results, at most %d", search.MaxLimit))
+}
@@ -0,0 +1,15 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+var sessionVarsCmd = &cobra.Command{
+	Use:   "sessionvars",
+	Short: "Session variable commands",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sessionVarsCmd)
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jonstjohn/crdb-settings/pkg/sessionvars"
+	"github.com/spf13/cobra"
+)
+
+var sessionVarsCompareR1Flag string
+var sessionVarsCompareR2Flag string
+
+var sessionVarsCompareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "Compare the session variables of two releases",
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := sessionvars.NewManager(urlArg)
+		if err != nil {
+			panic(err)
+		}
+		c, err := m.CompareSessionVariablesForReleases(sessionVarsCompareR1Flag, sessionVarsCompareR2Flag)
+		if err != nil {
+			panic(err)
+		}
+		b, err := json.MarshalIndent(c, "", "  ")
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(b))
+	},
+}
+
+func init() {
+	sessionVarsCmd.AddCommand(sessionVarsCompareCmd)
+	sessionVarsCompareCmd.Flags().StringVar(&sessionVarsCompareR1Flag, "r1", "", "Release to compare from")
+	sessionVarsCompareCmd.Flags().StringVar(&sessionVarsCompareR2Flag, "r2", "", "Release to compare to")
+	sessionVarsCompareCmd.MarkFlagRequired("r1")
+	sessionVarsCompareCmd.MarkFlagRequired("r2")
+}
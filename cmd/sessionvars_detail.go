@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jonstjohn/crdb-settings/pkg/sessionvars"
+	"github.com/spf13/cobra"
+)
+
+var sessionVarsDetailVariableFlag string
+
+var sessionVarsDetailCmd = &cobra.Command{
+	Use:   "detail",
+	Short: "Show the type, description and default value per release of a session variable",
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := sessionvars.NewManager(urlArg)
+		if err != nil {
+			panic(err)
+		}
+		detail, err := m.GetSessionVariableDetail(sessionVarsDetailVariableFlag)
+		if err != nil {
+			panic(err)
+		}
+		b, err := json.MarshalIndent(detail, "", "  ")
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(b))
+	},
+}
+
+func init() {
+	sessionVarsCmd.AddCommand(sessionVarsDetailCmd)
+	sessionVarsDetailCmd.Flags().StringVar(&sessionVarsDetailVariableFlag, "variable", "default_transaction_isolation", "Session variable to get details for")
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jonstjohn/crdb-settings/pkg/sessionvars"
+	"github.com/spf13/cobra"
+)
+
+var sessionVarsListReleaseFlag string
+
+var sessionVarsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the session variables and their defaults for a release",
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := sessionvars.NewManager(urlArg)
+		if err != nil {
+			panic(err)
+		}
+		vars, err := m.GetSessionVariablesForRelease(sessionVarsListReleaseFlag)
+		if err != nil {
+			panic(err)
+		}
+		b, err := json.MarshalIndent(vars, "", "  ")
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(b))
+	},
+}
+
+func init() {
+	sessionVarsCmd.AddCommand(sessionVarsListCmd)
+	sessionVarsListCmd.Flags().StringVarP(&sessionVarsListReleaseFlag, "release", "r", "", "Release name")
+	sessionVarsListCmd.MarkFlagRequired("release")
+}
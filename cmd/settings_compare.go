@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jonstjohn/crdb-settings/pkg/settings"
+	"github.com/spf13/cobra"
+)
+
+var settingsCompareReleasesFlag string
+
+var settingsCompareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "Compare settings across several releases, such as the hops of an upgrade",
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := settings.NewSettingsManager(urlArg)
+		if err != nil {
+			panic(err)
+		}
+		c, err := m.CompareSettingsForReleaseList(settingsCompareReleasesFlag)
+		if err != nil {
+			panic(err)
+		}
+		b, err := json.MarshalIndent(c, "", "  ")
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(b))
+	},
+}
+
+func init() {
+	settingsCmd.AddCommand(settingsCompareCmd)
+	settingsCompareCmd.Flags().StringVar(&settingsCompareReleasesFlag, "releases", "", "Comma separated releases in upgrade order, or a range such as v23.1.0...v24.1.0")
+	settingsCompareCmd.MarkFlagRequired("releases")
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jonstjohn/crdb-settings/pkg/settings"
+	"github.com/spf13/cobra"
+)
+
+var settingHistorySettingFlag string
+
+var settingsHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show when a setting was added, changed and removed across releases",
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := settings.NewSettingsManager(urlArg)
+		if err != nil {
+			panic(err)
+		}
+		history, err := m.HistoryForSetting(settingHistorySettingFlag)
+		if err != nil {
+			panic(err)
+		}
+		b, err := json.MarshalIndent(history, "", "  ")
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(b))
+	},
+}
+
+func init() {
+	settingsCmd.AddCommand(settingsHistoryCmd)
+	settingsHistoryCmd.Flags().StringVar(&settingHistorySettingFlag, "setting", "changefeed.random_replica_selection.enabled", "Setting to get history for")
+}
@@ -5,9 +5,13 @@ import (
 	"fmt"
 	"github.com/jonstjohn/crdb-settings/pkg/settings"
 	"github.com/spf13/cobra"
+	"os"
+	"strconv"
 )
 
 var listSettingsVersionFlag string
+var listSettingsPublicFlag string
+var listSettingsOptions settings.ListOptions
 
 var settingsListCmd = &cobra.Command{
 	Use:   "list",
@@ -19,16 +23,27 @@ var settingsListCmd = &cobra.Command{
 			panic(err)
 		}
 
-		sts, err := s.GetSettingsForRelease(listSettingsVersionFlag)
+		if listSettingsPublicFlag != "" {
+			public, err := strconv.ParseBool(listSettingsPublicFlag)
+			if err != nil {
+				panic(fmt.Errorf("invalid --public '%s', expected true or false", listSettingsPublicFlag))
+			}
+			listSettingsOptions.Public = &public
+		}
+
+		page, err := s.ListSettingsForRelease(listSettingsVersionFlag, listSettingsOptions)
 
 		if err != nil {
 			panic(err)
 		}
-		b, err := json.MarshalIndent(sts, "", "  ")
+		b, err := json.MarshalIndent(page.Settings, "", "  ")
 		if err != nil {
 			panic(err)
 		}
 		fmt.Println(string(b))
+		if page.Next != "" {
+			fmt.Fprintf(os.Stderr, "More settings available, use --next %s\n", page.Next)
+		}
 
 	},
 }
@@ -36,4 +51,12 @@ var settingsListCmd = &cobra.Command{
 func init() {
 	settingsCmd.AddCommand(settingsListCmd)
 	settingsListCmd.Flags().StringVar(&listSettingsVersionFlag, "version", "v23.2.1", "CRDB version, starting with 'v'")
+	settingsListCmd.Flags().StringVar(&listSettingsPublicFlag, "public", "", "Only public (true) or non-public (false) settings")
+	settingsListCmd.Flags().StringVar(&listSettingsOptions.Type, "type", "", "Only settings of a type, such as 'b' or 'd'")
+	settingsListCmd.Flags().StringVar(&listSettingsOptions.Variable, "variable", "", "Variable prefix, or a glob such as 'kv.rangefeed.*'")
+	settingsListCmd.Flags().StringVar(&listSettingsOptions.Description, "description", "", "Case-insensitive description substring")
+	settingsListCmd.Flags().StringVar(&listSettingsOptions.Sort, "sort", "", "Sort by public (default), variable, type or value")
+	settingsListCmd.Flags().BoolVar(&listSettingsOptions.Desc, "desc", false, "Sort in descending order")
+	settingsListCmd.Flags().IntVar(&listSettingsOptions.Limit, "limit", 0, "Maximum number of settings (default all)")
+	settingsListCmd.Flags().StringVar(&listSettingsOptions.Next, "next", "", "Token for the next page from a previous list")
 }
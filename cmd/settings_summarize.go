@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/jonstjohn/crdb-settings/pkg/settings"
+	"github.com/spf13/cobra"
+)
+
+var settingsSummarizeCmd = &cobra.Command{
+	Use:   "summarize",
+	Short: "Rebuild the settings summary table from the raw settings",
+	Run: func(cmd *cobra.Command, args []string) {
+		s, err := settings.NewSettingsManager(urlArg)
+		if err != nil {
+			panic(err)
+		}
+		err = s.SummarizeSettings()
+		if err != nil {
+			panic(err)
+		}
+	},
+}
+
+func init() {
+	settingsCmd.AddCommand(settingsSummarizeCmd)
+}
@@ -1,11 +1,16 @@
 package cmd
 
 import (
+	"github.com/jonstjohn/crdb-settings/pkg/host"
 	"github.com/jonstjohn/crdb-settings/pkg/settings"
 	"github.com/spf13/cobra"
 )
 
 var saveSettingsReleaseFlag string
+var saveSettingsCpuMatrixFlag string
+var saveSettingsMemoryMatrixFlag string
+var saveSettingsNodesFlag int
+var saveSettingsParallelismFlag int
 
 var settingsUpdateCmd = &cobra.Command{
 	Use:   "update",
@@ -15,10 +20,24 @@ var settingsUpdateCmd = &cobra.Command{
 		if err != nil {
 			panic(err)
 		}
-		err = s.SaveClusterSettingsForVersion(saveSettingsReleaseFlag, urlArg)
+		s.Binaries = binaryProvider()
+		cpus, err := host.ParseCpuMatrix(saveSettingsCpuMatrixFlag)
 		if err != nil {
 			panic(err)
 		}
+		memories, err := host.ParseMemoryMatrix(saveSettingsMemoryMatrixFlag)
+		if err != nil {
+			panic(err)
+		}
+		shapes, err := host.Matrix(cpus, memories)
+		if err != nil {
+			panic(err)
+		}
+		s.Parallelism = saveSettingsParallelismFlag
+		ctx, cancel := captureContext()
+		defer cancel()
+		err = s.SaveClusterSettingsForVersion(ctx, saveSettingsReleaseFlag, urlArg, shapes, saveSettingsNodesFlag)
+		exitOnCaptureError(err)
 		/*
 			err := settings.SaveClusterSettingsForVersion(saveSettingsReleaseFlag, urlArg)
 			if err != nil {
@@ -32,4 +51,8 @@ var settingsUpdateCmd = &cobra.Command{
 func init() {
 	settingsCmd.AddCommand(settingsUpdateCmd)
 	settingsUpdateCmd.Flags().StringVar(&saveSettingsReleaseFlag, "release", "all", "Update all or specify a single CRDB release, starting with 'v'")
+	settingsUpdateCmd.Flags().StringVar(&saveSettingsCpuMatrixFlag, "cpu-matrix", "", "Comma-separated CPU counts to simulate, e.g., '2,4,8' (default is the host CPU count)")
+	settingsUpdateCmd.Flags().StringVar(&saveSettingsMemoryMatrixFlag, "memory-matrix", "", "Comma-separated memory sizes to simulate, no more than the host memory, e.g., '4GiB,16GiB' (default is the host memory)")
+	settingsUpdateCmd.Flags().IntVar(&saveSettingsNodesFlag, "nodes", 1, "Number of nodes in the test cluster (1 or 3)")
+	settingsUpdateCmd.Flags().IntVar(&saveSettingsParallelismFlag, "parallelism", 1, "Number of releases to capture at once, each with its own test cluster")
 }
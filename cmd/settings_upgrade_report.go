@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jonstjohn/crdb-settings/pkg/settings"
+	"github.com/spf13/cobra"
+)
+
+var settingsUpgradeReportFromClusterFlag string
+var settingsUpgradeReportToFlag string
+var settingsUpgradeReportFormatFlag string
+
+var settingsUpgradeReportCmd = &cobra.Command{
+	Use:   "upgrade-report",
+	Short: "Check the settings overridden on a running cluster against the defaults of a target release",
+	Run: func(cmd *cobra.Command, args []string) {
+		if settingsUpgradeReportFormatFlag != "json" && settingsUpgradeReportFormatFlag != "markdown" {
+			panic(fmt.Sprintf("invalid format '%s', expected json or markdown", settingsUpgradeReportFormatFlag))
+		}
+		m, err := settings.NewSettingsManager(urlArg)
+		if err != nil {
+			panic(err)
+		}
+		report, err := m.UpgradeReportForCluster(settingsUpgradeReportFromClusterFlag, settingsUpgradeReportToFlag)
+		if err != nil {
+			panic(err)
+		}
+		if settingsUpgradeReportFormatFlag == "markdown" {
+			fmt.Print(report.Markdown())
+			return
+		}
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(b))
+	},
+}
+
+func init() {
+	settingsCmd.AddCommand(settingsUpgradeReportCmd)
+	settingsUpgradeReportCmd.Flags().StringVar(&settingsUpgradeReportFromClusterFlag, "from-cluster", "", "Database URL of the running cluster to upgrade")
+	settingsUpgradeReportCmd.Flags().StringVar(&settingsUpgradeReportToFlag, "to", "", "Release to upgrade to")
+	settingsUpgradeReportCmd.Flags().StringVar(&settingsUpgradeReportFormatFlag, "format", "json", "Output format, json or markdown")
+	settingsUpgradeReportCmd.MarkFlagRequired("from-cluster")
+	settingsUpgradeReportCmd.MarkFlagRequired("to")
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/jonstjohn/crdb-settings/pkg/dbpgx"
+	"github.com/jonstjohn/crdb-settings/pkg/metrics"
+	"github.com/jonstjohn/crdb-settings/pkg/releases"
+	"github.com/jonstjohn/crdb-settings/pkg/search"
+	"github.com/jonstjohn/crdb-settings/pkg/sessionvars"
+	"github.com/jonstjohn/crdb-settings/pkg/settings"
+	"github.com/sirupsen/logrus"
+)
+
+// Error codes returned in the error envelope, so clients can handle errors without parsing messages
+const (
+	CodeBadRequest             = "bad_request"
+	CodeNotFound               = "not_found"
+	CodeMethodNotAllowed       = "method_not_allowed"
+	CodeUnknownRelease         = "unknown_release"
+	CodeUnknownSetting         = "unknown_setting"
+	CodeUnknownMetric          = "unknown_metric"
+	CodeUnknownSessionVariable = "unknown_session_variable"
+	CodeUnavailable            = "database_unavailable"
+	CodeInternal               = "internal"
+)
+
+// ErrorResponse is the JSON envelope for every error response
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// BadRequestError is returned for requests that can never succeed, such as a path missing a release
+type BadRequestError struct {
+	Message string
+}
+
+func (e *BadRequestError) Error() string {
+	return e.Message
+}
+
+// ErrorHandler writes the error envelope with the status code for the type of error. Errors that are not expected
+// are logged and answered with a generic message, so that database details are not exposed.
+func ErrorHandler(w http.ResponseWriter, err error) {
+	status, body := errorResponse(err)
+	if status >= http.StatusInternalServerError {
+		logrus.Errorf("request failed: %v", err)
+	}
+	writeError(w, status, body)
+}
+
+func errorResponse(err error) (int, ErrorBody) {
+	var badRequest *BadRequestError
+	var invalidRelease *releases.InvalidReleaseNameError
+	var unknownRelease *releases.UnknownReleaseError
+	var unknownSetting *settings.UnknownSettingError
+	var unknownMetric *metrics.UnknownMetricError
+	var unknownSessionVariable *sessionvars.UnknownSessionVariableError
+	var invalidOption *settings.InvalidListOptionError
+	var invalidQuery *search.InvalidQueryError
+	var invalidReleaseList *settings.InvalidReleaseListError
+
+	switch {
+	case errors.As(err, &badRequest):
+		return http.StatusBadRequest, ErrorBody{Code: CodeBadRequest, Message: err.Error()}
+	case errors.As(err, &invalidOption):
+		return http.StatusBadRequest, ErrorBody{Code: CodeBadRequest, Message: err.Error()}
+	case errors.As(err, &invalidReleaseList):
+		return http.StatusBadRequest, ErrorBody{Code: CodeBadRequest, Message: err.Error()}
+	case errors.As(err, &invalidQuery):
+		return http.StatusBadRequest, ErrorBody{Code: CodeBadRequest, Message: err.Error()}
+	case errors.As(err, &invalidRelease):
+		return http.StatusBadRequest, ErrorBody{Code: CodeBadRequest, Message: err.Error()}
+	case errors.As(err, &unknownRelease):
+		return http.StatusNotFound, ErrorBody{Code: CodeUnknownRelease, Message: err.Error()}
+	case errors.As(err, &unknownSetting):
+		return http.StatusNotFound, ErrorBody{Code: CodeUnknownSetting, Message: err.Error()}
+	case errors.As(err, &unknownMetric):
+		return http.StatusNotFound, ErrorBody{Code: CodeUnknownMetric, Message: err.Error()}
+	case errors.As(err, &unknownSessionVariable):
+		return http.StatusNotFound, ErrorBody{Code: CodeUnknownSessionVariable, Message: err.Error()}
+	case dbpgx.IsUnavailable(err):
+		return http.StatusServiceUnavailable, ErrorBody{Code: CodeUnavailable, Message: "database unavailable"}
+	}
+	return http.StatusInternalServerError, ErrorBody{Code: CodeInternal, Message: "internal server error"}
+}
+
+func writeError(w http.ResponseWriter, status int, body ErrorBody) {
+	jsonBytes, err := json.Marshal(ErrorResponse{Error: body})
+	if err != nil {
+		http.Error(w, body.Message, status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(jsonBytes)
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jonstjohn/crdb-settings/pkg/dbpgx"
+	"github.com/jonstjohn/crdb-settings/pkg/metrics"
+	"github.com/jonstjohn/crdb-settings/pkg/releases"
+	"github.com/jonstjohn/crdb-settings/pkg/sessionvars"
+	"github.com/jonstjohn/crdb-settings/pkg/settings"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"bad request", &BadRequestError{Message: "release must be included"}, http.StatusBadRequest, CodeBadRequest},
+		{"invalid release", &releases.InvalidReleaseNameError{Name: "foo"}, http.StatusBadRequest, CodeBadRequest},
+		{"unknown release", &releases.UnknownReleaseError{Name: "v99.1.0"}, http.StatusNotFound, CodeUnknownRelease},
+		{"wrapped unknown release", fmt.Errorf("compare: %w", &releases.UnknownReleaseError{Name: "v99.1.0"}),
+			http.StatusNotFound, CodeUnknownRelease},
+		{"unknown setting", &settings.UnknownSettingError{Variable: "foo.bar"}, http.StatusNotFound, CodeUnknownSetting},
+		{"unknown metric", &metrics.UnknownMetricError{Name: "foo_bar"}, http.StatusNotFound, CodeUnknownMetric},
+		{"unknown session variable", &sessionvars.UnknownSessionVariableError{Variable: "foo_bar"},
+			http.StatusNotFound, CodeUnknownSessionVariable},
+		{"unavailable", fmt.Errorf("%w: bad url", dbpgx.ErrUnavailable), http.StatusServiceUnavailable, CodeUnavailable},
+		{"connect error", &pgconn.ConnectError{}, http.StatusServiceUnavailable, CodeUnavailable},
+		{"internal", errors.New("boom"), http.StatusInternalServerError, CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ErrorHandler(w, tt.err)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+
+			var resp ErrorResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.code, resp.Error.Code)
+			assert.NotEmpty(t, resp.Error.Message)
+		})
+	}
+}
+
+func TestServeHTTPErrors(t *testing.T) {
+	h := &SettingsHandler{}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{"error": {"code": "not_found", "message": "no route for '/unknown'"}}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/releases/list", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, http.MethodGet, w.Header().Get("Allow"))
+}
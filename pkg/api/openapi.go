@@ -0,0 +1,214 @@
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// openAPISpec is the checked-in spec served at /openapi.json. Regenerate it after changing routes or response
+// types with: go test ./pkg/api -run TestOpenAPISpec -update
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+var pathParamRe = regexp.MustCompile(`\{([^}]+)\}`)
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// PathParams returns the names of the parameters in an OpenAPI path template, in order
+func PathParams(path string) []string {
+	params := make([]string, 0)
+	for _, m := range pathParamRe.FindAllStringSubmatch(path, -1) {
+		params = append(params, m[1])
+	}
+	return params
+}
+
+// GenerateOpenAPI generates the OpenAPI 3 spec for Routes, with schemas built from the response types
+func GenerateOpenAPI() ([]byte, error) {
+	g := &schemaGenerator{components: make(map[string]any), pkgs: make(map[string]string)}
+
+	paths := make(map[string]any)
+	for _, route := range Routes {
+		params := make([]any, 0)
+		for _, name := range PathParams(route.Path) {
+			params = append(params, map[string]any{
+				"name": name, "in": "path", "required": true, "schema": map[string]any{"type": "string"},
+			})
+		}
+		for _, q := range route.Query {
+			schema := map[string]any{"type": q.Type}
+			if len(q.Enum) > 0 {
+				schema["enum"] = q.Enum
+			}
+			params = append(params, map[string]any{
+				"name": q.Name, "in": "query", "description": q.Description, "schema": schema,
+			})
+		}
+		schema, err := g.schema(reflect.TypeOf(route.Response))
+		if err != nil {
+			return nil, fmt.Errorf("route '%s': %w", route.Path, err)
+		}
+		success := jsonResponse("Success", schema)
+		if len(route.Headers) > 0 {
+			headers := make(map[string]any)
+			for name, description := range route.Headers {
+				headers[name] = map[string]any{"description": description, "schema": map[string]any{"type": "string"}}
+			}
+			success["headers"] = headers
+		}
+		paths[route.Path] = map[string]any{
+			"get": map[string]any{
+				"summary":    route.Summary,
+				"parameters": params,
+				"responses": map[string]any{
+					"200": success,
+					"default": jsonResponse("Error, see the error code for the cause",
+						map[string]any{"$ref": "#/components/schemas/ErrorResponse"}),
+				},
+			},
+		}
+	}
+	if _, err := g.schema(reflect.TypeOf(ErrorResponse{})); err != nil {
+		return nil, err
+	}
+
+	spec := map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "CockroachDB settings and metrics API",
+			"description": "Cluster settings and metrics captured for CockroachDB releases",
+			"version":     "1.0.0",
+		},
+		"paths":      paths,
+		"components": map[string]any{"schemas": g.components},
+	}
+	b, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+func jsonResponse(description string, schema any) map[string]any {
+	return map[string]any{
+		"description": description,
+		"content":     map[string]any{"application/json": map[string]any{"schema": schema}},
+	}
+}
+
+// schemaGenerator builds schemas for Go types the way encoding/json marshals them. Named structs are added to the
+// components and referenced, so their names must be unique across packages.
+type schemaGenerator struct {
+	components map[string]any
+	pkgs       map[string]string // package path of each component, to detect duplicate names
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) (map[string]any, error) {
+	if t.Kind() == reflect.Pointer {
+		s, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return withNullable(s), nil
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}, nil
+	case t.Implements(marshalerType) && t.Kind() != reflect.Struct:
+		// Types such as enums that marshal themselves as strings
+		return map[string]any{"type": "string"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16,
+		reflect.Uint32:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Int64, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.Slice, reflect.Array:
+		items, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Elem().Kind() == reflect.Interface {
+			return map[string]any{"type": "object"}, nil
+		}
+		values, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "object", "additionalProperties": values}, nil
+	case reflect.Interface:
+		return map[string]any{}, nil
+	case reflect.Struct:
+		return g.structRef(t)
+	}
+	return nil, fmt.Errorf("no schema for type %s", t)
+}
+
+func (g *schemaGenerator) structRef(t reflect.Type) (map[string]any, error) {
+	ref := map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	if pkg, ok := g.pkgs[t.Name()]; ok {
+		if pkg != t.PkgPath() {
+			return nil, fmt.Errorf("schema name %s is used by %s and %s", t.Name(), pkg, t.PkgPath())
+		}
+		return ref, nil
+	}
+	g.pkgs[t.Name()] = t.PkgPath()
+
+	properties := make(map[string]any)
+	required := make([]string, 0)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s, err := g.schema(f.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s.%s: %w", t.Name(), f.Name, err)
+		}
+		if k := f.Type.Kind(); k == reflect.Slice || k == reflect.Map {
+			s = withNullable(s) // nil slices and maps are marshalled as null
+		}
+		properties[name] = s
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+	g.components[t.Name()] = map[string]any{"type": "object", "properties": properties, "required": required}
+	return ref, nil
+}
+
+// withNullable marks a schema as nullable, wrapping references since siblings of $ref are ignored
+func withNullable(s map[string]any) map[string]any {
+	if _, ok := s["$ref"]; ok {
+		return map[string]any{"allOf": []any{s}, "nullable": true}
+	}
+	s["nullable"] = true
+	return s
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "update the checked-in OpenAPI spec")
+
+func TestOpenAPISpec(t *testing.T) {
+	generated, err := GenerateOpenAPI()
+	assert.NoError(t, err)
+
+	if *update {
+		assert.NoError(t, os.WriteFile("openapi.json", generated, 0644))
+	}
+
+	checkedIn, err := os.ReadFile("openapi.json")
+	assert.NoError(t, err)
+	assert.Equal(t, string(generated), string(checkedIn),
+		"openapi.json is out of date, run: go test ./pkg/api -run TestOpenAPISpec -update")
+}
+
+// TestRoutesMatchSpec checks that every path in the spec is dispatched to its own route and that every route is in
+// the spec
+func TestRoutesMatchSpec(t *testing.T) {
+	var spec struct {
+		Paths map[string]struct {
+			Get *struct {
+				Parameters []struct {
+					Name string `json:"name"`
+					In   string `json:"in"`
+				} `json:"parameters"`
+			} `json:"get"`
+		} `json:"paths"`
+	}
+	assert.NoError(t, json.Unmarshal(openAPISpec, &spec))
+	assert.Len(t, spec.Paths, len(Routes))
+
+	for i, route := range Routes {
+		t.Run(route.Path, func(t *testing.T) {
+			item, ok := spec.Paths[route.Path]
+			if !assert.True(t, ok, "route is missing from the spec") || !assert.NotNil(t, item.Get) {
+				return
+			}
+
+			params := PathParams(route.Path)
+			assert.Equal(t, route.Re.NumSubexp(), len(params), "regex groups and path parameters differ")
+			specParams := make([]string, 0)
+			for _, p := range item.Get.Parameters {
+				if p.In == "path" {
+					specParams = append(specParams, p.Name)
+				}
+			}
+			assert.Equal(t, params, specParams)
+
+			// A concrete path for the template must be dispatched to this route and not an earlier one
+			path := route.Path
+			values := make([]string, len(params))
+			for j, p := range params {
+				values[j] = "v23.2." + string(rune('1'+j))
+				path = strings.Replace(path, "{"+p+"}", values[j], 1)
+			}
+			matches := route.Re.FindStringSubmatch(path)
+			if assert.NotNil(t, matches) {
+				assert.Equal(t, values, matches[1:])
+			}
+			for _, earlier := range Routes[:i] {
+				assert.False(t, earlier.Re.MatchString(path), "dispatched to %s instead", earlier.Path)
+			}
+		})
+	}
+}
+
+func TestOpenAPIRoute(t *testing.T) {
+	w := httptest.NewRecorder()
+	newFakeHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, openAPISpec, w.Body.Bytes())
+}
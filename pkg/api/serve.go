@@ -3,51 +3,293 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jonstjohn/crdb-settings/pkg/catalog"
+	"github.com/jonstjohn/crdb-settings/pkg/dbpgx"
+	"github.com/jonstjohn/crdb-settings/pkg/keywords"
 	"github.com/jonstjohn/crdb-settings/pkg/metrics"
 	"github.com/jonstjohn/crdb-settings/pkg/releases"
+	"github.com/jonstjohn/crdb-settings/pkg/search"
+	"github.com/jonstjohn/crdb-settings/pkg/sessionvars"
 	"github.com/jonstjohn/crdb-settings/pkg/settings"
 	"net/http"
+	"net/url"
 	"regexp"
+	"strconv"
 )
 
+// NextTokenHeader holds the token for the next page of a paginated list, pass it as the next query parameter
+const NextTokenHeader = "X-Next-Token"
+
 var (
-	SettingsReleaseReWithRelease  = regexp.MustCompile(`^/settings/release/(.+)$`)
-	SettingsCompareReWithReleases = regexp.MustCompile(`^/settings/compare/(.+)\.\.(.+)$`)
-	SettingsHistoryReWithSetting  = regexp.MustCompile(`^/settings/history/(.+)$`)
-	SettingsDetailReWithSetting   = regexp.MustCompile(`^/settings/detail/(.+)$`)
-	ReleasesRe                    = regexp.MustCompile(`^/releases/list$`)
-	MetricsReleaseReWithRelease   = regexp.MustCompile(`^/metrics/release/(.+)$`)
-	MetricsCompareReWithReleases  = regexp.MustCompile(`^/metrics/compare/(.+)\.\.(.+)$`)
-	//	MetricsHistoryReWithSetting   = regexp.MustCompile(`^/metrics/history/(.+)$`)
-	//	MetricsDetailReWithSetting    = regexp.MustCompile(`^/metrics/detail/(.+)$`)
+	SettingsReleaseReWithRelease     = regexp.MustCompile(`^/settings/release/(.+)$`)
+	SettingsCompareReWithReleases    = regexp.MustCompile(`^/settings/compare/(.+)\.\.(.+)$`)
+	SettingsCompareRe                = regexp.MustCompile(`^/settings/compare$`)
+	SettingsHistoryReWithSetting     = regexp.MustCompile(`^/settings/history/(.+)$`)
+	SettingsDetailReWithSetting      = regexp.MustCompile(`^/settings/detail/(.+)$`)
+	SettingsSummaryRe                = regexp.MustCompile(`^/settings/summary$`)
+	SettingsSummaryReWithSetting     = regexp.MustCompile(`^/settings/summary/(.+)$`)
+	ReleasesRe                       = regexp.MustCompile(`^/releases/list$`)
+	MetricsReleaseReWithRelease      = regexp.MustCompile(`^/metrics/release/(.+)$`)
+	MetricsCompareReWithReleases     = regexp.MustCompile(`^/metrics/compare/(.+)\.\.(.+)$`)
+	MetricsHistoryReWithMetric       = regexp.MustCompile(`^/metrics/history/(.+)$`)
+	MetricsDetailReWithMetric        = regexp.MustCompile(`^/metrics/detail/(.+)$`)
+	SessionVarsReleaseReWithRelease  = regexp.MustCompile(`^/sessionvars/release/(.+)$`)
+	SessionVarsCompareReWithReleases = regexp.MustCompile(`^/sessionvars/compare/(.+)\.\.(.+)$`)
+	SessionVarsDetailReWithVariable  = regexp.MustCompile(`^/sessionvars/detail/(.+)$`)
+	CatalogReleaseReWithRelease      = regexp.MustCompile(`^/catalog/release/(.+)$`)
+	CatalogCompareReWithReleases     = regexp.MustCompile(`^/catalog/compare/(.+)\.\.(.+)$`)
+	KeywordsReleaseReWithRelease     = regexp.MustCompile(`^/keywords/release/(.+)$`)
+	KeywordsCompareReWithReleases    = regexp.MustCompile(`^/keywords/compare/(.+)\.\.(.+)$`)
+	SearchRe                         = regexp.MustCompile(`^/search$`)
+	OpenAPIRe                        = regexp.MustCompile(`^/openapi\.json$`)
 )
 
-func Serve(url string) {
-	mux := http.NewServeMux()
-	mux.Handle("/", &SettingsHandler{Url: url})
-	http.ListenAndServe(":8080", mux)
+// Route is a GET route of the API. Requests are dispatched to the first route that matches and the routes are
+// used to generate the OpenAPI spec, so every route must be listed here.
+type Route struct {
+	Path     string // OpenAPI path template, with parameters in the order of the regular expression groups
+	Re       *regexp.Regexp
+	Summary  string
+	Query    []QueryParam
+	Headers  map[string]string // response headers and their descriptions
+	Response any               // a value of the response body type, used for the response schema
+	Handle   func(h *SettingsHandler, w http.ResponseWriter, r *http.Request)
+}
+
+// QueryParam is an optional query parameter of a route
+type QueryParam struct {
+	Name        string
+	Type        string // OpenAPI type
+	Enum        []string
+	Description string
+}
+
+var Routes = []Route{
+	{
+		Path: "/settings/release/{release}", Re: SettingsReleaseReWithRelease,
+		Summary: "List the settings for a release", Response: settings.ReleaseSettings{},
+		Query: []QueryParam{
+			{Name: "public", Type: "boolean", Description: "Only public or non-public settings"},
+			{Name: "type", Type: "string", Description: "Setting type, such as 'b' or 'd'"},
+			{Name: "variable", Type: "string",
+				Description: "Variable prefix, or a glob such as 'kv.rangefeed.*' when it contains '*' or '?'"},
+			{Name: "description", Type: "string", Description: "Case-insensitive description substring"},
+			{Name: "sort", Type: "string", Enum: []string{"public", "variable", "type", "value"},
+				Description: "Sort field, public sorts public settings first and is the default"},
+			{Name: "order", Type: "string", Enum: []string{"asc", "desc"}, Description: "Sort direction"},
+			{Name: "limit", Type: "integer", Description: "Maximum number of settings, all settings if not set"},
+			{Name: "next", Type: "string", Description: "Token for the next page from the X-Next-Token header"},
+		},
+		Headers: map[string]string{NextTokenHeader: "Token for the next page, if there are more settings"},
+		Handle:  (*SettingsHandler).ListSettingsForRelease,
+	},
+	{
+		Path: "/settings/compare/{release1}..{release2}", Re: SettingsCompareReWithReleases,
+		Summary: "Compare the settings of two releases", Response: settings.ComparedReleaseSettings{},
+		Handle: (*SettingsHandler).CompareSettingsForReleases,
+	},
+	{
+		Path: "/settings/compare", Re: SettingsCompareRe,
+		Summary: "Compare the settings of several releases", Response: settings.MultiReleaseComparison{},
+		Query: []QueryParam{
+			{Name: "releases", Type: "string",
+				Description: "Comma separated releases in upgrade order, or a range such as 'v23.1.0...v24.1.0'"},
+		},
+		Handle: (*SettingsHandler).CompareSettingsForReleaseList,
+	},
+	{
+		Path: "/settings/history/{setting}", Re: SettingsHistoryReWithSetting,
+		Summary: "Get the history of a setting across releases", Response: settings.SettingHistory{},
+		Handle: (*SettingsHandler).HistoryForSetting,
+	},
+	{
+		Path: "/releases/list", Re: ReleasesRe,
+		Summary: "List releases", Response: releases.Releases{},
+		Handle: (*SettingsHandler).ListReleases,
+	},
+	{
+		Path: "/settings/detail/{setting}", Re: SettingsDetailReWithSetting,
+		Summary: "Get the detail of a setting", Response: settings.Detail{},
+		Handle: (*SettingsHandler).SettingDetail,
+	},
+	{
+		Path: "/settings/summary", Re: SettingsSummaryRe,
+		Summary: "List the summaries of all settings", Response: settings.Summaries{},
+		Handle: (*SettingsHandler).ListSettingSummaries,
+	},
+	{
+		Path: "/settings/summary/{setting}", Re: SettingsSummaryReWithSetting,
+		Summary: "Get the summary of a setting", Response: settings.Summary{},
+		Handle: (*SettingsHandler).SettingSummary,
+	},
+	{
+		Path: "/metrics/release/{release}", Re: MetricsReleaseReWithRelease,
+		Summary: "List the metrics for a release", Response: metrics.Metrics{},
+		Handle: (*SettingsHandler).ListMetricsForRelease,
+	},
+	{
+		Path: "/metrics/compare/{release1}..{release2}", Re: MetricsCompareReWithReleases,
+		Summary: "Compare the metrics of two releases", Response: metrics.ComparedReleaseMetrics{},
+		Handle: (*SettingsHandler).CompareMetricsForReleases,
+	},
+	{
+		Path: "/metrics/detail/{metric}", Re: MetricsDetailReWithMetric,
+		Summary: "Get the help, type and releases of a metric", Response: metrics.MetricDetail{},
+		Handle: (*SettingsHandler).MetricDetail,
+	},
+	{
+		Path: "/metrics/history/{metric}", Re: MetricsHistoryReWithMetric,
+		Summary: "Get the history of a metric across releases", Response: metrics.MetricHistory{},
+		Handle: (*SettingsHandler).HistoryForMetric,
+	},
+	{
+		Path: "/sessionvars/release/{release}", Re: SessionVarsReleaseReWithRelease,
+		Summary: "List the session variables for a release", Response: []sessionvars.SessionVariable{},
+		Handle: (*SettingsHandler).ListSessionVariablesForRelease,
+	},
+	{
+		Path: "/sessionvars/compare/{release1}..{release2}", Re: SessionVarsCompareReWithReleases,
+		Summary:  "Compare the session variables of two releases",
+		Response: sessionvars.ComparedReleaseSessionVariables{},
+		Handle:   (*SettingsHandler).CompareSessionVariablesForReleases,
+	},
+	{
+		Path: "/sessionvars/detail/{variable}", Re: SessionVarsDetailReWithVariable,
+		Summary:  "Get the type, description and default value per release of a session variable",
+		Response: sessionvars.SessionVariableDetail{},
+		Handle:   (*SettingsHandler).SessionVariableDetail,
+	},
+	{
+		Path: "/catalog/release/{release}", Re: CatalogReleaseReWithRelease,
+		Summary:  "List the virtual tables and builtin functions for a release",
+		Response: catalog.Catalog{},
+		Handle:   (*SettingsHandler).GetCatalogForRelease,
+	},
+	{
+		Path: "/catalog/compare/{release1}..{release2}", Re: CatalogCompareReWithReleases,
+		Summary:  "Compare the virtual tables and builtin functions of two releases",
+		Response: catalog.ComparedReleaseCatalogs{},
+		Handle:   (*SettingsHandler).CompareCatalogsForReleases,
+	},
+	{
+		Path: "/keywords/release/{release}", Re: KeywordsReleaseReWithRelease,
+		Summary: "List the SQL keywords and their categories for a release", Response: []keywords.Keyword{},
+		Handle: (*SettingsHandler).ListKeywordsForRelease,
+	},
+	{
+		Path: "/keywords/compare/{release1}..{release2}", Re: KeywordsCompareReWithReleases,
+		Summary:  "Compare the SQL keywords of two releases, including the newly reserved keywords",
+		Response: keywords.ComparedReleaseKeywords{},
+		Handle:   (*SettingsHandler).CompareKeywordsForReleases,
+	},
+	{
+		Path: "/search", Re: SearchRe,
+		Summary: "Search settings and metrics by name, description and help text", Response: search.Results{},
+		Query: []QueryParam{
+			{Name: "q", Type: "string", Description: "Search terms, every term must match the name or description"},
+			{Name: "limit", Type: "integer", Description: "Maximum number of results, 25 if not set and at most 100"},
+		},
+		Handle: (*SettingsHandler).SearchSettingsAndMetrics,
+	},
+	{
+		Path: "/openapi.json", Re: OpenAPIRe,
+		Summary: "Get this OpenAPI specification", Response: map[string]any{},
+		Handle: (*SettingsHandler).OpenAPI,
+	},
+}
+
+// SettingsProvider is the part of settings.Manager used by the API
+type SettingsProvider interface {
+	ListSettingsForRelease(release string, opts settings.ListOptions) (settings.SettingsPage, error)
+	CompareSettingsForReleases(r1 string, r2 string) (settings.ComparedReleaseSettings, error)
+	CompareSettingsForReleaseList(expr string) (settings.MultiReleaseComparison, error)
+	HistoryForSetting(setting string) (settings.SettingHistory, error)
+	GetSettingDetail(setting string) (settings.Detail, error)
+	GetSettingSummaries() (settings.Summaries, error)
+	GetSettingSummary(setting string) (settings.Summary, error)
+}
+
+// MetricsProvider is the part of metrics.Manager used by the API
+type MetricsProvider interface {
+	GetMetricsForRelease(release string) ([]metrics.Metric, error)
+	CompareMetricsForReleases(r1 string, r2 string) (metrics.ComparedReleaseMetrics, error)
+	GetMetricDetail(metric string) (metrics.MetricDetail, error)
+	HistoryForMetric(metric string) (metrics.MetricHistory, error)
+}
+
+// SessionVariablesProvider is the part of sessionvars.Manager used by the API
+type SessionVariablesProvider interface {
+	GetSessionVariablesForRelease(release string) ([]sessionvars.SessionVariable, error)
+	CompareSessionVariablesForReleases(r1 string, r2 string) (sessionvars.ComparedReleaseSessionVariables, error)
+	GetSessionVariableDetail(variable string) (sessionvars.SessionVariableDetail, error)
+}
+
+// CatalogProvider is the part of catalog.Manager used by the API
+type CatalogProvider interface {
+	GetCatalogForRelease(release string) (catalog.Catalog, error)
+	CompareCatalogsForReleases(r1 string, r2 string) (catalog.ComparedReleaseCatalogs, error)
+}
+
+// KeywordsProvider is the part of keywords.Manager used by the API
+type KeywordsProvider interface {
+	GetKeywordsForRelease(release string) ([]keywords.Keyword, error)
+	CompareKeywordsForReleases(r1 string, r2 string) (keywords.ComparedReleaseKeywords, error)
+}
+
+// SearchProvider is the part of search.Manager used by the API
+type SearchProvider interface {
+	Search(query string, limit int) (search.Results, error)
 }
 
+// SettingsHandler serves the API using managers that are built once and shared by all requests. The providers can
+// be replaced with fakes in tests.
 type SettingsHandler struct {
-	Url string
+	Settings         SettingsProvider
+	Metrics          MetricsProvider
+	SessionVariables SessionVariablesProvider
+	Catalog          CatalogProvider
+	Keywords         KeywordsProvider
+	Releases         releases.Provider
+	Search           SearchProvider
+	pool             *pgxpool.Pool
+}
+
+// NewSettingsHandler creates a single pool for the database URL and builds the managers over it. Close the handler
+// to close the pool.
+func NewSettingsHandler(url string) (*SettingsHandler, error) {
+	pool, err := dbpgx.NewPoolFromUrl(url)
+	if err != nil {
+		return nil, err
+	}
+	return &SettingsHandler{
+		Settings:         settings.NewSettingsManagerFromPool(pool),
+		Metrics:          metrics.NewManagerFromPool(pool),
+		SessionVariables: sessionvars.NewManagerFromPool(pool),
+		Catalog:          catalog.NewManagerFromPool(pool),
+		Keywords:         keywords.NewManagerFromPool(pool),
+		Releases:         releases.NewReleasesManagerFromPool(pool),
+		Search:           search.NewManagerFromPool(pool),
+		pool:             pool,
+	}, nil
+}
+
+// Close closes the pool created by NewSettingsHandler
+func (h *SettingsHandler) Close() {
+	if h.pool != nil {
+		h.pool.Close()
+	}
 }
 
 func (h *SettingsHandler) HistoryForSetting(w http.ResponseWriter, r *http.Request) {
 	matches := SettingsHistoryReWithSetting.FindStringSubmatch(r.URL.Path)
 	if len(matches) != 2 {
-		w.WriteHeader(http.StatusOK) // TODO
-		w.Write([]byte("Release must be included"))
+		ErrorHandler(w, &BadRequestError{Message: "setting must be included"})
 		return
 	}
 	setting := matches[1]
 
-	sm, err := settings.NewSettingsManager(h.Url)
-	if err != nil {
-		ErrorHandler(w, err)
-		return
-	}
-
-	s, err := sm.HistoryForSetting(setting)
+	s, err := h.Settings.HistoryForSetting(setting)
 	if err != nil {
 		ErrorHandler(w, err)
 		return
@@ -67,20 +309,30 @@ func (h *SettingsHandler) HistoryForSetting(w http.ResponseWriter, r *http.Reque
 func (h *SettingsHandler) CompareSettingsForReleases(w http.ResponseWriter, r *http.Request) {
 	matches := SettingsCompareReWithReleases.FindStringSubmatch(r.URL.Path)
 	if len(matches) != 3 {
-		w.WriteHeader(http.StatusOK) // TODO
-		w.Write([]byte("Release must be included"))
+		ErrorHandler(w, &BadRequestError{Message: "release must be included"})
 		return
 	}
 	r1 := matches[1]
 	r2 := matches[2]
 
-	sm, err := settings.NewSettingsManager(h.Url)
+	s, err := h.Settings.CompareSettingsForReleases(r1, r2)
+	if err != nil {
+		ErrorHandler(w, err)
+		return
+	}
+	jsonBytes, err := json.Marshal(s)
 	if err != nil {
 		ErrorHandler(w, err)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonBytes)
 
-	s, err := sm.CompareSettingsForReleases(r1, r2)
+}
+
+func (h *SettingsHandler) CompareSettingsForReleaseList(w http.ResponseWriter, r *http.Request) {
+	s, err := h.Settings.CompareSettingsForReleaseList(r.URL.Query().Get("releases"))
 	if err != nil {
 		ErrorHandler(w, err)
 		return
@@ -93,45 +345,75 @@ func (h *SettingsHandler) CompareSettingsForReleases(w http.ResponseWriter, r *h
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 	w.Write(jsonBytes)
-
 }
 
 func (h *SettingsHandler) ListSettingsForRelease(w http.ResponseWriter, r *http.Request) {
 	matches := SettingsReleaseReWithRelease.FindStringSubmatch(r.URL.Path)
 	if len(matches) != 2 {
-		w.WriteHeader(http.StatusOK) // TODO
-		w.Write([]byte("Release must be included"))
+		ErrorHandler(w, &BadRequestError{Message: "release must be included"})
 		return
 	}
 	release := matches[1]
 
-	sm, err := settings.NewSettingsManager(h.Url)
+	opts, err := listOptionsFromQuery(r.URL.Query())
 	if err != nil {
 		ErrorHandler(w, err)
 		return
 	}
-	s, err := sm.GetSettingsForRelease(release)
+
+	page, err := h.Settings.ListSettingsForRelease(release, opts)
 	if err != nil {
 		ErrorHandler(w, err)
 		return
 	}
-	jsonBytes, err := json.Marshal(s)
+	jsonBytes, err := json.Marshal(page.Settings)
 	if err != nil {
 		ErrorHandler(w, err)
 		return
 	}
+	if page.Next != "" {
+		w.Header().Set(NextTokenHeader, page.Next)
+	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 	w.Write(jsonBytes)
 }
 
-func (h *SettingsHandler) ListReleases(w http.ResponseWriter, r *http.Request) {
-	rm, err := releases.NewReleasesManager(h.Url)
-	if err != nil {
-		ErrorHandler(w, err)
-		return
+// listOptionsFromQuery parses the query parameters of the settings list
+func listOptionsFromQuery(q url.Values) (settings.ListOptions, error) {
+	opts := settings.ListOptions{
+		Type:        q.Get("type"),
+		Variable:    q.Get("variable"),
+		Description: q.Get("description"),
+		Sort:        q.Get("sort"),
+		Next:        q.Get("next"),
+	}
+	if v := q.Get("public"); v != "" {
+		public, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, &BadRequestError{Message: fmt.Sprintf("invalid public '%s', expected true or false", v)}
+		}
+		opts.Public = &public
+	}
+	switch order := q.Get("order"); order {
+	case "", "asc":
+	case "desc":
+		opts.Desc = true
+	default:
+		return opts, &BadRequestError{Message: fmt.Sprintf("invalid order '%s', expected asc or desc", order)}
 	}
-	releases, err := rm.GetReleases()
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, &BadRequestError{Message: fmt.Sprintf("invalid limit '%s'", v)}
+		}
+		opts.Limit = limit
+	}
+	return opts, nil
+}
+
+func (h *SettingsHandler) ListReleases(w http.ResponseWriter, r *http.Request) {
+	releases, err := h.Releases.GetReleases()
 	if err != nil {
 		ErrorHandler(w, err)
 		return
@@ -149,18 +431,32 @@ func (h *SettingsHandler) ListReleases(w http.ResponseWriter, r *http.Request) {
 func (h *SettingsHandler) SettingDetail(w http.ResponseWriter, r *http.Request) {
 	matches := SettingsDetailReWithSetting.FindStringSubmatch(r.URL.Path)
 	if len(matches) != 2 {
-		w.WriteHeader(http.StatusOK) // TODO
-		w.Write([]byte("Release must be included"))
+		ErrorHandler(w, &BadRequestError{Message: "setting must be included"})
 		return
 	}
 	setting := matches[1]
 
-	sm, err := settings.NewSettingsManager(h.Url)
+	s, err := h.Settings.GetSettingDetail(setting)
+	if err != nil {
+		ErrorHandler(w, err)
+		return
+	}
+	jsonBytes, err := json.Marshal(s)
+	if err != nil {
+		ErrorHandler(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(jsonBytes)
 	if err != nil {
 		ErrorHandler(w, err)
 		return
 	}
-	s, err := sm.GetSettingDetail(setting)
+}
+
+func (h *SettingsHandler) ListSettingSummaries(w http.ResponseWriter, r *http.Request) {
+	s, err := h.Settings.GetSettingSummaries()
 	if err != nil {
 		ErrorHandler(w, err)
 		return
@@ -172,34 +468,94 @@ func (h *SettingsHandler) SettingDetail(w http.ResponseWriter, r *http.Request)
 	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
-	_, err = w.Write(jsonBytes)
+	w.Write(jsonBytes)
+}
+
+func (h *SettingsHandler) SettingSummary(w http.ResponseWriter, r *http.Request) {
+	matches := SettingsSummaryReWithSetting.FindStringSubmatch(r.URL.Path)
+	if len(matches) != 2 {
+		ErrorHandler(w, &BadRequestError{Message: "setting must be included"})
+		return
+	}
+	setting := matches[1]
+
+	s, err := h.Settings.GetSettingSummary(setting)
+	if err != nil {
+		ErrorHandler(w, err)
+		return
+	}
+	jsonBytes, err := json.Marshal(s)
 	if err != nil {
 		ErrorHandler(w, err)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonBytes)
 }
 
 func (h *SettingsHandler) ListMetricsForRelease(w http.ResponseWriter, r *http.Request) {
 	matches := MetricsReleaseReWithRelease.FindStringSubmatch(r.URL.Path)
 	if len(matches) != 2 {
-		w.WriteHeader(http.StatusOK) // TODO
-		w.Write([]byte("Release must be included"))
+		ErrorHandler(w, &BadRequestError{Message: "release must be included"})
 		return
 	}
 	release := matches[1]
 
-	m, err := metrics.NewManager(h.Url)
+	ms, err := h.Metrics.GetMetricsForRelease(release)
+	if err != nil {
+		ErrorHandler(w, err)
+		return
+	}
+	jsonBytes, err := json.Marshal(ms)
+	if err != nil {
+		ErrorHandler(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonBytes)
+}
+
+func (h *SettingsHandler) CompareMetricsForReleases(w http.ResponseWriter, r *http.Request) {
+	matches := MetricsCompareReWithReleases.FindStringSubmatch(r.URL.Path)
+	if len(matches) != 3 {
+		ErrorHandler(w, &BadRequestError{Message: "release must be included"})
+		return
+	}
+	r1 := matches[1]
+	r2 := matches[2]
+
+	s, err := h.Metrics.CompareMetricsForReleases(r1, r2)
 	if err != nil {
 		ErrorHandler(w, err)
 		return
 	}
+	jsonBytes, err := json.Marshal(s)
+	if err != nil {
+		ErrorHandler(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonBytes)
 
-	ms, err := m.GetMetricsForRelease(release)
+}
+
+func (h *SettingsHandler) MetricDetail(w http.ResponseWriter, r *http.Request) {
+	matches := MetricsDetailReWithMetric.FindStringSubmatch(r.URL.Path)
+	if len(matches) != 2 {
+		ErrorHandler(w, &BadRequestError{Message: "metric must be included"})
+		return
+	}
+	metric := matches[1]
+
+	d, err := h.Metrics.GetMetricDetail(metric)
 	if err != nil {
 		ErrorHandler(w, err)
 		return
 	}
-	jsonBytes, err := json.Marshal(ms)
+	jsonBytes, err := json.Marshal(d)
 	if err != nil {
 		ErrorHandler(w, err)
 		return
@@ -209,28 +565,184 @@ func (h *SettingsHandler) ListMetricsForRelease(w http.ResponseWriter, r *http.R
 	w.Write(jsonBytes)
 }
 
-func (h *SettingsHandler) CompareMetricsForReleases(w http.ResponseWriter, r *http.Request) {
-	matches := MetricsCompareReWithReleases.FindStringSubmatch(r.URL.Path)
+func (h *SettingsHandler) HistoryForMetric(w http.ResponseWriter, r *http.Request) {
+	matches := MetricsHistoryReWithMetric.FindStringSubmatch(r.URL.Path)
+	if len(matches) != 2 {
+		ErrorHandler(w, &BadRequestError{Message: "metric must be included"})
+		return
+	}
+	metric := matches[1]
+
+	history, err := h.Metrics.HistoryForMetric(metric)
+	if err != nil {
+		ErrorHandler(w, err)
+		return
+	}
+	jsonBytes, err := json.Marshal(history)
+	if err != nil {
+		ErrorHandler(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonBytes)
+}
+
+func (h *SettingsHandler) ListSessionVariablesForRelease(w http.ResponseWriter, r *http.Request) {
+	matches := SessionVarsReleaseReWithRelease.FindStringSubmatch(r.URL.Path)
+	if len(matches) != 2 {
+		ErrorHandler(w, &BadRequestError{Message: "release must be included"})
+		return
+	}
+	release := matches[1]
+
+	vars, err := h.SessionVariables.GetSessionVariablesForRelease(release)
+	if err != nil {
+		ErrorHandler(w, err)
+		return
+	}
+	jsonBytes, err := json.Marshal(vars)
+	if err != nil {
+		ErrorHandler(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonBytes)
+}
+
+func (h *SettingsHandler) CompareSessionVariablesForReleases(w http.ResponseWriter, r *http.Request) {
+	matches := SessionVarsCompareReWithReleases.FindStringSubmatch(r.URL.Path)
 	if len(matches) != 3 {
-		w.WriteHeader(http.StatusOK) // TODO
-		w.Write([]byte("Release must be included"))
+		ErrorHandler(w, &BadRequestError{Message: "release must be included"})
 		return
 	}
 	r1 := matches[1]
 	r2 := matches[2]
 
-	m, err := metrics.NewManager(h.Url)
+	c, err := h.SessionVariables.CompareSessionVariablesForReleases(r1, r2)
+	if err != nil {
+		ErrorHandler(w, err)
+		return
+	}
+	jsonBytes, err := json.Marshal(c)
+	if err != nil {
+		ErrorHandler(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonBytes)
+}
+
+func (h *SettingsHandler) SessionVariableDetail(w http.ResponseWriter, r *http.Request) {
+	matches := SessionVarsDetailReWithVariable.FindStringSubmatch(r.URL.Path)
+	if len(matches) != 2 {
+		ErrorHandler(w, &BadRequestError{Message: "session variable must be included"})
+		return
+	}
+	variable := matches[1]
+
+	d, err := h.SessionVariables.GetSessionVariableDetail(variable)
+	if err != nil {
+		ErrorHandler(w, err)
+		return
+	}
+	jsonBytes, err := json.Marshal(d)
 	if err != nil {
 		ErrorHandler(w, err)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonBytes)
+}
+
+func (h *SettingsHandler) GetCatalogForRelease(w http.ResponseWriter, r *http.Request) {
+	matches := CatalogReleaseReWithRelease.FindStringSubmatch(r.URL.Path)
+	if len(matches) != 2 {
+		ErrorHandler(w, &BadRequestError{Message: "release must be included"})
+		return
+	}
+	release := matches[1]
 
-	s, err := m.CompareMetricsForReleases(r1, r2)
+	c, err := h.Catalog.GetCatalogForRelease(release)
 	if err != nil {
 		ErrorHandler(w, err)
 		return
 	}
-	jsonBytes, err := json.Marshal(s)
+	jsonBytes, err := json.Marshal(c)
+	if err != nil {
+		ErrorHandler(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonBytes)
+}
+
+func (h *SettingsHandler) CompareCatalogsForReleases(w http.ResponseWriter, r *http.Request) {
+	matches := CatalogCompareReWithReleases.FindStringSubmatch(r.URL.Path)
+	if len(matches) != 3 {
+		ErrorHandler(w, &BadRequestError{Message: "release must be included"})
+		return
+	}
+	r1 := matches[1]
+	r2 := matches[2]
+
+	c, err := h.Catalog.CompareCatalogsForReleases(r1, r2)
+	if err != nil {
+		ErrorHandler(w, err)
+		return
+	}
+	jsonBytes, err := json.Marshal(c)
+	if err != nil {
+		ErrorHandler(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonBytes)
+}
+
+func (h *SettingsHandler) ListKeywordsForRelease(w http.ResponseWriter, r *http.Request) {
+	matches := KeywordsReleaseReWithRelease.FindStringSubmatch(r.URL.Path)
+	if len(matches) != 2 {
+		ErrorHandler(w, &BadRequestError{Message: "release must be included"})
+		return
+	}
+	release := matches[1]
+
+	kws, err := h.Keywords.GetKeywordsForRelease(release)
+	if err != nil {
+		ErrorHandler(w, err)
+		return
+	}
+	jsonBytes, err := json.Marshal(kws)
+	if err != nil {
+		ErrorHandler(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonBytes)
+}
+
+func (h *SettingsHandler) CompareKeywordsForReleases(w http.ResponseWriter, r *http.Request) {
+	matches := KeywordsCompareReWithReleases.FindStringSubmatch(r.URL.Path)
+	if len(matches) != 3 {
+		ErrorHandler(w, &BadRequestError{Message: "release must be included"})
+		return
+	}
+	r1 := matches[1]
+	r2 := matches[2]
+
+	c, err := h.Keywords.CompareKeywordsForReleases(r1, r2)
+	if err != nil {
+		ErrorHandler(w, err)
+		return
+	}
+	jsonBytes, err := json.Marshal(c)
 	if err != nil {
 		ErrorHandler(w, err)
 		return
@@ -238,37 +750,57 @@ func (h *SettingsHandler) CompareMetricsForReleases(w http.ResponseWriter, r *ht
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 	w.Write(jsonBytes)
+}
 
+func (h *SettingsHandler) SearchSettingsAndMetrics(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := 0
+	if v := q.Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil {
+			ErrorHandler(w, &BadRequestError{Message: fmt.Sprintf("invalid limit '%s'", v)})
+			return
+		}
+		limit = l
+	}
+
+	results, err := h.Search.Search(q.Get("q"), limit)
+	if err != nil {
+		ErrorHandler(w, err)
+		return
+	}
+	jsonBytes, err := json.Marshal(results)
+	if err != nil {
+		ErrorHandler(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonBytes)
 }
 
-func ErrorHandler(w http.ResponseWriter, err error) {
-	w.WriteHeader(http.StatusBadGateway) // TODO
-	w.Write([]byte(fmt.Sprintf("%v", err)))
-	return
+// OpenAPI serves the checked-in OpenAPI spec, which is kept in sync with Routes by GenerateOpenAPI
+func (h *SettingsHandler) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(openAPISpec)
 }
 
 func (h *SettingsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-	switch {
-	case r.Method == http.MethodGet && SettingsReleaseReWithRelease.MatchString(r.URL.Path):
-		h.ListSettingsForRelease(w, r)
-		return
-	case r.Method == http.MethodGet && SettingsCompareReWithReleases.MatchString(r.URL.Path):
-		h.CompareSettingsForReleases(w, r)
-		return
-	case r.Method == http.MethodGet && SettingsHistoryReWithSetting.MatchString(r.URL.Path):
-		h.HistoryForSetting(w, r)
-		return
-	case r.Method == http.MethodGet && ReleasesRe.MatchString(r.URL.Path):
-		h.ListReleases(w, r)
-	case r.Method == http.MethodGet && SettingsDetailReWithSetting.MatchString(r.URL.Path):
-		h.SettingDetail(w, r)
-	case r.Method == http.MethodGet && MetricsReleaseReWithRelease.MatchString(r.URL.Path):
-		h.ListMetricsForRelease(w, r)
-	case r.Method == http.MethodGet && MetricsCompareReWithReleases.MatchString(r.URL.Path):
-		h.CompareMetricsForReleases(w, r)
-	default:
-		w.WriteHeader(http.StatusNotFound)
+	w.Header().Set("Access-Control-Expose-Headers", NextTokenHeader)
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, ErrorBody{
+			Code: CodeMethodNotAllowed, Message: fmt.Sprintf("method %s is not allowed", r.Method)})
 		return
 	}
+	for _, route := range Routes {
+		if route.Re.MatchString(r.URL.Path) {
+			route.Handle(h, w, r)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, ErrorBody{
+		Code: CodeNotFound, Message: fmt.Sprintf("no route for '%s'", r.URL.Path)})
 }
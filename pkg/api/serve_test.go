@@ -1,10 +1,167 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
+	"github.com/jonstjohn/crdb-settings/pkg/catalog"
+	"github.com/jonstjohn/crdb-settings/pkg/keywords"
+	"github.com/jonstjohn/crdb-settings/pkg/metrics"
+	"github.com/jonstjohn/crdb-settings/pkg/releases"
+	"github.com/jonstjohn/crdb-settings/pkg/search"
+	"github.com/jonstjohn/crdb-settings/pkg/sessionvars"
+	"github.com/jonstjohn/crdb-settings/pkg/settings"
 	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
+// fakeSettings serves settings from memory, only knowing about release v23.2.10 and setting sql.defaults.distsql
+type fakeSettings struct{}
+
+func (f *fakeSettings) ListSettingsForRelease(release string, opts settings.ListOptions) (settings.SettingsPage, error) {
+	if release != "v23.2.10" {
+		return settings.SettingsPage{}, &releases.UnknownReleaseError{Name: release}
+	}
+	if err := opts.Validate(); err != nil {
+		return settings.SettingsPage{}, err
+	}
+	page := settings.SettingsPage{
+		Settings: settings.ReleaseSettings{{ReleaseName: release, Variable: "sql.defaults.distsql", Value: "auto"}},
+	}
+	if opts.Limit == 1 {
+		page.Next = "next-token"
+	}
+	return page, nil
+}
+
+func (f *fakeSettings) CompareSettingsForReleases(r1 string, r2 string) (settings.ComparedReleaseSettings, error) {
+	return settings.ComparedReleaseSettings{}, errors.New("not implemented")
+}
+
+func (f *fakeSettings) CompareSettingsForReleaseList(expr string) (settings.MultiReleaseComparison, error) {
+	names, _, err := settings.ParseReleaseList(expr)
+	if err != nil {
+		return settings.MultiReleaseComparison{}, err
+	}
+	return settings.CompareMultipleReleaseSettings(names, make([]settings.ReleaseSettings, len(names))), nil
+}
+
+func (f *fakeSettings) HistoryForSetting(setting string) (settings.SettingHistory, error) {
+	return settings.SettingHistory{}, &settings.UnknownSettingError{Variable: setting}
+}
+
+func (f *fakeSettings) GetSettingDetail(setting string) (settings.Detail, error) {
+	return settings.Detail{}, &settings.UnknownSettingError{Variable: setting}
+}
+
+func (f *fakeSettings) GetSettingSummaries() (settings.Summaries, error) {
+	return settings.Summaries{{Variable: "sql.defaults.distsql", Value: "auto"}}, nil
+}
+
+func (f *fakeSettings) GetSettingSummary(setting string) (settings.Summary, error) {
+	return settings.Summary{}, &settings.UnknownSettingError{Variable: setting}
+}
+
+type fakeMetrics struct{}
+
+func (f *fakeMetrics) GetMetricsForRelease(release string) ([]metrics.Metric, error) {
+	return []metrics.Metric{{Name: "sys_uptime", Help: "Process uptime", Type: metrics.Gauge}}, nil
+}
+
+func (f *fakeMetrics) CompareMetricsForReleases(r1 string, r2 string) (metrics.ComparedReleaseMetrics, error) {
+	return metrics.ComparedReleaseMetrics{}, nil
+}
+
+func (f *fakeMetrics) GetMetricDetail(metric string) (metrics.MetricDetail, error) {
+	if metric != "sys_uptime" {
+		return metrics.MetricDetail{}, &metrics.UnknownMetricError{Name: metric}
+	}
+	return metrics.MetricDetail{Name: metric, Help: "Process uptime", Type: metrics.Gauge,
+		ReleaseNames: []string{"v23.2.10"}}, nil
+}
+
+func (f *fakeMetrics) HistoryForMetric(metric string) (metrics.MetricHistory, error) {
+	if metric != "sys_uptime" {
+		return metrics.MetricHistory{}, &metrics.UnknownMetricError{Name: metric}
+	}
+	return metrics.MetricHistory{Metric: metric, FirstSeen: "v23.2.10", LastSeen: "v23.2.10"}, nil
+}
+
+type fakeSessionVariables struct{}
+
+func (f *fakeSessionVariables) GetSessionVariablesForRelease(release string) ([]sessionvars.SessionVariable, error) {
+	if release != "v23.2.10" {
+		return nil, &releases.UnknownReleaseError{Name: release}
+	}
+	return []sessionvars.SessionVariable{{Variable: "default_transaction_isolation", Value: "serializable"}}, nil
+}
+
+func (f *fakeSessionVariables) CompareSessionVariablesForReleases(r1 string,
+	r2 string) (sessionvars.ComparedReleaseSessionVariables, error) {
+	return sessionvars.ComparedReleaseSessionVariables{}, nil
+}
+
+func (f *fakeSessionVariables) GetSessionVariableDetail(variable string) (sessionvars.SessionVariableDetail, error) {
+	if variable != "default_transaction_isolation" {
+		return sessionvars.SessionVariableDetail{}, &sessionvars.UnknownSessionVariableError{Variable: variable}
+	}
+	return sessionvars.SessionVariableDetail{Variable: variable,
+		Values: []sessionvars.ReleaseValue{{Release: "v23.2.10", Value: "serializable"}}}, nil
+}
+
+type fakeCatalog struct{}
+
+func (f *fakeCatalog) GetCatalogForRelease(release string) (catalog.Catalog, error) {
+	if release != "v23.2.10" {
+		return catalog.Catalog{}, &releases.UnknownReleaseError{Name: release}
+	}
+	return catalog.Catalog{Functions: []catalog.Function{{Name: "now", Signature: "() -> timestamptz"}}}, nil
+}
+
+func (f *fakeCatalog) CompareCatalogsForReleases(r1 string, r2 string) (catalog.ComparedReleaseCatalogs, error) {
+	return catalog.ComparedReleaseCatalogs{}, nil
+}
+
+type fakeKeywords struct{}
+
+func (f *fakeKeywords) GetKeywordsForRelease(release string) ([]keywords.Keyword, error) {
+	if release != "v23.2.10" {
+		return nil, &releases.UnknownReleaseError{Name: release}
+	}
+	return []keywords.Keyword{{Word: "select", Category: keywords.Reserved, Description: "reserved"}}, nil
+}
+
+func (f *fakeKeywords) CompareKeywordsForReleases(r1 string, r2 string) (keywords.ComparedReleaseKeywords, error) {
+	if r1 != "v23.2.10" {
+		return keywords.ComparedReleaseKeywords{}, &releases.UnknownReleaseError{Name: r1}
+	}
+	return keywords.ComparedReleaseKeywords{}, nil
+}
+
+type fakeReleases struct{}
+
+func (f *fakeReleases) GetReleases() (releases.Releases, error) {
+	return releases.Releases{{Name: "v23.2.10"}}, nil
+}
+
+// fakeSearch finds sql.defaults.distsql for any query, using the real query validation
+type fakeSearch struct{}
+
+func (f *fakeSearch) Search(query string, limit int) (search.Results, error) {
+	if len(search.Terms(query)) == 0 {
+		return nil, &search.InvalidQueryError{Message: "query must include at least one term"}
+	}
+	return search.Results{{Kind: search.KindSetting, Name: "sql.defaults.distsql", Score: 3,
+		Releases: []string{"v23.2.10"}}}, nil
+}
+
+func newFakeHandler() *SettingsHandler {
+	return &SettingsHandler{Settings: &fakeSettings{}, Metrics: &fakeMetrics{},
+		SessionVariables: &fakeSessionVariables{}, Catalog: &fakeCatalog{}, Keywords: &fakeKeywords{},
+		Releases: &fakeReleases{}, Search: &fakeSearch{}}
+}
+
 func TestSettingsCompareRegex(t *testing.T) {
 	url := "/settings/compare/v23.1.5..23.1.6"
 	assert.True(t, SettingsCompareReWithReleases.Match([]byte(url)))
@@ -12,3 +169,88 @@ func TestSettingsCompareRegex(t *testing.T) {
 	matches := SettingsCompareReWithReleases.FindStringSubmatch(url)
 	assert.Len(t, matches, 3)
 }
+
+func TestSettingsSummaryRegex(t *testing.T) {
+	assert.True(t, SettingsSummaryRe.MatchString("/settings/summary"))
+	assert.False(t, SettingsSummaryReWithSetting.MatchString("/settings/summary"))
+
+	matches := SettingsSummaryReWithSetting.FindStringSubmatch("/settings/summary/sql.distsql.num_runners")
+	assert.Len(t, matches, 2)
+	assert.Equal(t, "sql.distsql.num_runners", matches[1])
+}
+
+func TestSettingsHandler(t *testing.T) {
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/settings/release/v23.2.10", http.StatusOK},
+		{"/settings/release/v99.1.0", http.StatusNotFound},
+		{"/settings/release/v23.2.10?public=true&sort=variable&order=desc&limit=10", http.StatusOK},
+		{"/settings/release/v23.2.10?public=maybe", http.StatusBadRequest},
+		{"/settings/release/v23.2.10?order=up", http.StatusBadRequest},
+		{"/settings/release/v23.2.10?limit=ten", http.StatusBadRequest},
+		{"/settings/release/v23.2.10?sort=color", http.StatusBadRequest},
+		{"/settings/release/v23.2.10?next=%25%25", http.StatusBadRequest},
+		{"/settings/history/foo.bar", http.StatusNotFound},
+		{"/settings/compare/v23.2.9..v23.2.10", http.StatusInternalServerError},
+		{"/settings/compare?releases=v23.1.0,v23.2.0,v24.1.0", http.StatusOK},
+		{"/settings/compare?releases=v23.1.0...v24.1.0", http.StatusOK},
+		{"/settings/compare?releases=v23.1.0", http.StatusBadRequest},
+		{"/settings/compare", http.StatusBadRequest},
+		{"/settings/summary", http.StatusOK},
+		{"/releases/list", http.StatusOK},
+		{"/metrics/release/v23.2.10", http.StatusOK},
+		{"/metrics/detail/sys_uptime", http.StatusOK},
+		{"/metrics/detail/foo_bar", http.StatusNotFound},
+		{"/metrics/history/sys_uptime", http.StatusOK},
+		{"/metrics/history/foo_bar", http.StatusNotFound},
+		{"/sessionvars/release/v23.2.10", http.StatusOK},
+		{"/sessionvars/release/v99.1.0", http.StatusNotFound},
+		{"/sessionvars/compare/v23.2.9..v23.2.10", http.StatusOK},
+		{"/sessionvars/detail/default_transaction_isolation", http.StatusOK},
+		{"/sessionvars/detail/foo_bar", http.StatusNotFound},
+		{"/catalog/release/v23.2.10", http.StatusOK},
+		{"/catalog/release/v99.1.0", http.StatusNotFound},
+		{"/catalog/compare/v23.2.9..v23.2.10", http.StatusOK},
+		{"/keywords/release/v23.2.10", http.StatusOK},
+		{"/keywords/compare/v23.2.10..v24.1.0", http.StatusOK},
+		{"/keywords/compare/v99.1.0..v24.1.0", http.StatusNotFound},
+		{"/search?q=distsql", http.StatusOK},
+		{"/search?q=distsql&limit=5", http.StatusOK},
+		{"/search", http.StatusBadRequest},
+		{"/search?q=distsql&limit=five", http.StatusBadRequest},
+	}
+
+	h := newFakeHandler()
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func TestSettingsHandler_ListSettingsForRelease(t *testing.T) {
+	w := httptest.NewRecorder()
+	newFakeHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/settings/release/v23.2.10", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var s settings.ReleaseSettings
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &s))
+	assert.Len(t, s, 1)
+	assert.Equal(t, "sql.defaults.distsql", s[0].Variable)
+}
+
+func TestSettingsHandler_ListSettingsForReleaseNextToken(t *testing.T) {
+	w := httptest.NewRecorder()
+	newFakeHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/settings/release/v23.2.10?limit=1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "next-token", w.Header().Get(NextTokenHeader))
+
+	w = httptest.NewRecorder()
+	newFakeHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/settings/release/v23.2.10", nil))
+	assert.Empty(t, w.Header().Get(NextTokenHeader))
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ServerConfig configures the API web server
+type ServerConfig struct {
+	Listen          string // address to listen on, such as ':8080'
+	TLSCertFile     string // serve HTTPS when both the certificate and key files are set
+	TLSKeyFile      string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration // how long to wait for in-flight requests when shutting down
+}
+
+// DefaultServerConfig returns the configuration used by both the api serve command and App Engine unless overridden
+func DefaultServerConfig() ServerConfig {
+	return ServerConfig{
+		Listen:          ":8080",
+		ReadTimeout:     15 * time.Second,
+		WriteTimeout:    30 * time.Second,
+		IdleTimeout:     60 * time.Second,
+		ShutdownTimeout: 30 * time.Second,
+	}
+}
+
+func (c ServerConfig) Validate() error {
+	if c.Listen == "" {
+		return fmt.Errorf("listen address is required")
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return fmt.Errorf("both a TLS certificate and key are required for TLS")
+	}
+	if c.ReadTimeout < 0 || c.WriteTimeout < 0 || c.IdleTimeout < 0 || c.ShutdownTimeout < 0 {
+		return fmt.Errorf("timeouts cannot be negative")
+	}
+	return nil
+}
+
+func (c ServerConfig) tls() bool {
+	return c.TLSCertFile != ""
+}
+
+type Server struct {
+	Config ServerConfig
+	http   *http.Server
+}
+
+// NewServer returns a server for the handler. Zero timeouts mean no timeout, as for http.Server.
+func NewServer(handler http.Handler, config ServerConfig) (*Server, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &Server{
+		Config: config,
+		http: &http.Server{
+			Addr:         config.Listen,
+			Handler:      handler,
+			ReadTimeout:  config.ReadTimeout,
+			WriteTimeout: config.WriteTimeout,
+			IdleTimeout:  config.IdleTimeout,
+		},
+	}, nil
+}
+
+// Run serves requests until the context is done, then stops accepting connections and waits up to the shutdown
+// timeout for in-flight requests to finish
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.Config.Listen)
+	if err != nil {
+		return err
+	}
+	return s.serve(ctx, ln)
+}
+
+func (s *Server) serve(ctx context.Context, ln net.Listener) error {
+	logrus.Infof("Listening on %s (tls: %t)", ln.Addr(), s.Config.tls())
+
+	errCh := make(chan error, 1)
+	go func() {
+		if s.Config.tls() {
+			errCh <- s.http.ServeTLS(ln, s.Config.TLSCertFile, s.Config.TLSKeyFile)
+		} else {
+			errCh <- s.http.Serve(ln)
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	logrus.Info("Shutting down, waiting for in-flight requests")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.Config.ShutdownTimeout)
+	defer cancel()
+	if err := s.http.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Serve runs the API for the database URL until SIGINT or SIGTERM is received. It is shared by the api serve command
+// and the App Engine entrypoint.
+func Serve(url string, config ServerConfig) error {
+	h, err := NewSettingsHandler(url)
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", h)
+	s, err := NewServer(mux, config)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return s.Run(ctx)
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultServerConfig().Validate())
+
+	c := DefaultServerConfig()
+	c.TLSCertFile = "cert.pem"
+	assert.Error(t, c.Validate())
+	c.TLSKeyFile = "key.pem"
+	assert.NoError(t, c.Validate())
+
+	c = DefaultServerConfig()
+	c.Listen = ""
+	assert.Error(t, c.Validate())
+
+	c = DefaultServerConfig()
+	c.ReadTimeout = -time.Second
+	assert.Error(t, c.Validate())
+}
+
+func TestServer_DrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte("done"))
+	})
+
+	s, err := NewServer(handler, DefaultServerConfig())
+	assert.NoError(t, err)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- s.serve(ctx, ln) }()
+
+	type result struct {
+		body string
+		err  error
+	}
+	responses := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/")
+		if err != nil {
+			responses <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		responses <- result{body: string(b), err: err}
+	}()
+
+	// Shut down while the request is in flight, then let it finish
+	<-started
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	r := <-responses
+	assert.NoError(t, r.err)
+	assert.Equal(t, "done", r.body)
+	assert.NoError(t, <-served)
+}
@@ -0,0 +1,143 @@
+package capture
+
+// The capture package starts a single test cluster per release and collects several artifacts from it, such as
+// cluster settings, metrics and session variables, which are then saved together in one transaction.
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jonstjohn/crdb-settings/pkg/crdbcluster"
+	"github.com/jonstjohn/crdb-settings/pkg/dbpgx"
+)
+
+// Run identifies a capture: a release captured on a host shape with a number of nodes
+type Run struct {
+	Release     string
+	Cpu         int
+	MemoryBytes int64
+	Nodes       int
+}
+
+// Cluster is a running test cluster that artifacts are collected from
+type Cluster struct {
+	Manager *crdbcluster.Manager
+	Pool    *pgxpool.Pool // connection to the first node
+}
+
+// Collector collects one kind of artifact from a running test cluster
+type Collector interface {
+	Name() string
+	Collect(ctx context.Context, c *Cluster) (Artifact, error)
+}
+
+// ShapedCollector is a collector whose artifact is saved for each host shape and node count along with its own save
+// run, rather than once per release, so whether it has been captured is checked against its save runs instead of
+// the capture run of the release
+type ShapedCollector interface {
+	Collector
+	Captured(ctx context.Context, pool *pgxpool.Pool, run Run) (bool, error)
+}
+
+// Artifact is a collected artifact, saved with the other artifacts of the release in a single transaction
+type Artifact interface {
+	Count() int
+	Save(tx pgx.Tx, run Run) error
+}
+
+// Capture is the artifacts collected from the test cluster of a release, by collector name
+type Capture struct {
+	Run       Run
+	Artifacts map[string]Artifact
+}
+
+// Names returns the names of the collected artifacts, sorted
+func (c Capture) Names() []string {
+	names := make([]string, 0, len(c.Artifacts))
+	for name := range c.Artifacts {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// UnknownArtifactError is returned for artifact names without a collector
+type UnknownArtifactError struct {
+	Name string
+}
+
+func (e *UnknownArtifactError) Error() string {
+	return fmt.Sprintf("unknown artifact '%s', expected one of %s", e.Name, strings.Join(ArtifactNames(), ", "))
+}
+
+// CollectorsForNames returns the collectors for comma separated artifact names, or every collector if the names are
+// empty
+func CollectorsForNames(names string) ([]Collector, error) {
+	if strings.TrimSpace(names) == "" {
+		return DefaultCollectors(), nil
+	}
+	collectors := make([]Collector, 0)
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		i := slices.IndexFunc(DefaultCollectors(), func(c Collector) bool { return c.Name() == name })
+		if i < 0 {
+			return nil, &UnknownArtifactError{Name: name}
+		}
+		if !slices.ContainsFunc(collectors, func(c Collector) bool { return c.Name() == name }) {
+			collectors = append(collectors, DefaultCollectors()[i])
+		}
+	}
+	return collectors, nil
+}
+
+// ArtifactNames returns the names of every collector
+func ArtifactNames() []string {
+	names := make([]string, 0)
+	for _, c := range DefaultCollectors() {
+		names = append(names, c.Name())
+	}
+	return names
+}
+
+// CaptureRelease starts a test cluster for the release with the cluster manager of a worker, runs every collector
+// against it and stops the cluster
+func CaptureRelease(ctx context.Context, cm *crdbcluster.Manager, run Run, collectors []Collector) (Capture, error) {
+	c := Capture{Run: run, Artifacts: make(map[string]Artifact)}
+	if err := cm.StartTestCluster(run.Release); err != nil {
+		return c, err
+	}
+	err := collect(ctx, cm, collectors, c.Artifacts)
+	if cleanupErr := cm.CleanupTestCluster(); cleanupErr != nil && err == nil {
+		err = cleanupErr
+	}
+	return c, err
+}
+
+func collect(ctx context.Context, cm *crdbcluster.Manager, collectors []Collector, artifacts map[string]Artifact) error {
+	pgurl, err := cm.GetPGUrl()
+	if err != nil {
+		return err
+	}
+	pool, err := dbpgx.NewPoolFromUrl(pgurl.String())
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	cluster := &Cluster{Manager: cm, Pool: pool}
+	for _, collector := range collectors {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		artifact, err := collector.Collect(ctx, cluster)
+		if err != nil {
+			return fmt.Errorf("could not collect %s: %w", collector.Name(), err)
+		}
+		artifacts[collector.Name()] = artifact
+	}
+	return nil
+}
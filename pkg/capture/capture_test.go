@@ -0,0 +1,80 @@
+package capture
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectorsForNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		names    string
+		expected []string
+	}{
+		{name: "empty", names: "", expected: []string{ArtifactSettings, ArtifactMetrics, ArtifactSessionVariables,
+			ArtifactCatalog, ArtifactKeywords}},
+		{name: "single", names: "metrics", expected: []string{ArtifactMetrics}},
+		{name: "spaces", names: " settings , session_variables ", expected: []string{ArtifactSettings, ArtifactSessionVariables}},
+		{name: "duplicate", names: "metrics,metrics", expected: []string{ArtifactMetrics}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collectors, err := CollectorsForNames(tt.names)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, collectorNames(collectors))
+		})
+	}
+}
+
+func TestCollectorsForNamesUnknown(t *testing.T) {
+	_, err := CollectorsForNames("settings,foo")
+	var unknown *UnknownArtifactError
+	require.ErrorAs(t, err, &unknown)
+	assert.Equal(t, "foo", unknown.Name)
+	assert.Equal(t, "unknown artifact 'foo', expected one of settings, metrics, session_variables, catalog, keywords", err.Error())
+}
+
+func TestCapture_Names(t *testing.T) {
+	c := Capture{Artifacts: map[string]Artifact{
+		ArtifactSessionVariables: nil,
+		ArtifactMetrics:          nil,
+		ArtifactSettings:         nil,
+	}}
+	assert.Equal(t, []string{ArtifactMetrics, ArtifactSessionVariables, ArtifactSettings}, c.Names())
+}
+
+func TestMergeNames(t *testing.T) {
+	assert.Equal(t, []string{"metrics", "session_variables", "settings"},
+		mergeNames([]string{"settings", "metrics"}, []string{"session_variables", "metrics"}))
+	assert.Equal(t, []string{"metrics"}, mergeNames(nil, []string{"metrics"}))
+}
+
+// shapedCollector is captured for the node counts in nodes, whatever the artifacts of the capture run
+type shapedCollector struct {
+	SettingsCollector
+	nodes []int
+}
+
+func (c shapedCollector) Captured(ctx context.Context, pool *pgxpool.Pool, run Run) (bool, error) {
+	return slices.Contains(c.nodes, run.Nodes), nil
+}
+
+func TestPendingCollectors(t *testing.T) {
+	collectors := []Collector{shapedCollector{nodes: []int{1}}, SessionVariablesCollector{}, KeywordsCollector{}}
+	previous := []string{ArtifactSettings, ArtifactSessionVariables}
+
+	// Settings were captured with one node, so only keywords are missing for the release
+	pending, err := pendingCollectors(context.Background(), nil, Run{Release: "v24.1.0", Nodes: 1}, previous, collectors)
+	require.NoError(t, err)
+	assert.Equal(t, []string{ArtifactKeywords}, collectorNames(pending))
+
+	// With three nodes, settings are captured again even though the capture run lists them
+	pending, err = pendingCollectors(context.Background(), nil, Run{Release: "v24.1.0", Nodes: 3}, previous, collectors)
+	require.NoError(t, err)
+	assert.Equal(t, []string{ArtifactSettings, ArtifactKeywords}, collectorNames(pending))
+}
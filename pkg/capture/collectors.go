@@ -0,0 +1,170 @@
+package capture
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jonstjohn/crdb-settings/pkg/catalog"
+	"github.com/jonstjohn/crdb-settings/pkg/keywords"
+	"github.com/jonstjohn/crdb-settings/pkg/metrics"
+	"github.com/jonstjohn/crdb-settings/pkg/sessionvars"
+	"github.com/jonstjohn/crdb-settings/pkg/settings"
+)
+
+// Artifact names
+const (
+	ArtifactSettings         = "settings"
+	ArtifactMetrics          = "metrics"
+	ArtifactSessionVariables = "session_variables"
+	ArtifactCatalog          = "catalog"
+	ArtifactKeywords         = "keywords"
+)
+
+// DefaultCollectors returns a collector for every artifact, in the order they are collected
+func DefaultCollectors() []Collector {
+	return []Collector{
+		SettingsCollector{},
+		MetricsCollector{},
+		SessionVariablesCollector{},
+		CatalogCollector{},
+		KeywordsCollector{},
+	}
+}
+
+// SettingsCollector collects crdb_internal.cluster_settings from the first node
+type SettingsCollector struct{}
+
+func (SettingsCollector) Name() string {
+	return ArtifactSettings
+}
+
+func (SettingsCollector) Collect(ctx context.Context, c *Cluster) (Artifact, error) {
+	s, err := settings.GetLocalClusterSettings(c.Pool)
+	return settingsArtifact(s), err
+}
+
+func (SettingsCollector) Captured(ctx context.Context, pool *pgxpool.Pool, run Run) (bool, error) {
+	return settings.NewDbFromPool(pool).SaveRunExists(run.Release, run.Cpu, run.MemoryBytes, run.Nodes)
+}
+
+type settingsArtifact []settings.ClusterSetting
+
+func (a settingsArtifact) Count() int {
+	return len(a)
+}
+
+func (a settingsArtifact) Save(tx pgx.Tx, run Run) error {
+	raws := make(settings.RawSettings, len(a))
+	for i, s := range a {
+		raws[i] = *settings.NewRawSetting(run.Release, run.Cpu, run.MemoryBytes, run.Nodes, s)
+	}
+	if err := settings.SaveRawSettingsTx(tx, raws); err != nil {
+		return err
+	}
+	return settings.SaveRunTx(tx, run.Release, run.Cpu, run.MemoryBytes, run.Nodes)
+}
+
+// MetricsCollector scrapes /_status/vars from every node and merges the metrics
+type MetricsCollector struct{}
+
+func (MetricsCollector) Name() string {
+	return ArtifactMetrics
+}
+
+func (MetricsCollector) Collect(ctx context.Context, c *Cluster) (Artifact, error) {
+	nodeFamilies, err := c.Manager.GetMetricsFamilies()
+	if err != nil {
+		return nil, err
+	}
+	nodeMetrics := make([]metrics.Metrics, len(nodeFamilies))
+	for i, families := range nodeFamilies {
+		nodeMetrics[i] = metrics.FromFamilies(families)
+	}
+	return metricsArtifact(metrics.MergeMetrics(nodeMetrics...)), nil
+}
+
+func (MetricsCollector) Captured(ctx context.Context, pool *pgxpool.Pool, run Run) (bool, error) {
+	runs, err := metrics.NewDbFromPool(pool).SelectSaveRuns(run.Release, run.Nodes)
+	return len(runs) > 0, err
+}
+
+type metricsArtifact metrics.Metrics
+
+func (a metricsArtifact) Count() int {
+	return len(a)
+}
+
+func (a metricsArtifact) Save(tx pgx.Tx, run Run) error {
+	if err := metrics.UpsertRawTx(tx, run.Release, run.Nodes, metrics.Metrics(a)); err != nil {
+		return err
+	}
+	return metrics.UpsertSaveRunTx(tx, run.Release, run.Nodes)
+}
+
+// SessionVariablesCollector collects the session variables of a new session on the first node
+type SessionVariablesCollector struct{}
+
+func (SessionVariablesCollector) Name() string {
+	return ArtifactSessionVariables
+}
+
+func (SessionVariablesCollector) Collect(ctx context.Context, c *Cluster) (Artifact, error) {
+	vars, err := sessionvars.GetLocalSessionVariables(c.Pool)
+	return sessionVariablesArtifact(vars), err
+}
+
+type sessionVariablesArtifact []sessionvars.SessionVariable
+
+func (a sessionVariablesArtifact) Count() int {
+	return len(a)
+}
+
+func (a sessionVariablesArtifact) Save(tx pgx.Tx, run Run) error {
+	return sessionvars.SaveRawTx(tx, run.Release, a)
+}
+
+// CatalogCollector collects the crdb_internal, information_schema and pg_catalog tables with their columns and the
+// builtin functions from the first node
+type CatalogCollector struct{}
+
+func (CatalogCollector) Name() string {
+	return ArtifactCatalog
+}
+
+func (CatalogCollector) Collect(ctx context.Context, c *Cluster) (Artifact, error) {
+	cat, err := catalog.GetLocalCatalog(c.Pool)
+	return catalogArtifact(cat), err
+}
+
+type catalogArtifact catalog.Catalog
+
+func (a catalogArtifact) Count() int {
+	return len(a.Tables) + len(a.Functions)
+}
+
+func (a catalogArtifact) Save(tx pgx.Tx, run Run) error {
+	return catalog.SaveRawTx(tx, run.Release, catalog.Catalog(a))
+}
+
+// KeywordsCollector collects the SQL keywords and their categories from pg_get_keywords() on the first node
+type KeywordsCollector struct{}
+
+func (KeywordsCollector) Name() string {
+	return ArtifactKeywords
+}
+
+func (KeywordsCollector) Collect(ctx context.Context, c *Cluster) (Artifact, error) {
+	kws, err := keywords.GetLocalKeywords(c.Pool)
+	return keywordsArtifact(kws), err
+}
+
+type keywordsArtifact []keywords.Keyword
+
+func (a keywordsArtifact) Count() int {
+	return len(a)
+}
+
+func (a keywordsArtifact) Save(tx pgx.Tx, run Run) error {
+	return keywords.SaveRawTx(tx, run.Release, a)
+}
@@ -0,0 +1,80 @@
+package capture
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jonstjohn/crdb-settings/pkg/dbpgx"
+)
+
+type Db struct {
+	Url  string
+	Pool *pgxpool.Pool
+}
+
+// RunRow is the saved capture run of a release, with the host shape and node count it was last captured with and
+// the artifacts that were captured
+type RunRow struct {
+	Run
+	Artifacts []string
+	Updated   time.Time
+}
+
+const UpsertCaptureRunSql = `
+UPSERT INTO capture_runs (release_name, cpu, memory_bytes, nodes, artifacts, updated)
+VALUES ($1, $2, $3, $4, $5, now())
+`
+
+const SelectCaptureRunSql = `
+SELECT release_name, cpu, memory_bytes, nodes, artifacts, updated
+FROM capture_runs
+WHERE release_name = $1
+`
+
+func NewDbDatasource(url string) (*Db, error) {
+	pool, err := dbpgx.NewPoolFromUrl(url)
+	if err != nil {
+		return nil, err
+	}
+	return &Db{
+		Url:  url,
+		Pool: pool,
+	}, nil
+}
+
+// NewDbFromPool returns a datasource that uses an existing pool, which is owned and closed by the caller
+func NewDbFromPool(pool *pgxpool.Pool) *Db {
+	return &Db{Pool: pool}
+}
+
+// GetRun gets the saved capture run of a release, or nil if the release has not been captured
+func (db *Db) GetRun(release string) (*RunRow, error) {
+	row := RunRow{}
+	err := db.Pool.QueryRow(context.Background(), SelectCaptureRunSql, release).Scan(
+		&row.Release, &row.Cpu, &row.MemoryBytes, &row.Nodes, &row.Artifacts, &row.Updated)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// SaveCapture saves every artifact of a capture and the capture run of the release in a single transaction, so that
+// a release is either fully captured or not at all. Artifacts captured by an earlier run are kept in the run's
+// artifact list.
+func (db *Db) SaveCapture(c Capture, previous []string) error {
+	return pgx.BeginFunc(context.Background(), db.Pool, func(tx pgx.Tx) error {
+		for _, name := range c.Names() {
+			if err := c.Artifacts[name].Save(tx, c.Run); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec(context.Background(), UpsertCaptureRunSql,
+			c.Run.Release, c.Run.Cpu, c.Run.MemoryBytes, c.Run.Nodes, mergeNames(previous, c.Names()))
+		return err
+	})
+}
@@ -0,0 +1,151 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jonstjohn/crdb-settings/pkg/crdbcluster"
+	"github.com/jonstjohn/crdb-settings/pkg/host"
+	"github.com/jonstjohn/crdb-settings/pkg/releases"
+	"github.com/sirupsen/logrus"
+)
+
+type Manager struct {
+	Db          *Db
+	Binaries    crdbcluster.BinaryProvider // optional, cockroach binaries used to capture
+	Parallelism int                        // number of releases captured at once, one if not set
+	Collectors  []Collector                // artifacts to capture, every artifact if not set
+}
+
+func NewManager(url string) (*Manager, error) {
+	db, err := NewDbDatasource(url)
+	if err != nil {
+		return nil, err
+	}
+	return &Manager{Db: db}, nil
+}
+
+// NewManagerFromPool returns a manager that uses an existing pool, which is owned and closed by the caller
+func NewManagerFromPool(pool *pgxpool.Pool) *Manager {
+	return &Manager{Db: NewDbFromPool(pool)}
+}
+
+// CaptureReleases captures the artifacts of one or more releases ('all' or 'recent-N' for several) on the current
+// host with a test cluster of the given number of nodes. Every artifact of a release is collected from the same
+// test cluster and saved in one transaction with the capture run of the release. Artifacts that are already captured
+// are skipped: session variables, the catalog and keywords once per release, and settings and metrics once per host
+// shape and node count, as recorded by their save runs. A release that fails does not stop the others; a
+// crdbcluster.CaptureError lists the releases that failed.
+func (m *Manager) CaptureReleases(ctx context.Context, release string, nodes int) error {
+	if _, err := crdbcluster.NodesOpts(nodes); err != nil {
+		return err
+	}
+	shape, err := host.GetShape()
+	if err != nil {
+		return err
+	}
+	rs, err := m.getReleasesNames(release)
+	if err != nil {
+		return err
+	}
+	collectors := m.Collectors
+	if len(collectors) == 0 {
+		collectors = DefaultCollectors()
+	}
+	logrus.Info(fmt.Sprintf("Found %d releases that are candidate for capturing %s with %d node(s)",
+		len(rs), strings.Join(collectorNames(collectors), ", "), nodes))
+
+	pool := crdbcluster.NewPool(m.Parallelism, nodes, m.Binaries)
+	results := pool.Run(ctx, rs, func(ctx context.Context, cm *crdbcluster.Manager, r string) error {
+		run := Run{Release: r, Cpu: shape.Cpu, MemoryBytes: shape.MemoryBytes, Nodes: cm.NodeCount()}
+		return m.captureRelease(ctx, cm, run, collectors)
+	})
+	return crdbcluster.ResultsError(results)
+}
+
+func (m *Manager) captureRelease(ctx context.Context, cm *crdbcluster.Manager, run Run, collectors []Collector) error {
+	existing, err := m.Db.GetRun(run.Release)
+	if err != nil {
+		return err
+	}
+	var previous []string
+	if existing != nil {
+		previous = existing.Artifacts
+	}
+	collectors, err = pendingCollectors(ctx, m.Db.Pool, run, previous, collectors)
+	if err != nil {
+		return err
+	}
+	if len(collectors) == 0 {
+		logrus.Info(fmt.Sprintf("Every artifact already captured for '%s' with cpu/memory/nodes %d/%d/%d",
+			run.Release, run.Cpu, run.MemoryBytes, run.Nodes))
+		return nil
+	}
+
+	c, err := CaptureRelease(ctx, cm, run, collectors)
+	if err != nil {
+		return err
+	}
+	for _, name := range c.Names() {
+		logrus.Debug(fmt.Sprintf("Captured %d %s for '%s'", c.Artifacts[name].Count(), name, run.Release))
+	}
+	return m.Db.SaveCapture(c, previous)
+}
+
+// pendingCollectors returns the collectors whose artifacts have not been captured for the run. Shaped collectors check
+// their own save runs, other artifacts are captured once per release and checked against the previously captured
+// artifacts of the release.
+func pendingCollectors(ctx context.Context, pool *pgxpool.Pool, run Run, previous []string, collectors []Collector) ([]Collector, error) {
+	pending := make([]Collector, 0, len(collectors))
+	for _, c := range collectors {
+		captured := slices.Contains(previous, c.Name())
+		if sc, ok := c.(ShapedCollector); ok {
+			var err error
+			if captured, err = sc.Captured(ctx, pool, run); err != nil {
+				return nil, err
+			}
+		}
+		if !captured {
+			pending = append(pending, c)
+		}
+	}
+	return pending, nil
+}
+
+func (m *Manager) getReleasesNames(release string) ([]string, error) {
+	if release == "all" || strings.HasPrefix(release, "recent-") {
+		cnt := math.MaxInt
+		var err error
+		if strings.HasPrefix(release, "recent-") {
+			cntStr := strings.Replace(release, "recent-", "", 1)
+			cnt, err = strconv.Atoi(cntStr)
+			if err != nil {
+				return nil, err
+			}
+		}
+		rm := releases.NewReleasesManagerFromPool(m.Db.Pool)
+		return rm.GetRecentReleaseNames(cnt)
+	} else {
+		return []string{release}, nil
+	}
+}
+
+func collectorNames(collectors []Collector) []string {
+	names := make([]string, len(collectors))
+	for i, c := range collectors {
+		names[i] = c.Name()
+	}
+	return names
+}
+
+// mergeNames returns the sorted names in either list
+func mergeNames(n1 []string, n2 []string) []string {
+	merged := append(slices.Clone(n1), n2...)
+	slices.Sort(merged)
+	return slices.Compact(merged)
+}
@@ -0,0 +1,172 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Schemas are the virtual schemas whose tables are captured
+var Schemas = []string{"crdb_internal", "information_schema", "pg_catalog"}
+
+// Catalog is the virtual tables and builtin functions of a release
+type Catalog struct {
+	Tables    []Table    `json:"tables"`
+	Functions []Function `json:"functions"`
+}
+
+// Table is a virtual table with its columns in ordinal order
+type Table struct {
+	Schema  string   `json:"schema"`
+	Name    string   `json:"name"`
+	Columns []Column `json:"columns"`
+}
+
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Function is a single signature of a builtin function. Overloaded functions have a Function for each signature.
+type Function struct {
+	Name      string `json:"name"`
+	Signature string `json:"signature"`
+	Category  string `json:"category"`
+	Details   string `json:"details"`
+}
+
+// FullName is the table name qualified by its schema
+func (t Table) FullName() string {
+	return t.Schema + "." + t.Name
+}
+
+const TablesSql = `
+SELECT table_schema, table_name
+FROM information_schema.tables
+WHERE table_schema = ANY($1)
+ORDER BY table_schema, table_name
+`
+
+const ColumnsSql = `
+SELECT table_schema, table_name, column_name, data_type
+FROM information_schema.columns
+WHERE table_schema = ANY($1)
+ORDER BY table_schema, table_name, ordinal_position
+`
+
+const FunctionColumnsSql = `
+SELECT column_name FROM information_schema.columns
+WHERE table_schema = 'crdb_internal' AND table_name = 'builtin_functions'
+`
+
+// functionColumns are the columns of crdb_internal.builtin_functions that are captured, in the order of the Function
+// fields. The name and signature have always been there.
+var functionColumns = []string{"function", "signature", "category", "details"}
+
+// GetLocalCatalog gets the virtual tables of the captured schemas with their columns, which is what SHOW TABLES and
+// SHOW COLUMNS report, and the builtin functions from crdb_internal.builtin_functions
+func GetLocalCatalog(pool *pgxpool.Pool) (Catalog, error) {
+	tables, err := getLocalTables(pool)
+	if err != nil {
+		return Catalog{}, err
+	}
+	functions, err := getLocalFunctions(pool)
+	if err != nil {
+		return Catalog{}, err
+	}
+	return Catalog{Tables: tables, Functions: functions}, nil
+}
+
+func getLocalTables(pool *pgxpool.Pool) ([]Table, error) {
+	rows, err := pool.Query(context.Background(), TablesSql, Schemas)
+	if err != nil {
+		return nil, err
+	}
+	tables := make([]Table, 0)
+	for rows.Next() {
+		t := Table{Columns: make([]Column, 0)}
+		if err := rows.Scan(&t.Schema, &t.Name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = pool.Query(context.Background(), ColumnsSql, Schemas)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns := make(map[string][]Column)
+	for rows.Next() {
+		var schema, table string
+		var c Column
+		if err := rows.Scan(&schema, &table, &c.Name, &c.Type); err != nil {
+			return nil, err
+		}
+		name := Table{Schema: schema, Name: table}.FullName()
+		columns[name] = append(columns[name], c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, t := range tables {
+		if cs, ok := columns[t.FullName()]; ok {
+			tables[i].Columns = cs
+		}
+	}
+	return tables, nil
+}
+
+// getLocalFunctions gets the builtin functions, selecting only the columns of crdb_internal.builtin_functions that
+// exist in the release and leaving the other fields empty
+func getLocalFunctions(pool *pgxpool.Pool) ([]Function, error) {
+	rows, err := pool.Query(context.Background(), FunctionColumnsSql)
+	if err != nil {
+		return nil, err
+	}
+	existing := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		existing = append(existing, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	selected := make([]string, len(functionColumns))
+	for i, c := range functionColumns {
+		selected[i] = "''"
+		if slices.Contains(existing, c) {
+			selected[i] = c
+		}
+	}
+	sql := fmt.Sprintf("SELECT %s FROM crdb_internal.builtin_functions ORDER BY function, signature",
+		strings.Join(selected, ","))
+	rows, err = pool.Query(context.Background(), sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	functions := make([]Function, 0)
+	for rows.Next() {
+		var f Function
+		if err := rows.Scan(&f.Name, &f.Signature, &f.Category, &f.Details); err != nil {
+			return nil, err
+		}
+		functions = append(functions, f)
+	}
+	return functions, rows.Err()
+}
@@ -0,0 +1,183 @@
+package catalog
+
+import (
+	"sort"
+)
+
+type ChangedTables []ChangedTable
+
+// ChangedTable is a table that exists in both releases with added, removed or retyped columns
+type ChangedTable struct {
+	Schema         string          `json:"schema"`
+	Name           string          `json:"name"`
+	AddedColumns   []Column        `json:"added_columns"`
+	RemovedColumns []Column        `json:"removed_columns"`
+	ChangedColumns []ChangedColumn `json:"changed_columns"`
+}
+
+// ChangedColumn is a column whose type changed
+type ChangedColumn struct {
+	Name       string `json:"name"`
+	BeforeType string `json:"before_type"`
+	AfterType  string `json:"after_type"`
+}
+
+type ChangedFunctions []ChangedFunction
+
+// ChangedFunction is a builtin function that exists in both releases with added or removed signatures
+type ChangedFunction struct {
+	Name              string   `json:"name"`
+	AddedSignatures   []string `json:"added_signatures"`
+	RemovedSignatures []string `json:"removed_signatures"`
+}
+
+// ComparedReleaseCatalogs is the difference between the catalogs of two releases. Functions are added or removed
+// when none of their signatures exist in the other release, and changed when only some signatures do.
+type ComparedReleaseCatalogs struct {
+	AddedTables      []Table          `json:"added_tables"`
+	RemovedTables    []Table          `json:"removed_tables"`
+	ChangedTables    ChangedTables    `json:"changed_tables"`
+	AddedFunctions   []Function       `json:"added_functions"`
+	RemovedFunctions []Function       `json:"removed_functions"`
+	ChangedFunctions ChangedFunctions `json:"changed_functions"`
+}
+
+// CompareReleaseCatalogs compares the catalogs of two releases
+func CompareReleaseCatalogs(c1 Catalog, c2 Catalog) ComparedReleaseCatalogs {
+	c := ComparedReleaseCatalogs{}
+	c.AddedTables, c.RemovedTables, c.ChangedTables = compareTables(c1.Tables, c2.Tables)
+	c.AddedFunctions, c.RemovedFunctions, c.ChangedFunctions = compareFunctions(c1.Functions, c2.Functions)
+	return c
+}
+
+func compareTables(t1 []Table, t2 []Table) ([]Table, []Table, ChangedTables) {
+	t1indexed := make(map[string]Table)
+	for _, t := range t1 {
+		t1indexed[t.FullName()] = t
+	}
+	t2indexed := make(map[string]Table)
+	for _, t := range t2 {
+		t2indexed[t.FullName()] = t
+	}
+
+	added := make([]Table, 0)
+	removed := make([]Table, 0)
+	changed := ChangedTables{}
+
+	for _, before := range t1 {
+		after, ok := t2indexed[before.FullName()]
+		if !ok { // exists in t1 but not t2
+			removed = append(removed, before)
+			continue
+		}
+		if ct, ok := compareColumns(before, after); ok {
+			changed = append(changed, ct)
+		}
+	}
+
+	for _, after := range t2 {
+		if _, ok := t1indexed[after.FullName()]; !ok {
+			added = append(added, after)
+		}
+	}
+	return added, removed, changed
+}
+
+// compareColumns compares the columns of a table in two releases, returning false if they are the same. Moving a
+// column is not a change.
+func compareColumns(before Table, after Table) (ChangedTable, bool) {
+	ct := ChangedTable{
+		Schema:         before.Schema,
+		Name:           before.Name,
+		AddedColumns:   make([]Column, 0),
+		RemovedColumns: make([]Column, 0),
+		ChangedColumns: make([]ChangedColumn, 0),
+	}
+
+	beforeTypes := make(map[string]string)
+	for _, col := range before.Columns {
+		beforeTypes[col.Name] = col.Type
+	}
+	afterTypes := make(map[string]string)
+	for _, col := range after.Columns {
+		afterTypes[col.Name] = col.Type
+	}
+
+	for _, col := range before.Columns {
+		typ, ok := afterTypes[col.Name]
+		if !ok {
+			ct.RemovedColumns = append(ct.RemovedColumns, col)
+		} else if typ != col.Type {
+			ct.ChangedColumns = append(ct.ChangedColumns,
+				ChangedColumn{Name: col.Name, BeforeType: col.Type, AfterType: typ})
+		}
+	}
+	for _, col := range after.Columns {
+		if _, ok := beforeTypes[col.Name]; !ok {
+			ct.AddedColumns = append(ct.AddedColumns, col)
+		}
+	}
+
+	return ct, len(ct.AddedColumns) > 0 || len(ct.RemovedColumns) > 0 || len(ct.ChangedColumns) > 0
+}
+
+func compareFunctions(f1 []Function, f2 []Function) ([]Function, []Function, ChangedFunctions) {
+	f1signatures := signaturesByName(f1)
+	f2signatures := signaturesByName(f2)
+
+	added := make([]Function, 0)
+	removed := make([]Function, 0)
+	for _, f := range f1 {
+		if _, ok := f2signatures[f.Name]; !ok {
+			removed = append(removed, f)
+		}
+	}
+	for _, f := range f2 {
+		if _, ok := f1signatures[f.Name]; !ok {
+			added = append(added, f)
+		}
+	}
+
+	changed := ChangedFunctions{}
+	for name, before := range f1signatures {
+		after, ok := f2signatures[name]
+		if !ok {
+			continue
+		}
+		cf := ChangedFunction{
+			Name:              name,
+			AddedSignatures:   signaturesNotIn(after, before),
+			RemovedSignatures: signaturesNotIn(before, after),
+		}
+		if len(cf.AddedSignatures) > 0 || len(cf.RemovedSignatures) > 0 {
+			changed = append(changed, cf)
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool {
+		return changed[i].Name < changed[j].Name
+	})
+	return added, removed, changed
+}
+
+func signaturesByName(functions []Function) map[string]map[string]bool {
+	signatures := make(map[string]map[string]bool)
+	for _, f := range functions {
+		if signatures[f.Name] == nil {
+			signatures[f.Name] = make(map[string]bool)
+		}
+		signatures[f.Name][f.Signature] = true
+	}
+	return signatures
+}
+
+// signaturesNotIn returns the sorted signatures of s1 that are not in s2
+func signaturesNotIn(s1 map[string]bool, s2 map[string]bool) []string {
+	diff := make([]string, 0)
+	for s := range s1 {
+		if !s2[s] {
+			diff = append(diff, s)
+		}
+	}
+	sort.Strings(diff)
+	return diff
+}
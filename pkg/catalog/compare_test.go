@@ -0,0 +1,84 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareReleaseCatalogs(t *testing.T) {
+	before := Catalog{
+		Tables: []Table{
+			{Schema: "crdb_internal", Name: "cluster_settings", Columns: []Column{
+				{Name: "variable", Type: "STRING"}, {Name: "value", Type: "STRING"}, {Name: "type", Type: "STRING"},
+			}},
+			{Schema: "crdb_internal", Name: "ranges", Columns: []Column{
+				{Name: "range_id", Type: "INT8"}, {Name: "replicas", Type: "INT8[]"}, {Name: "lease_holder", Type: "INT8"},
+			}},
+			{Schema: "crdb_internal", Name: "gossip_alerts", Columns: []Column{{Name: "node_id", Type: "INT8"}}},
+			{Schema: "pg_catalog", Name: "pg_class", Columns: []Column{{Name: "oid", Type: "OID"}}},
+		},
+		Functions: []Function{
+			{Name: "crdb_internal.force_error", Signature: "(errorCode: string, msg: string) -> int"},
+			{Name: "crdb_internal.range_stats", Signature: "(key: bytes) -> jsonb"},
+			{Name: "now", Signature: "() -> timestamptz"},
+			{Name: "now", Signature: "() -> timestamp"},
+		},
+	}
+	after := Catalog{
+		Tables: []Table{
+			// Moving a column is not a change
+			{Schema: "crdb_internal", Name: "cluster_settings", Columns: []Column{
+				{Name: "value", Type: "STRING"}, {Name: "variable", Type: "STRING"}, {Name: "type", Type: "STRING"},
+			}},
+			{Schema: "crdb_internal", Name: "ranges", Columns: []Column{
+				{Name: "range_id", Type: "INT8"}, {Name: "replicas", Type: "INT[]"}, {Name: "span_stats", Type: "JSONB"},
+			}},
+			{Schema: "pg_catalog", Name: "pg_class", Columns: []Column{{Name: "oid", Type: "OID"}}},
+			{Schema: "information_schema", Name: "crdb_node_info", Columns: []Column{}},
+		},
+		Functions: []Function{
+			{Name: "crdb_internal.range_stats", Signature: "(key: bytes) -> jsonb"},
+			{Name: "now", Signature: "() -> timestamptz"},
+			{Name: "now", Signature: "() -> date"},
+			{Name: "crdb_internal.plpgsql_raise", Signature: "(severity: string) -> int"},
+		},
+	}
+	c := CompareReleaseCatalogs(before, after)
+
+	assert.Equal(t, []Table{{Schema: "information_schema", Name: "crdb_node_info", Columns: []Column{}}},
+		c.AddedTables)
+	assert.Equal(t, []Table{{Schema: "crdb_internal", Name: "gossip_alerts",
+		Columns: []Column{{Name: "node_id", Type: "INT8"}}}}, c.RemovedTables)
+	assert.Equal(t, ChangedTables{{
+		Schema:         "crdb_internal",
+		Name:           "ranges",
+		AddedColumns:   []Column{{Name: "span_stats", Type: "JSONB"}},
+		RemovedColumns: []Column{{Name: "lease_holder", Type: "INT8"}},
+		ChangedColumns: []ChangedColumn{{Name: "replicas", BeforeType: "INT8[]", AfterType: "INT[]"}},
+	}}, c.ChangedTables)
+
+	assert.Equal(t, []Function{{Name: "crdb_internal.plpgsql_raise", Signature: "(severity: string) -> int"}},
+		c.AddedFunctions)
+	assert.Equal(t, []Function{{Name: "crdb_internal.force_error",
+		Signature: "(errorCode: string, msg: string) -> int"}}, c.RemovedFunctions)
+	assert.Equal(t, ChangedFunctions{{
+		Name:              "now",
+		AddedSignatures:   []string{"() -> date"},
+		RemovedSignatures: []string{"() -> timestamp"},
+	}}, c.ChangedFunctions)
+}
+
+func TestCompareReleaseCatalogsSame(t *testing.T) {
+	catalog := Catalog{
+		Tables:    []Table{{Schema: "pg_catalog", Name: "pg_class", Columns: []Column{{Name: "oid", Type: "OID"}}}},
+		Functions: []Function{{Name: "now", Signature: "() -> timestamptz"}},
+	}
+	c := CompareReleaseCatalogs(catalog, catalog)
+	assert.Empty(t, c.AddedTables)
+	assert.Empty(t, c.RemovedTables)
+	assert.Empty(t, c.ChangedTables)
+	assert.Empty(t, c.AddedFunctions)
+	assert.Empty(t, c.RemovedFunctions)
+	assert.Empty(t, c.ChangedFunctions)
+}
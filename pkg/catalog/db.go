@@ -0,0 +1,135 @@
+package catalog
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jonstjohn/crdb-settings/pkg/dbpgx"
+)
+
+type Db struct {
+	Url  string
+	Pool *pgxpool.Pool
+}
+
+const UpsertTableSql = `
+UPSERT INTO catalog_tables_raw (release_name, schema_name, table_name, column_names, column_types, updated)
+VALUES ($1, $2, $3, $4, $5, now())
+`
+
+const UpsertFunctionSql = `
+UPSERT INTO builtin_functions_raw (release_name, function, signature, category, details, updated)
+VALUES ($1, $2, $3, $4, $5, now())
+`
+
+const SelectTablesForReleaseSql = `
+SELECT schema_name, table_name, column_names, column_types
+FROM catalog_tables_raw
+WHERE release_name = $1
+ORDER BY schema_name, table_name
+`
+
+const SelectFunctionsForReleaseSql = `
+SELECT function, signature, category, details
+FROM builtin_functions_raw
+WHERE release_name = $1
+ORDER BY function, signature
+`
+
+func NewDbDatasource(url string) (*Db, error) {
+	pool, err := dbpgx.NewPoolFromUrl(url)
+	if err != nil {
+		return nil, err
+	}
+	return &Db{
+		Url:  url,
+		Pool: pool,
+	}, nil
+}
+
+// NewDbFromPool returns a datasource that uses an existing pool, which is owned and closed by the caller
+func NewDbFromPool(pool *pgxpool.Pool) *Db {
+	return &Db{Pool: pool}
+}
+
+// SaveRawTx saves the catalog of a release in an existing transaction, so that it can be saved with other artifacts
+func SaveRawTx(tx pgx.Tx, releaseName string, c Catalog) error {
+	for _, t := range c.Tables {
+		names := make([]string, len(t.Columns))
+		types := make([]string, len(t.Columns))
+		for i, col := range t.Columns {
+			names[i] = col.Name
+			types[i] = col.Type
+		}
+		_, err := tx.Exec(context.Background(), UpsertTableSql, releaseName, t.Schema, t.Name, names, types)
+		if err != nil {
+			return err
+		}
+	}
+	for _, f := range c.Functions {
+		_, err := tx.Exec(context.Background(), UpsertFunctionSql, releaseName, f.Name, f.Signature, f.Category,
+			f.Details)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SelectCatalog gets the catalog captured for a release
+func (db *Db) SelectCatalog(releaseName string) (Catalog, error) {
+	tables, err := db.selectTables(releaseName)
+	if err != nil {
+		return Catalog{}, err
+	}
+	functions, err := db.selectFunctions(releaseName)
+	if err != nil {
+		return Catalog{}, err
+	}
+	return Catalog{Tables: tables, Functions: functions}, nil
+}
+
+func (db *Db) selectTables(releaseName string) ([]Table, error) {
+	rows, err := db.Pool.Query(context.Background(), SelectTablesForReleaseSql, releaseName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := make([]Table, 0)
+	for rows.Next() {
+		var t Table
+		var names, types []string
+		if err := rows.Scan(&t.Schema, &t.Name, &names, &types); err != nil {
+			return nil, err
+		}
+		t.Columns = make([]Column, len(names))
+		for i := range names {
+			t.Columns[i] = Column{Name: names[i]}
+			if i < len(types) {
+				t.Columns[i].Type = types[i]
+			}
+		}
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+func (db *Db) selectFunctions(releaseName string) ([]Function, error) {
+	rows, err := db.Pool.Query(context.Background(), SelectFunctionsForReleaseSql, releaseName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	functions := make([]Function, 0)
+	for rows.Next() {
+		var f Function
+		if err := rows.Scan(&f.Name, &f.Signature, &f.Category, &f.Details); err != nil {
+			return nil, err
+		}
+		functions = append(functions, f)
+	}
+	return functions, rows.Err()
+}
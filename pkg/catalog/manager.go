@@ -0,0 +1,47 @@
+package catalog
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jonstjohn/crdb-settings/pkg/releases"
+)
+
+type Manager struct {
+	Db *Db
+}
+
+func NewManager(url string) (*Manager, error) {
+	db, err := NewDbDatasource(url)
+	if err != nil {
+		return nil, err
+	}
+	return &Manager{Db: db}, nil
+}
+
+// NewManagerFromPool returns a manager that uses an existing pool, which is owned and closed by the caller
+func NewManagerFromPool(pool *pgxpool.Pool) *Manager {
+	return &Manager{Db: NewDbFromPool(pool)}
+}
+
+// GetCatalogForRelease gets the virtual tables and builtin functions captured for a release, returning a
+// releases.UnknownReleaseError if the release does not exist
+func (m *Manager) GetCatalogForRelease(releaseName string) (Catalog, error) {
+	rm := releases.NewReleasesManagerFromPool(m.Db.Pool)
+	if _, err := rm.GetRelease(releaseName); err != nil {
+		return Catalog{}, err
+	}
+	return m.Db.SelectCatalog(releaseName)
+}
+
+func (m *Manager) CompareCatalogsForReleases(r1 string, r2 string) (ComparedReleaseCatalogs, error) {
+	c1, err := m.GetCatalogForRelease(r1)
+	if err != nil {
+		return ComparedReleaseCatalogs{}, err
+	}
+
+	c2, err := m.GetCatalogForRelease(r2)
+	if err != nil {
+		return ComparedReleaseCatalogs{}, err
+	}
+
+	return CompareReleaseCatalogs(c1, c2), nil
+}
@@ -0,0 +1,239 @@
+package crdbcluster
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultMirrorUrl is where CockroachDB release binaries are published
+const DefaultMirrorUrl = "https://binaries.cockroachdb.com"
+
+// BinaryProvider provides the cockroach binary used to start a test server for a release, so that capture
+// does not depend on the test server downloading binaries from the internet.
+type BinaryProvider interface {
+	// Binary returns the path to the cockroach binary for the release
+	Binary(release string) (string, error)
+	// Done is called when the binary for the release is no longer needed
+	Done(release string) error
+}
+
+// NewBinaryProvider returns the binary provider for the configured sources. An explicit binary path takes
+// precedence, then a cache directory keyed by release name, which is only filled from a mirror if a mirror URL is
+// provided. Without either, binaries are downloaded from the mirror (or DefaultMirrorUrl) into a managed
+// directory and removed once they are no longer needed.
+func NewBinaryProvider(binaryPath string, cacheDir string, mirrorUrl string) BinaryProvider {
+	if binaryPath != "" {
+		return &LocalBinaryProvider{Path: binaryPath}
+	}
+	if cacheDir != "" {
+		c := &CacheBinaryProvider{Dir: cacheDir}
+		if mirrorUrl != "" {
+			c.Mirror = &MirrorBinaryProvider{Url: mirrorUrl, Dir: cacheDir, Keep: true}
+		}
+		return c
+	}
+	if mirrorUrl == "" {
+		mirrorUrl = DefaultMirrorUrl
+	}
+	return &MirrorBinaryProvider{Url: mirrorUrl, Dir: filepath.Join(os.TempDir(), "crdb-settings-cockroach")}
+}
+
+// LocalBinaryProvider uses a single cockroach binary. Since one binary can only be one release, the binary's version
+// must match the requested release, so that its settings and metrics are never saved under another release.
+type LocalBinaryProvider struct {
+	Path string
+}
+
+func (p *LocalBinaryProvider) Binary(release string) (string, error) {
+	if _, err := os.Stat(p.Path); err != nil {
+		return "", fmt.Errorf("cockroach binary not found: %w", err)
+	}
+	path, err := filepath.Abs(p.Path)
+	if err != nil {
+		return "", err
+	}
+	version, err := BinaryVersion(path)
+	if err != nil {
+		return "", err
+	}
+	if version != release {
+		return "", &BinaryVersionError{Path: path, Version: version, Release: release}
+	}
+	return path, nil
+}
+
+func (p *LocalBinaryProvider) Done(release string) error {
+	return nil
+}
+
+// BinaryVersionError is returned when a cockroach binary is not the requested release
+type BinaryVersionError struct {
+	Path    string
+	Version string
+	Release string
+}
+
+func (e *BinaryVersionError) Error() string {
+	return fmt.Sprintf("cockroach binary '%s' is %s, not '%s'", e.Path, e.Version, e.Release)
+}
+
+// BinaryVersion runs 'cockroach version' and returns the build tag of the binary, such as v23.2.10
+func BinaryVersion(path string) (string, error) {
+	out, err := exec.Command(path, "version").Output()
+	if err != nil {
+		return "", fmt.Errorf("could not get the version of cockroach binary '%s': %w", path, err)
+	}
+	return parseBuildTag(string(out))
+}
+
+// parseBuildTag gets the build tag from the output of 'cockroach version'
+func parseBuildTag(output string) (string, error) {
+	for _, line := range strings.Split(output, "\n") {
+		if tag, ok := strings.CutPrefix(strings.TrimSpace(line), "Build Tag:"); ok {
+			return strings.TrimSpace(tag), nil
+		}
+	}
+	return "", fmt.Errorf("build tag not found in cockroach version output")
+}
+
+// CacheBinaryProvider uses binaries stored at <dir>/<release>/cockroach, downloading missing binaries from the
+// mirror into the cache if a mirror is configured
+type CacheBinaryProvider struct {
+	Dir    string
+	Mirror *MirrorBinaryProvider
+}
+
+func (p *CacheBinaryProvider) Binary(release string) (string, error) {
+	path := binaryPathForRelease(p.Dir, release)
+	if _, err := os.Stat(path); err == nil {
+		return filepath.Abs(path)
+	}
+	if p.Mirror == nil {
+		return "", fmt.Errorf("cockroach binary for '%s' not found in cache '%s'", release, p.Dir)
+	}
+	return p.Mirror.Binary(release)
+}
+
+func (p *CacheBinaryProvider) Done(release string) error {
+	return nil
+}
+
+// MirrorBinaryProvider downloads release archives from a mirror with the same layout as DefaultMirrorUrl and
+// extracts the cockroach binary to <dir>/<release>/cockroach. Unless Keep is set, the binary is removed when done.
+type MirrorBinaryProvider struct {
+	Url  string
+	Dir  string
+	Keep bool
+}
+
+func (p *MirrorBinaryProvider) Binary(release string) (string, error) {
+	path := binaryPathForRelease(p.Dir, release)
+	if _, err := os.Stat(path); err == nil {
+		return filepath.Abs(path)
+	}
+
+	archive, err := archiveName(release)
+	if err != nil {
+		return "", err
+	}
+	url := strings.TrimSuffix(p.Url, "/") + "/" + archive
+	logrus.Info(fmt.Sprintf("Downloading cockroach binary for '%s' from %s", release, url))
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("could not download cockroach binary: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("could not download cockroach binary from '%s': %s", url, resp.Status)
+	}
+
+	if err := extractBinary(resp.Body, path); err != nil {
+		return "", fmt.Errorf("could not extract cockroach binary from '%s': %w", url, err)
+	}
+	return filepath.Abs(path)
+}
+
+func (p *MirrorBinaryProvider) Done(release string) error {
+	if p.Keep {
+		return nil
+	}
+	return os.RemoveAll(filepath.Dir(binaryPathForRelease(p.Dir, release)))
+}
+
+func binaryPathForRelease(dir string, release string) string {
+	return filepath.Join(dir, release, "cockroach")
+}
+
+// archiveName returns the name of the release archive for the current platform, matching the names used on
+// DefaultMirrorUrl
+func archiveName(release string) (string, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return fmt.Sprintf("cockroach-%s.linux-%s.tgz", release, runtime.GOARCH), nil
+	case "darwin":
+		osVersion := "10.9"
+		if runtime.GOARCH == "arm64" {
+			osVersion = "11.0"
+		}
+		return fmt.Sprintf("cockroach-%s.darwin-%s-%s.tgz", release, osVersion, runtime.GOARCH), nil
+	}
+	return "", fmt.Errorf("cockroach binaries are not supported on %s", runtime.GOOS)
+}
+
+// extractBinary extracts the cockroach binary from a gzipped tar archive to path. The binary is written to a
+// temporary file and renamed so that a partially written binary is never used.
+func extractBinary(r io.Reader, path string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return fmt.Errorf("cockroach binary not found in archive")
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg || filepath.Base(header.Name) != "cockroach" {
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		tmp, err := os.CreateTemp(filepath.Dir(path), "cockroach-*.tmp")
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(tmp, tr); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return err
+		}
+		if err := tmp.Chmod(0755); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return err
+		}
+		if err := tmp.Close(); err != nil {
+			os.Remove(tmp.Name())
+			return err
+		}
+		return os.Rename(tmp.Name(), path)
+	}
+}
@@ -0,0 +1,127 @@
+package crdbcluster
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func releaseArchive(t *testing.T, contents string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, body := range map[string]string{
+		"cockroach-v23.2.10.linux-amd64/lib/libgeos.so": "geos",
+		"cockroach-v23.2.10.linux-amd64/cockroach":      contents,
+	} {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(body)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(body))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestMirrorBinaryProvider(t *testing.T) {
+	archive, err := archiveName("v23.2.10")
+	assert.NoError(t, err)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/"+archive {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(releaseArchive(t, "binary"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	p := &MirrorBinaryProvider{Url: server.URL, Dir: dir}
+
+	path, err := p.Binary("v23.2.10")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "v23.2.10", "cockroach"), path)
+	b, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "binary", string(b))
+
+	// A second request uses the extracted binary
+	_, err = p.Binary("v23.2.10")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, requests)
+
+	assert.NoError(t, p.Done("v23.2.10"))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = p.Binary("v99.1.0")
+	assert.Error(t, err)
+}
+
+func TestCacheBinaryProvider(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "v23.2.10"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "v23.2.10", "cockroach"), []byte("binary"), 0755))
+
+	p := NewBinaryProvider("", dir, "")
+	path, err := p.Binary("v23.2.10")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "v23.2.10", "cockroach"), path)
+
+	// Cached binaries are kept
+	assert.NoError(t, p.Done("v23.2.10"))
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+
+	// Without a mirror, a missing binary is an error instead of a download
+	_, err = p.Binary("v24.1.0")
+	assert.Error(t, err)
+}
+
+// fakeCockroach writes a script that reports the version like 'cockroach version' does
+func fakeCockroach(t *testing.T, dir string, version string) string {
+	binary := filepath.Join(dir, "cockroach")
+	script := fmt.Sprintf("#!/bin/sh\necho 'Build Tag:        %s'\necho 'Go Version:       go1.22.5'\n", version)
+	assert.NoError(t, os.WriteFile(binary, []byte(script), 0755))
+	return binary
+}
+
+func TestLocalBinaryProvider(t *testing.T) {
+	dir := t.TempDir()
+	binary := fakeCockroach(t, dir, "v23.2.10")
+
+	p := NewBinaryProvider(binary, dir, "https://example.com")
+	path, err := p.Binary("v23.2.10")
+	assert.NoError(t, err)
+	assert.Equal(t, binary, path)
+
+	// The binary is never used for another release
+	_, err = p.Binary("v24.1.0")
+	var versionErr *BinaryVersionError
+	assert.ErrorAs(t, err, &versionErr)
+	assert.Equal(t, "v23.2.10", versionErr.Version)
+	assert.Equal(t, "v24.1.0", versionErr.Release)
+
+	_, err = NewBinaryProvider(filepath.Join(dir, "missing"), "", "").Binary("v23.2.10")
+	assert.Error(t, err)
+}
+
+func TestParseBuildTag(t *testing.T) {
+	tag, err := parseBuildTag("Build Tag:        v23.2.10\nBuild Time:       2024/08/12 16:16:52\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "v23.2.10", tag)
+
+	_, err = parseBuildTag("Usage:\n  cockroach [command]\n")
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package crdbcluster
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -9,49 +10,166 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/cockroachdb/cockroach-go/v2/testserver"
 	"github.com/jonstjohn/crdb-settings/pkg/dbpgx"
+	"github.com/jonstjohn/crdb-settings/pkg/exposition"
+	"github.com/jonstjohn/crdb-settings/pkg/host"
 	"github.com/sirupsen/logrus"
 )
 
+// defaultCacheSize matches the cockroach-go testserver default, which is a proportion of host memory
+const defaultCacheSize = 0.1
+
+// defaultMaxSqlMemory matches the CockroachDB default, which is a proportion of host memory
+const defaultMaxSqlMemory = 0.25
+
 type Manager struct {
 	TestServer *testserver.TestServer
+	Binaries   BinaryProvider
+	Nodes      int      // number of nodes in the test cluster, a single node if not set
+	StartArgs  []string // flags added to the start command of every node, such as those from ShapeArgs
+	startDir   string   // directory of the start wrapper, removed with the cluster
 }
 
+// NewManager returns a manager that downloads binaries from DefaultMirrorUrl, set Binaries to use another source
 func NewManager() *Manager {
-	return &Manager{}
+	return &Manager{Binaries: NewBinaryProvider("", "", "")}
 }
 
-func (m *Manager) StartTestCluster(releaseName string) error {
-	t, err := testserver.NewTestServer(
-		testserver.CustomVersionOpt(releaseName))
+// StartTestCluster starts a test server for the release using the binary from the binary provider, with Nodes
+// nodes and StartArgs added to the start command of every node. Additional test server options, such as those from
+// ShapeOpts, are applied after the binary path.
+func (m *Manager) StartTestCluster(releaseName string, opts ...testserver.TestServerOpt) error {
+	nodeOpts, err := NodesOpts(m.NodeCount())
+	if err != nil {
+		return err
+	}
+	if m.Binaries == nil {
+		m.Binaries = NewBinaryProvider("", "", "")
+	}
+	binary, err := m.Binaries.Binary(releaseName)
 	if err != nil {
 		return err
 	}
+	if len(m.StartArgs) > 0 {
+		if m.startDir, err = os.MkdirTemp("", "crdb-settings-start-*"); err != nil {
+			return err
+		}
+		if binary, err = writeStartWrapper(m.startDir, binary, m.StartArgs); err != nil {
+			return errors.Join(err, m.removeStartDir())
+		}
+	}
+
+	tsOpts := append([]testserver.TestServerOpt{testserver.CockroachBinaryPathOpt(binary)}, nodeOpts...)
+	t, err := testserver.NewTestServer(append(tsOpts, opts...)...)
+	if err != nil {
+		return errors.Join(err, m.removeStartDir())
+	}
 
 	m.TestServer = &t
 	return nil
 }
 
-func (m *Manager) CleanupTestCluster() error {
+// writeStartWrapper writes a script to dir that runs the binary with args added to its start commands, since the
+// test server has no option for additional start flags. Flags given later take precedence, so args override the
+// flags of the test server.
+func writeStartWrapper(dir string, binary string, args []string) (string, error) {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	script := fmt.Sprintf(`#!/bin/sh
+case "$1" in
+start|start-single-node) exec %s "$@" %s ;;
+esac
+exec %s "$@"
+`, shellQuote(binary), strings.Join(quoted, " "), shellQuote(binary))
 
-	(*m.TestServer).Stop()
+	wrapper := filepath.Join(dir, "cockroach")
+	if err := os.WriteFile(wrapper, []byte(script), 0755); err != nil {
+		return "", err
+	}
+	return wrapper, nil
+}
 
-	// Delete files
-	files, err := filepath.Glob(filepath.Join(os.TempDir(), "cockroach-v*"))
-	if err != nil {
-		return err
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func (m *Manager) removeStartDir() error {
+	if m.startDir == "" {
+		return nil
 	}
-	for _, f := range files {
-		if err := os.Remove(f); err != nil {
-			return err
-		}
+	err := os.RemoveAll(m.startDir)
+	m.startDir = ""
+	return err
+}
+
+// ShapeOpts returns test server options that simulate the CPU count of a shape with GOMAXPROCS. The memory of the
+// shape is simulated with ShapeArgs, and can be no more than the memory of the host.
+func ShapeOpts(shape host.Shape, hostMemoryBytes int64) ([]testserver.TestServerOpt, error) {
+	if shape.Cpu <= 0 {
+		return nil, fmt.Errorf("cpu count must be positive, got %d", shape.Cpu)
+	}
+	if shape.MemoryBytes <= 0 || shape.MemoryBytes > hostMemoryBytes {
+		return nil, fmt.Errorf("memory size %d must be positive and no more than host memory %d",
+			shape.MemoryBytes, hostMemoryBytes)
 	}
 
+	return []testserver.TestServerOpt{
+		testserver.EnvVarOpt([]string{fmt.Sprintf("GOMAXPROCS=%d", shape.Cpu)}),
+	}, nil
+}
+
+// ShapeArgs returns start flags that size the cache and SQL memory of every node from the memory of the shape, with
+// the same proportions that would be used on a host with that memory
+func ShapeArgs(shape host.Shape) []string {
+	return []string{
+		fmt.Sprintf("--cache=%d", int64(float64(shape.MemoryBytes)*defaultCacheSize)),
+		fmt.Sprintf("--max-sql-memory=%d", int64(float64(shape.MemoryBytes)*defaultMaxSqlMemory)),
+	}
+}
+
+// NodeCount returns the number of nodes in the test cluster
+func (m *Manager) NodeCount() int {
+	if m.Nodes <= 0 {
+		return 1
+	}
+	return m.Nodes
+}
+
+// NodesOpts returns test server options for a cluster with the given number of nodes. The test server only supports
+// single node and three node clusters. Nodes are started one after another and join the nodes already running, so
+// no ports need to be reserved.
+func NodesOpts(nodes int) ([]testserver.TestServerOpt, error) {
+	switch nodes {
+	case 1:
+		return nil, nil
+	case 3:
+		return []testserver.TestServerOpt{testserver.ThreeNodeOpt()}, nil
+	}
+	return nil, fmt.Errorf("test clusters can have 1 or 3 nodes, got %d", nodes)
+}
+
+func (m *Manager) CleanupTestCluster() error {
+	if err := m.errorTestServerNotRunning(); err != nil {
+		return err
+	}
+
+	(*m.TestServer).Stop()
 	m.TestServer = nil
+	return m.removeStartDir()
+}
 
-	return nil
+// ReleaseDone lets the binary provider remove the binary for the release, if it isn't being kept. It is called once
+// every cluster for the release has been cleaned up, since a release may be started once per shape.
+func (m *Manager) ReleaseDone(releaseName string) error {
+	if m.Binaries == nil {
+		return nil
+	}
+	return m.Binaries.Done(releaseName)
 }
 
 func (m *Manager) GetPGUrl() (*url.URL, error) {
@@ -61,20 +179,27 @@ func (m *Manager) GetPGUrl() (*url.URL, error) {
 	return (*m.TestServer).PGURL(), nil
 }
 
+// GetDbConsoleURL returns the DB Console URL of the first node
 func (m *Manager) GetDbConsoleURL() (*url.URL, error) {
+	return m.GetDbConsoleURLForNode(0)
+}
+
+// GetDbConsoleURLForNode returns the DB Console URL of a node, which is read from the node itself since each node
+// has its own HTTP port
+func (m *Manager) GetDbConsoleURLForNode(node int) (*url.URL, error) {
 	if err := m.errorTestServerNotRunning(); err != nil {
 		return nil, err
 	}
-
-	pgurl, err := m.GetPGUrl()
-	if err != nil {
-		return nil, err
+	if node < 0 || node >= m.NodeCount() {
+		return nil, fmt.Errorf("node %d does not exist in a %d node cluster", node, m.NodeCount())
 	}
-	pool, err := dbpgx.NewPoolFromUrl(pgurl.String())
 
+	pgurl := (*m.TestServer).PGURLForNode(node)
+	pool, err := dbpgx.NewPoolFromUrl(pgurl.String())
 	if err != nil {
 		return nil, err
 	}
+	defer pool.Close()
 
 	conn, err := pool.Acquire(context.Background())
 
@@ -111,8 +236,26 @@ LIMIT 1
 
 }
 
+// GetMetricsEndpointOutput returns the metrics output of the first node
 func (m *Manager) GetMetricsEndpointOutput() ([]byte, error) {
-	ep, err := m.GetMetricsEndpoint()
+	return m.GetMetricsEndpointOutputForNode(0)
+}
+
+// GetMetricsEndpointOutputs returns the metrics output of every node, in node order
+func (m *Manager) GetMetricsEndpointOutputs() ([][]byte, error) {
+	outputs := make([][]byte, 0, m.NodeCount())
+	for i := 0; i < m.NodeCount(); i++ {
+		output, err := m.GetMetricsEndpointOutputForNode(i)
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, output)
+	}
+	return outputs, nil
+}
+
+func (m *Manager) GetMetricsEndpointOutputForNode(node int) ([]byte, error) {
+	ep, err := m.GetMetricsEndpointForNode(node)
 	if err != nil {
 		return nil, err
 	}
@@ -126,11 +269,41 @@ func (m *Manager) GetMetricsEndpointOutput() ([]byte, error) {
 	return io.ReadAll(resp.Body)
 }
 
-func (m *Manager) GetMetricsEndpoint() (*url.URL, error) {
-	if err := m.errorTestServerNotRunning(); err != nil {
+// GetMetricsFamilies scrapes and parses the metrics of every node, in node order
+func (m *Manager) GetMetricsFamilies() ([][]exposition.Family, error) {
+	nodeFamilies := make([][]exposition.Family, 0, m.NodeCount())
+	for i := 0; i < m.NodeCount(); i++ {
+		families, err := m.GetMetricsFamiliesForNode(i)
+		if err != nil {
+			return nil, err
+		}
+		nodeFamilies = append(nodeFamilies, families)
+	}
+	return nodeFamilies, nil
+}
+
+// GetMetricsFamiliesForNode scrapes and parses the metrics of a node, in whichever exposition format the node
+// prefers of those that can be parsed
+func (m *Manager) GetMetricsFamiliesForNode(node int) ([]exposition.Family, error) {
+	ep, err := m.GetMetricsEndpointForNode(node)
+	if err != nil {
 		return nil, err
 	}
-	consoleUrl, err := m.GetDbConsoleURL()
+	families, format, err := exposition.Scrape(http.DefaultClient, ep.String())
+	if err != nil {
+		return nil, err
+	}
+	logrus.Debug(fmt.Sprintf("Scraped %d metrics from node %d in the %s format", len(families), node, format))
+	return families, nil
+}
+
+// GetMetricsEndpoint returns the metrics endpoint of the first node
+func (m *Manager) GetMetricsEndpoint() (*url.URL, error) {
+	return m.GetMetricsEndpointForNode(0)
+}
+
+func (m *Manager) GetMetricsEndpointForNode(node int) (*url.URL, error) {
+	consoleUrl, err := m.GetDbConsoleURLForNode(node)
 	if err != nil {
 		return nil, err
 	}
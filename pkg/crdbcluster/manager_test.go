@@ -1,7 +1,11 @@
 package crdbcluster
 
 import (
+	"github.com/jonstjohn/crdb-settings/pkg/host"
 	"github.com/stretchr/testify/assert"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
 )
 
@@ -38,3 +42,58 @@ func TestManager_GetMetricsEndpointOutput(t *testing.T) {
 
 	assert.Contains(t, string(output), "HELP")
 }
+
+func TestShapeOpts(t *testing.T) {
+	opts, err := ShapeOpts(host.Shape{Cpu: 4, MemoryBytes: 4 << 30}, 16<<30)
+	assert.NoError(t, err)
+	assert.Len(t, opts, 1)
+
+	_, err = ShapeOpts(host.Shape{Cpu: 4, MemoryBytes: 32 << 30}, 16<<30)
+	assert.Error(t, err)
+
+	_, err = ShapeOpts(host.Shape{Cpu: 0, MemoryBytes: 4 << 30}, 16<<30)
+	assert.Error(t, err)
+}
+
+func TestShapeArgs(t *testing.T) {
+	assert.Equal(t, []string{"--cache=429496729", "--max-sql-memory=1073741824"},
+		ShapeArgs(host.Shape{Cpu: 4, MemoryBytes: 4 << 30}))
+}
+
+func TestWriteStartWrapper(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "it's cockroach")
+	assert.NoError(t, os.WriteFile(binary, []byte("#!/bin/sh\necho \"$@\"\n"), 0755))
+
+	wrapper, err := writeStartWrapper(t.TempDir(), binary, []string{"--cache=1024", "--max-sql-memory=2048"})
+	assert.NoError(t, err)
+
+	out, err := exec.Command(wrapper, "start-single-node", "--cache=0.1000").Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "start-single-node --cache=0.1000 --cache=1024 --max-sql-memory=2048\n", string(out))
+
+	// Other commands, such as version and init, are passed through
+	out, err = exec.Command(wrapper, "version").Output()
+	assert.NoError(t, err)
+	assert.Equal(t, "version\n", string(out))
+}
+
+func TestNodesOpts(t *testing.T) {
+	opts, err := NodesOpts(1)
+	assert.NoError(t, err)
+	assert.Empty(t, opts)
+
+	opts, err = NodesOpts(3)
+	assert.NoError(t, err)
+	assert.Len(t, opts, 1)
+
+	for _, n := range []int{0, 2, 5} {
+		_, err = NodesOpts(n)
+		assert.Error(t, err)
+	}
+}
+
+func TestManager_NodeCount(t *testing.T) {
+	assert.Equal(t, 1, NewManager().NodeCount())
+	assert.Equal(t, 3, (&Manager{Nodes: 3}).NodeCount())
+}
@@ -0,0 +1,178 @@
+package crdbcluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ReleaseJob captures a single release using the cluster manager of the worker running it. Jobs should stop early
+// once the context is canceled. A job may start several clusters for the release, the binary of the release is only
+// released once the job returns.
+type ReleaseJob func(ctx context.Context, cm *Manager, release string) error
+
+// ReleaseResult is the outcome of a release job. Err is the context error for releases that were not started
+// because the run was canceled.
+type ReleaseResult struct {
+	Release  string
+	Worker   int
+	Duration time.Duration
+	Err      error
+}
+
+// Progress is reported each time a release job finishes
+type Progress struct {
+	ReleaseResult
+	Done  int
+	Total int
+}
+
+// Pool runs release jobs on a fixed number of workers. Each worker owns its own cluster manager, and the test server
+// picks free ports and creates a temporary store directory for each cluster, so workers do not share ports or
+// stores.
+type Pool struct {
+	Parallelism int              // number of workers, a single worker if not set
+	NewManager  func() *Manager  // creates the cluster manager of a worker
+	Progress    func(p Progress) // called as each release finishes, logs progress if not set
+	progressMu  sync.Mutex       // serializes progress callbacks
+}
+
+// NewPool returns a pool with the given number of workers, each with a manager for clusters with the given number
+// of nodes using the binary provider
+func NewPool(parallelism int, nodes int, binaries BinaryProvider) *Pool {
+	return &Pool{
+		Parallelism: parallelism,
+		NewManager: func() *Manager {
+			cm := NewManager()
+			if binaries != nil {
+				cm.Binaries = binaries
+			}
+			cm.Nodes = nodes
+			return cm
+		},
+	}
+}
+
+// Run runs the job for each release and returns the results in release order. A release that fails or panics does
+// not stop the other releases. Canceling the context stops workers from starting more releases, and the releases
+// that were not started get the context error.
+func (p *Pool) Run(ctx context.Context, releases []string, job ReleaseJob) []ReleaseResult {
+	results := make([]ReleaseResult, len(releases))
+	workers := max(p.Parallelism, 1)
+	if workers > len(releases) {
+		workers = len(releases)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	done := 0
+	for w := 1; w <= workers; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			cm := p.NewManager()
+			for i := range jobs {
+				if err := ctx.Err(); err != nil { // canceled while the release was being handed over
+					results[i] = ReleaseResult{Release: releases[i], Err: err}
+					continue
+				}
+				start := time.Now()
+				err := runJob(ctx, cm, releases[i], job)
+				results[i] = ReleaseResult{Release: releases[i], Worker: worker, Duration: time.Since(start), Err: err}
+
+				p.progressMu.Lock()
+				done++
+				p.report(Progress{ReleaseResult: results[i], Done: done, Total: len(releases)})
+				p.progressMu.Unlock()
+			}
+		}(w)
+	}
+
+	next := 0
+feed:
+	for ; next < len(releases) && ctx.Err() == nil; next++ {
+		select {
+		case jobs <- next:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i := next; i < len(releases); i++ {
+		results[i] = ReleaseResult{Release: releases[i], Err: ctx.Err()}
+	}
+	return results
+}
+
+// runJob runs the job for a release and then lets the binary provider remove the binary of the release. A panic in
+// the job, such as from the test server or a driver, is returned as the error of the release and the cluster it left
+// running is stopped, so that it does not stop the other releases.
+func runJob(ctx context.Context, cm *Manager, release string, job ReleaseJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+			if cm.TestServer != nil {
+				err = errors.Join(err, cm.CleanupTestCluster())
+			}
+		}
+		err = errors.Join(err, cm.ReleaseDone(release))
+	}()
+	return job(ctx, cm, release)
+}
+
+func (p *Pool) report(progress Progress) {
+	if p.Progress != nil {
+		p.Progress(progress)
+		return
+	}
+	if progress.Err != nil {
+		logrus.Error(fmt.Sprintf("[%d/%d] Capturing '%s' failed on worker %d after %s: %v", progress.Done,
+			progress.Total, progress.Release, progress.Worker, progress.Duration.Round(time.Second), progress.Err))
+		return
+	}
+	logrus.Info(fmt.Sprintf("[%d/%d] Captured '%s' on worker %d in %s", progress.Done, progress.Total,
+		progress.Release, progress.Worker, progress.Duration.Round(time.Second)))
+}
+
+// CaptureError is returned when one or more releases could not be captured, after every other release was captured
+type CaptureError struct {
+	Failed []ReleaseResult
+}
+
+func (e *CaptureError) Error() string {
+	failures := make([]string, len(e.Failed))
+	for i, r := range e.Failed {
+		failures[i] = fmt.Sprintf("%s: %v", r.Release, r.Err)
+	}
+	return fmt.Sprintf("%d release(s) could not be captured: %s", len(e.Failed), strings.Join(failures, "; "))
+}
+
+// Unwrap returns the errors of the failed releases, so that callers can check for context cancellation
+func (e *CaptureError) Unwrap() []error {
+	errs := make([]error, len(e.Failed))
+	for i, r := range e.Failed {
+		errs[i] = r.Err
+	}
+	return errs
+}
+
+// ResultsError returns a CaptureError for the failed releases, or nil if every release was captured
+func ResultsError(results []ReleaseResult) error {
+	failed := make([]ReleaseResult, 0)
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return &CaptureError{Failed: failed}
+}
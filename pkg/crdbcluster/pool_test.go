@@ -0,0 +1,161 @@
+package crdbcluster
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolRun(t *testing.T) {
+	releases := []string{"v23.1.0", "v23.2.0", "v24.1.0", "v24.2.0", "v24.3.0"}
+	failure := errors.New("could not start")
+
+	var running, maxRunning atomic.Int32
+	var mu sync.Mutex
+	managers := make(map[*Manager]bool)
+	progress := make([]Progress, 0)
+
+	p := NewPool(2, 3, &LocalBinaryProvider{Path: "cockroach"})
+	p.Progress = func(pr Progress) { progress = append(progress, pr) }
+	results := p.Run(context.Background(), releases, func(ctx context.Context, cm *Manager, release string) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		mu.Lock()
+		managers[cm] = true
+		mu.Unlock()
+		assert.Equal(t, 3, cm.NodeCount())
+
+		time.Sleep(10 * time.Millisecond)
+		if release == "v24.1.0" {
+			return failure
+		}
+		return nil
+	})
+
+	assert.Len(t, results, len(releases))
+	for i, r := range results {
+		assert.Equal(t, releases[i], r.Release)
+		assert.NotZero(t, r.Worker)
+	}
+	assert.ErrorIs(t, results[2].Err, failure)
+	assert.NoError(t, results[4].Err)
+
+	// Each worker owns its own manager
+	assert.Equal(t, int32(2), maxRunning.Load())
+	assert.Len(t, managers, 2)
+
+	assert.Len(t, progress, len(releases))
+	for i, pr := range progress {
+		assert.Equal(t, i+1, pr.Done)
+		assert.Equal(t, len(releases), pr.Total)
+	}
+
+	var captureErr *CaptureError
+	assert.ErrorAs(t, ResultsError(results), &captureErr)
+	assert.Len(t, captureErr.Failed, 1)
+	assert.Equal(t, "v24.1.0", captureErr.Failed[0].Release)
+	assert.ErrorIs(t, captureErr, failure)
+}
+
+func TestPoolRunCanceled(t *testing.T) {
+	releases := []string{"v23.1.0", "v23.2.0", "v24.1.0", "v24.2.0"}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := NewPool(1, 1, nil)
+	p.Progress = func(pr Progress) {}
+	results := p.Run(ctx, releases, func(ctx context.Context, cm *Manager, release string) error {
+		cancel()
+		return nil
+	})
+
+	assert.NoError(t, results[0].Err)
+	for _, r := range results[1:] {
+		assert.ErrorIs(t, r.Err, context.Canceled)
+		assert.Zero(t, r.Worker)
+	}
+	assert.ErrorIs(t, ResultsError(results), context.Canceled)
+}
+
+func TestPoolRunDefaults(t *testing.T) {
+	p := NewPool(0, 1, nil)
+	p.Progress = func(pr Progress) {}
+	results := p.Run(context.Background(), []string{"v23.1.0"}, func(ctx context.Context, cm *Manager, release string) error {
+		return nil
+	})
+	assert.Equal(t, 1, results[0].Worker)
+	assert.NoError(t, ResultsError(results))
+
+	assert.Empty(t, NewPool(4, 1, nil).Run(context.Background(), nil, nil))
+}
+
+type countingBinaryProvider struct {
+	mu   sync.Mutex
+	done map[string]int
+	err  error
+}
+
+func (p *countingBinaryProvider) Binary(release string) (string, error) {
+	return "cockroach", nil
+}
+
+func (p *countingBinaryProvider) Done(release string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done[release]++
+	return p.err
+}
+
+func TestPoolRunReleaseDone(t *testing.T) {
+	releases := []string{"v23.1.0", "v23.2.0", "v24.1.0"}
+	binaries := &countingBinaryProvider{done: make(map[string]int)}
+
+	p := NewPool(2, 1, binaries)
+	p.Progress = func(pr Progress) {}
+	results := p.Run(context.Background(), releases, func(ctx context.Context, cm *Manager, release string) error {
+		// Done is not called between the clusters of a release
+		binaries.mu.Lock()
+		defer binaries.mu.Unlock()
+		assert.Zero(t, binaries.done[release])
+		return nil
+	})
+	assert.NoError(t, ResultsError(results))
+	assert.Equal(t, map[string]int{"v23.1.0": 1, "v23.2.0": 1, "v24.1.0": 1}, binaries.done)
+
+	binaries.err = errors.New("could not remove binary")
+	results = p.Run(context.Background(), releases[:1], func(ctx context.Context, cm *Manager, release string) error {
+		return nil
+	})
+	assert.ErrorIs(t, results[0].Err, binaries.err)
+}
+
+func TestPoolRunPanic(t *testing.T) {
+	releases := []string{"v23.1.0", "v23.2.0", "v24.1.0"}
+	binaries := &countingBinaryProvider{done: make(map[string]int)}
+
+	p := NewPool(1, 1, binaries)
+	p.Progress = func(pr Progress) {}
+	results := p.Run(context.Background(), releases, func(ctx context.Context, cm *Manager, release string) error {
+		if release == "v23.2.0" {
+			panic("connection reset")
+		}
+		return nil
+	})
+
+	assert.NoError(t, results[0].Err)
+	assert.EqualError(t, results[1].Err, "panic: connection reset")
+	assert.NoError(t, results[2].Err)
+	// The binary of the release that panicked is still released
+	assert.Equal(t, map[string]int{"v23.1.0": 1, "v23.2.0": 1, "v24.1.0": 1}, binaries.done)
+}
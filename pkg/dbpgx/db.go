@@ -2,9 +2,14 @@ package dbpgx
 
 import (
 	"context"
+	"fmt"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 func NewPoolFromUrl(url string) (*pgxpool.Pool, error) {
-	return pgxpool.New(context.Background(), url)
+	pool, err := pgxpool.New(context.Background(), url)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return pool, nil
 }
@@ -0,0 +1,19 @@
+package dbpgx
+
+import (
+	"errors"
+	"net"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrUnavailable is returned when the database cannot be reached
+var ErrUnavailable = errors.New("database unavailable")
+
+// IsUnavailable reports whether an error means that the database could not be reached, either because the pool
+// could not be created or because a connection could not be established
+func IsUnavailable(err error) bool {
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	return errors.Is(err, ErrUnavailable) || errors.As(err, &connectErr) || errors.As(err, &netErr)
+}
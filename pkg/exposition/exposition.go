@@ -0,0 +1,140 @@
+package exposition
+
+// The exposition package parses the formats that Prometheus metrics are exposed in: the Prometheus text format
+// 0.0.4, the OpenMetrics 1.0 text format and the delimited protobuf format. Parsed metrics are returned as families
+// of samples, so that callers do not depend on the format.
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+type Format int
+
+const (
+	FormatText Format = iota
+	FormatOpenMetrics
+	FormatProtobuf
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatText:
+		return "text"
+	case FormatOpenMetrics:
+		return "openmetrics"
+	case FormatProtobuf:
+		return "protobuf"
+	}
+	return fmt.Sprintf("unknown(%d)", int(f))
+}
+
+// AcceptHeader asks for the formats that can be parsed, preferring protobuf since it needs no escaping
+const AcceptHeader = "application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited;q=0.7," +
+	"application/openmetrics-text;version=1.0.0;q=0.5," +
+	"text/plain;version=0.0.4;q=0.3," +
+	"*/*;q=0.1"
+
+// Metric types. OpenMetrics unknown metrics and metrics without a TYPE line are untyped.
+const (
+	TypeCounter        = "counter"
+	TypeGauge          = "gauge"
+	TypeHistogram      = "histogram"
+	TypeGaugeHistogram = "gaugehistogram"
+	TypeSummary        = "summary"
+	TypeInfo           = "info"
+	TypeStateSet       = "stateset"
+	TypeUntyped        = "untyped"
+)
+
+// Family is a metric and its samples. Histograms and summaries have samples for each series with the _bucket, _sum
+// and _count suffixes as in the text format, including for protobuf.
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Unit    string
+	Samples []Sample
+}
+
+type Sample struct {
+	Name   string
+	Labels []Label
+	Value  float64
+}
+
+type Label struct {
+	Name  string
+	Value string
+}
+
+// ParseError is returned for exposition that is not valid in its format
+type ParseError struct {
+	Format Format
+	Line   int // line of text formats, or the index of the message for protobuf
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	if e.Format == FormatProtobuf {
+		return fmt.Sprintf("invalid %s exposition at message %d: %s", e.Format, e.Line, e.Msg)
+	}
+	return fmt.Sprintf("invalid %s exposition at line %d: %s", e.Format, e.Line, e.Msg)
+}
+
+// FormatFromContentType returns the format of a response's content type. Content types that are not recognized
+// are the Prometheus text format, which is also what servers send when they do not support negotiation.
+func FormatFromContentType(contentType string) Format {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return FormatText
+	}
+	switch mediaType {
+	case "application/openmetrics-text":
+		return FormatOpenMetrics
+	case "application/vnd.google.protobuf":
+		if params["proto"] == "io.prometheus.client.MetricFamily" && params["encoding"] == "delimited" {
+			return FormatProtobuf
+		}
+	}
+	return FormatText
+}
+
+// Parse parses exposition in the given format
+func Parse(data []byte, format Format) ([]Family, error) {
+	switch format {
+	case FormatOpenMetrics:
+		return ParseOpenMetrics(data)
+	case FormatProtobuf:
+		return ParseProtobuf(data)
+	}
+	return ParseText(data)
+}
+
+// Scrape gets the metrics from an endpoint, negotiating the format with AcceptHeader
+func Scrape(client *http.Client, url string) ([]Family, Format, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, FormatText, err
+	}
+	req.Header.Set("Accept", AcceptHeader)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, FormatText, fmt.Errorf("could not download metrics data: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, FormatText, fmt.Errorf("could not download metrics data: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, FormatText, err
+	}
+	format := FormatFromContentType(resp.Header.Get("Content-Type"))
+	families, err := Parse(data, format)
+	return families, format, err
+}
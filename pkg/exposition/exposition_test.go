@@ -0,0 +1,71 @@
+package exposition
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatFromContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		format      Format
+	}{
+		{"text/plain; version=0.0.4; charset=utf-8", FormatText},
+		{"application/openmetrics-text; version=1.0.0; charset=utf-8", FormatOpenMetrics},
+		{"application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=delimited",
+			FormatProtobuf},
+		{"application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=text", FormatText},
+		{"", FormatText},
+		{"not a content type;;", FormatText},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			assert.Equal(t, tt.format, FormatFromContentType(tt.contentType))
+		})
+	}
+}
+
+func TestScrape(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		format      Format
+	}{
+		{"text", "text/plain; version=0.0.4", []byte("# TYPE sys_uptime gauge\nsys_uptime 10\n"), FormatText},
+		{"openmetrics", "application/openmetrics-text; version=1.0.0",
+			[]byte("# TYPE sys_uptime gauge\nsys_uptime 10\n# EOF\n"), FormatOpenMetrics},
+		{"protobuf", "application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=delimited",
+			delimited(message(encodeString(familyName, "sys_uptime"), encodeVarint(familyType, 1),
+				encodeBytes(familyMetric, encodeBytes(metricGauge, encodeDouble(valueValue, 10))))), FormatProtobuf},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, AcceptHeader, r.Header.Get("Accept"))
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Write(tt.body)
+			}))
+			defer server.Close()
+
+			families, format, err := Scrape(server.Client(), server.URL)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.format, format)
+			assert.Equal(t, []Family{{Name: "sys_uptime", Type: TypeGauge, Samples: []Sample{
+				{Name: "sys_uptime", Labels: []Label{}, Value: 10}}}}, families)
+		})
+	}
+}
+
+func TestScrapeError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, _, err := Scrape(server.Client(), server.URL)
+	assert.ErrorContains(t, err, "503")
+}
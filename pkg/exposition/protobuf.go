@@ -0,0 +1,362 @@
+package exposition
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers of the io.prometheus.client messages
+const (
+	familyName   = 1
+	familyHelp   = 2
+	familyType   = 3
+	familyMetric = 4
+	familyUnit   = 5
+
+	metricLabel     = 1
+	metricGauge     = 2
+	metricCounter   = 3
+	metricSummary   = 4
+	metricUntyped   = 5
+	metricHistogram = 7
+
+	labelName  = 1
+	labelValue = 2
+
+	valueValue = 1 // the value of Gauge, Counter and Untyped
+
+	summaryCount    = 1
+	summarySum      = 2
+	summaryQuantile = 3
+	quantileQ       = 1
+	quantileValue   = 2
+
+	histogramCount      = 1
+	histogramSum        = 2
+	histogramBucket     = 3
+	histogramCountFloat = 4
+	bucketCount         = 1
+	bucketUpperBound    = 2
+	bucketCountFloat    = 4
+)
+
+// protobufTypes are the names of the MetricType enum values
+var protobufTypes = map[uint64]string{
+	0: TypeCounter,
+	1: TypeGauge,
+	2: TypeSummary,
+	3: TypeUntyped,
+	4: TypeHistogram,
+	5: TypeGaugeHistogram,
+}
+
+// ParseProtobuf parses length delimited io.prometheus.client.MetricFamily messages. Native histogram fields are
+// ignored, so only classic buckets are returned.
+func ParseProtobuf(data []byte) ([]Family, error) {
+	families := make([]Family, 0)
+	for i := 0; len(data) > 0; i++ {
+		size, n := protowire.ConsumeVarint(data)
+		if n < 0 {
+			return nil, &ParseError{Format: FormatProtobuf, Line: i, Msg: protowire.ParseError(n).Error()}
+		}
+		data = data[n:]
+		if size > uint64(len(data)) {
+			return nil, &ParseError{Format: FormatProtobuf, Line: i, Msg: "message is truncated"}
+		}
+
+		f, err := parseFamily(data[:size])
+		if err != nil {
+			return nil, &ParseError{Format: FormatProtobuf, Line: i, Msg: err.Error()}
+		}
+		families = append(families, f)
+		data = data[size:]
+	}
+	return families, nil
+}
+
+func parseFamily(b []byte) (Family, error) {
+	f := Family{Type: TypeCounter, Samples: make([]Sample, 0)} // counter is the default enum value
+	var metrics [][]byte
+	err := forEachField(b, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		var err error
+		switch num {
+		case familyName:
+			f.Name, err = stringValue(typ, v)
+		case familyHelp:
+			f.Help, err = stringValue(typ, v)
+		case familyUnit:
+			f.Unit, err = stringValue(typ, v)
+		case familyType:
+			var t uint64
+			if t, err = varintValue(typ, v); err == nil {
+				var ok bool
+				if f.Type, ok = protobufTypes[t]; !ok {
+					err = errors.New("unknown type " + strconv.FormatUint(t, 10))
+				}
+			}
+		case familyMetric:
+			var m []byte
+			if m, err = bytesValue(typ, v); err == nil {
+				metrics = append(metrics, m)
+			}
+		}
+		return err
+	})
+	if err != nil {
+		return f, err
+	}
+	if f.Name == "" {
+		return f, errors.New("family has no name")
+	}
+
+	// Metrics are parsed after the family so that the type is known whatever the field order
+	for _, m := range metrics {
+		samples, err := parseMetric(f.Name, f.Type, m)
+		if err != nil {
+			return f, err
+		}
+		f.Samples = append(f.Samples, samples...)
+	}
+	return f, nil
+}
+
+// parseMetric converts a metric to the samples the text format would have for it
+func parseMetric(name string, typ string, b []byte) ([]Sample, error) {
+	labels := make([]Label, 0)
+	var value float64
+	var summary, histogram []byte
+	err := forEachField(b, func(num protowire.Number, t protowire.Type, v []byte) error {
+		var err error
+		switch num {
+		case metricLabel:
+			var l Label
+			if l, err = parseLabel(t, v); err == nil {
+				labels = append(labels, l)
+			}
+		case metricGauge, metricCounter, metricUntyped:
+			var m []byte
+			if m, err = bytesValue(t, v); err == nil {
+				value, err = doubleField(m, valueValue)
+			}
+		case metricSummary:
+			summary, err = bytesValue(t, v)
+		case metricHistogram:
+			histogram, err = bytesValue(t, v)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case (typ == TypeSummary) && summary != nil:
+		return summarySamples(name, labels, summary)
+	case (typ == TypeHistogram || typ == TypeGaugeHistogram) && histogram != nil:
+		return histogramSamples(name, labels, histogram)
+	}
+	return []Sample{{Name: name, Labels: labels, Value: value}}, nil
+}
+
+func parseLabel(typ protowire.Type, v []byte) (Label, error) {
+	b, err := bytesValue(typ, v)
+	if err != nil {
+		return Label{}, err
+	}
+	var l Label
+	err = forEachField(b, func(num protowire.Number, t protowire.Type, v []byte) error {
+		var err error
+		switch num {
+		case labelName:
+			l.Name, err = stringValue(t, v)
+		case labelValue:
+			l.Value, err = stringValue(t, v)
+		}
+		return err
+	})
+	return l, err
+}
+
+func summarySamples(name string, labels []Label, b []byte) ([]Sample, error) {
+	samples := make([]Sample, 0)
+	var count, sum float64
+	err := forEachField(b, func(num protowire.Number, t protowire.Type, v []byte) error {
+		switch num {
+		case summaryCount:
+			c, err := varintValue(t, v)
+			count = float64(c)
+			return err
+		case summarySum:
+			var err error
+			sum, err = doubleValue(t, v)
+			return err
+		case summaryQuantile:
+			q, err := bytesValue(t, v)
+			if err != nil {
+				return err
+			}
+			quantile, err := doubleField(q, quantileQ)
+			if err != nil {
+				return err
+			}
+			value, err := doubleField(q, quantileValue)
+			if err != nil {
+				return err
+			}
+			samples = append(samples, Sample{Name: name, Labels: withLabel(labels, "quantile", quantile),
+				Value: value})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(samples,
+		Sample{Name: name + "_sum", Labels: labels, Value: sum},
+		Sample{Name: name + "_count", Labels: labels, Value: count}), nil
+}
+
+func histogramSamples(name string, labels []Label, b []byte) ([]Sample, error) {
+	samples := make([]Sample, 0)
+	var count, sum float64
+	hasInf := false
+	err := forEachField(b, func(num protowire.Number, t protowire.Type, v []byte) error {
+		var err error
+		switch num {
+		case histogramCount:
+			var c uint64
+			c, err = varintValue(t, v)
+			count = float64(c)
+		case histogramCountFloat:
+			count, err = doubleValue(t, v)
+		case histogramSum:
+			sum, err = doubleValue(t, v)
+		case histogramBucket:
+			var bucket []byte
+			if bucket, err = bytesValue(t, v); err != nil {
+				return err
+			}
+			var upper, c float64
+			err = forEachField(bucket, func(num protowire.Number, t protowire.Type, v []byte) error {
+				var err error
+				switch num {
+				case bucketCount:
+					var u uint64
+					u, err = varintValue(t, v)
+					c = float64(u)
+				case bucketCountFloat:
+					c, err = doubleValue(t, v)
+				case bucketUpperBound:
+					upper, err = doubleValue(t, v)
+				}
+				return err
+			})
+			hasInf = hasInf || math.IsInf(upper, 1)
+			samples = append(samples, Sample{Name: name + "_bucket", Labels: withLabel(labels, "le", upper), Value: c})
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !hasInf { // the +Inf bucket is implied in protobuf
+		samples = append(samples, Sample{Name: name + "_bucket", Labels: withLabel(labels, "le", math.Inf(1)),
+			Value: count})
+	}
+	return append(samples,
+		Sample{Name: name + "_sum", Labels: labels, Value: sum},
+		Sample{Name: name + "_count", Labels: labels, Value: count}), nil
+}
+
+// withLabel returns the labels with another label, formatting the value as the text format does
+func withLabel(labels []Label, name string, value float64) []Label {
+	l := make([]Label, len(labels), len(labels)+1)
+	copy(l, labels)
+	return append(l, Label{Name: name, Value: formatFloat(value)})
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// forEachField calls fn with the number, type and encoded value of each field of a message
+func forEachField(b []byte, fn func(num protowire.Number, typ protowire.Type, v []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		m := protowire.ConsumeFieldValue(num, typ, b)
+		if m < 0 {
+			return protowire.ParseError(m)
+		}
+		if err := fn(num, typ, b[:m]); err != nil {
+			return err
+		}
+		b = b[m:]
+	}
+	return nil
+}
+
+// doubleField returns a double field of a message, or 0 if it is not set
+func doubleField(b []byte, field protowire.Number) (float64, error) {
+	var f float64
+	err := forEachField(b, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		var err error
+		if num == field {
+			f, err = doubleValue(typ, v)
+		}
+		return err
+	})
+	return f, err
+}
+
+func bytesValue(typ protowire.Type, v []byte) ([]byte, error) {
+	if typ != protowire.BytesType {
+		return nil, errors.New("expected a length delimited field")
+	}
+	b, n := protowire.ConsumeBytes(v)
+	if n < 0 {
+		return nil, protowire.ParseError(n)
+	}
+	return b, nil
+}
+
+func stringValue(typ protowire.Type, v []byte) (string, error) {
+	b, err := bytesValue(typ, v)
+	return string(b), err
+}
+
+func varintValue(typ protowire.Type, v []byte) (uint64, error) {
+	if typ != protowire.VarintType {
+		return 0, errors.New("expected a varint field")
+	}
+	u, n := protowire.ConsumeVarint(v)
+	if n < 0 {
+		return 0, protowire.ParseError(n)
+	}
+	return u, nil
+}
+
+func doubleValue(typ protowire.Type, v []byte) (float64, error) {
+	if typ != protowire.Fixed64Type {
+		return 0, errors.New("expected a double field")
+	}
+	u, n := protowire.ConsumeFixed64(v)
+	if n < 0 {
+		return 0, protowire.ParseError(n)
+	}
+	return math.Float64frombits(u), nil
+}
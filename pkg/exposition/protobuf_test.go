@@ -0,0 +1,157 @@
+package exposition
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// message joins the encoded fields of a protobuf message
+func message(fields ...[]byte) []byte {
+	var b []byte
+	for _, f := range fields {
+		b = append(b, f...)
+	}
+	return b
+}
+
+func encodeBytes(num protowire.Number, v []byte) []byte {
+	b := protowire.AppendTag(nil, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func encodeString(num protowire.Number, v string) []byte {
+	return encodeBytes(num, []byte(v))
+}
+
+func encodeVarint(num protowire.Number, v uint64) []byte {
+	b := protowire.AppendTag(nil, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func encodeDouble(num protowire.Number, v float64) []byte {
+	b := protowire.AppendTag(nil, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(v))
+}
+
+func labelPair(name string, value string) []byte {
+	return encodeBytes(metricLabel, message(encodeString(labelName, name), encodeString(labelValue, value)))
+}
+
+// delimited encodes messages with their length, as they are exposed
+func delimited(messages ...[]byte) []byte {
+	var b []byte
+	for _, m := range messages {
+		b = protowire.AppendVarint(b, uint64(len(m)))
+		b = append(b, m...)
+	}
+	return b
+}
+
+func TestParseProtobuf(t *testing.T) {
+	gauge := message(
+		encodeString(familyName, "capacity"),
+		encodeString(familyHelp, "Total storage capacity"),
+		encodeVarint(familyType, 1),
+		encodeBytes(familyMetric, message(labelPair("store", "1"),
+			encodeBytes(metricGauge, encodeDouble(valueValue, 100)))),
+	)
+	histogram := message(
+		encodeString(familyName, "sql_exec_latency"),
+		encodeVarint(familyType, 4),
+		encodeBytes(familyMetric, message(labelPair("node_id", "1"), encodeBytes(metricHistogram, message(
+			encodeVarint(histogramCount, 2),
+			encodeDouble(histogramSum, 1500),
+			encodeBytes(histogramBucket, message(encodeVarint(bucketCount, 1), encodeDouble(bucketUpperBound, 1000))),
+		)))),
+	)
+	summary := message(
+		encodeBytes(familyMetric, message(encodeBytes(metricSummary, message(
+			encodeVarint(summaryCount, 2),
+			encodeDouble(summarySum, 3),
+			encodeBytes(summaryQuantile, message(encodeDouble(quantileQ, 0.5), encodeDouble(quantileValue, 1))),
+		)))),
+		encodeString(familyName, "gc_pause"),
+		encodeVarint(familyType, 2),
+	)
+	counter := message(encodeString(familyName, "requests"), encodeBytes(familyMetric, message(
+		encodeBytes(metricCounter, encodeDouble(valueValue, 10)))))
+
+	families, err := ParseProtobuf(delimited(gauge, histogram, summary, counter))
+	assert.NoError(t, err)
+	assert.Len(t, families, 4)
+
+	assert.Equal(t, Family{Name: "capacity", Help: "Total storage capacity", Type: TypeGauge, Samples: []Sample{
+		{Name: "capacity", Labels: []Label{{"store", "1"}}, Value: 100},
+	}}, families[0])
+
+	node := []Label{{"node_id", "1"}}
+	assert.Equal(t, Family{Name: "sql_exec_latency", Type: TypeHistogram, Samples: []Sample{
+		{Name: "sql_exec_latency_bucket", Labels: []Label{{"node_id", "1"}, {"le", "1000"}}, Value: 1},
+		{Name: "sql_exec_latency_bucket", Labels: []Label{{"node_id", "1"}, {"le", "+Inf"}}, Value: 2},
+		{Name: "sql_exec_latency_sum", Labels: node, Value: 1500},
+		{Name: "sql_exec_latency_count", Labels: node, Value: 2},
+	}}, families[1])
+
+	// The type is known even though the metric comes before it
+	assert.Equal(t, Family{Name: "gc_pause", Type: TypeSummary, Samples: []Sample{
+		{Name: "gc_pause", Labels: []Label{{"quantile", "0.5"}}, Value: 1},
+		{Name: "gc_pause_sum", Labels: []Label{}, Value: 3},
+		{Name: "gc_pause_count", Labels: []Label{}, Value: 2},
+	}}, families[2])
+
+	// Counter is the default type
+	assert.Equal(t, TypeCounter, families[3].Type)
+	assert.Equal(t, 10.0, families[3].Samples[0].Value)
+
+	families, err = ParseProtobuf(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, families)
+}
+
+func TestParseProtobufInvalid(t *testing.T) {
+	valid := message(encodeString(familyName, "capacity"))
+	tests := []struct {
+		name    string
+		data    []byte
+		message int
+	}{
+		{"truncated length", []byte{0x80}, 0},
+		{"truncated message", append(delimited(valid), 10, 1), 1},
+		{"no name", delimited(valid, message(encodeString(familyHelp, "Help"))), 1},
+		{"unknown type", delimited(message(encodeString(familyName, "capacity"), encodeVarint(familyType, 9))), 0},
+		{"wrong wire type", delimited(message(encodeVarint(familyName, 1))), 0},
+		{"invalid metric", delimited(message(encodeString(familyName, "capacity"), encodeBytes(familyMetric,
+			encodeBytes(metricGauge, encodeVarint(valueValue, 1))))), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseProtobuf(tt.data)
+			var parseErr *ParseError
+			if assert.ErrorAs(t, err, &parseErr) {
+				assert.Equal(t, FormatProtobuf, parseErr.Format)
+				assert.Equal(t, tt.message, parseErr.Line)
+			}
+		})
+	}
+}
+
+func FuzzParseProtobuf(f *testing.F) {
+	f.Add(delimited(message(encodeString(familyName, "capacity"), encodeVarint(familyType, 1),
+		encodeBytes(familyMetric, message(labelPair("store", "1"), encodeBytes(metricGauge, encodeDouble(valueValue, 1)))))))
+	f.Add(delimited(message(encodeString(familyName, "latency"), encodeVarint(familyType, 4),
+		encodeBytes(familyMetric, encodeBytes(metricHistogram, encodeBytes(histogramBucket,
+			encodeDouble(bucketUpperBound, 1)))))))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		families, err := ParseProtobuf(data)
+		if err != nil {
+			assert.IsType(t, &ParseError{}, err)
+			return
+		}
+		for _, fam := range families {
+			assert.NotEmpty(t, fam.Name)
+		}
+	})
+}
@@ -0,0 +1,307 @@
+package exposition
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// sampleSuffixes are the suffixes that samples can add to the name of their family
+var sampleSuffixes = []string{"_bucket", "_sum", "_count", "_total", "_created", "_gsum", "_gcount", "_info"}
+
+// ParseText parses the Prometheus text format 0.0.4
+func ParseText(data []byte) ([]Family, error) {
+	return newTextParser(FormatText).parse(data)
+}
+
+// ParseOpenMetrics parses the OpenMetrics 1.0 text format. Parsing stops at the # EOF line.
+func ParseOpenMetrics(data []byte) ([]Family, error) {
+	return newTextParser(FormatOpenMetrics).parse(data)
+}
+
+type textParser struct {
+	format   Format
+	line     int
+	families []*Family
+	byName   map[string]*Family
+	current  *Family // the family of the last metadata line
+}
+
+func newTextParser(format Format) *textParser {
+	return &textParser{format: format, byName: make(map[string]*Family)}
+}
+
+func (p *textParser) parse(data []byte) ([]Family, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		p.line++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			eof, err := p.parseComment(line)
+			if err != nil {
+				return nil, err
+			}
+			if eof {
+				break
+			}
+			continue
+		}
+		if err := p.parseSample(line); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, p.error(err.Error())
+	}
+
+	families := make([]Family, len(p.families))
+	for i, f := range p.families {
+		families[i] = *f
+	}
+	return families, nil
+}
+
+func (p *textParser) error(msg string) error {
+	return &ParseError{Format: p.format, Line: p.line, Msg: msg}
+}
+
+// family returns the family with a name, adding it if it does not exist yet
+func (p *textParser) family(name string) *Family {
+	if f, ok := p.byName[name]; ok {
+		return f
+	}
+	f := &Family{Name: name, Type: TypeUntyped, Samples: make([]Sample, 0)}
+	p.byName[name] = f
+	p.families = append(p.families, f)
+	return f
+}
+
+// parseComment parses HELP, TYPE and UNIT lines and ignores other comments. It returns true for the OpenMetrics
+// # EOF line.
+func (p *textParser) parseComment(line string) (bool, error) {
+	if p.format == FormatOpenMetrics && line == "# EOF" {
+		return true, nil
+	}
+	fields := strings.SplitN(line, " ", 4)
+	if len(fields) < 3 || fields[0] != "#" {
+		return false, nil
+	}
+	keyword, name := fields[1], fields[2]
+	if keyword != "HELP" && keyword != "TYPE" && keyword != "UNIT" {
+		return false, nil
+	}
+	if name == "" {
+		return false, p.error(keyword + " line has no metric name")
+	}
+	rest := ""
+	if len(fields) == 4 {
+		rest = fields[3]
+	}
+
+	switch keyword {
+	case "HELP":
+		f := p.family(name)
+		f.Help = unescape(rest, p.format == FormatOpenMetrics)
+		p.current = f
+	case "TYPE":
+		typ := strings.TrimSpace(rest)
+		switch typ {
+		case TypeCounter, TypeGauge, TypeHistogram, TypeGaugeHistogram, TypeSummary, TypeInfo, TypeStateSet,
+			TypeUntyped:
+		case "unknown":
+			typ = TypeUntyped
+		default:
+			return false, p.error("unknown type '" + typ + "'")
+		}
+		f := p.family(name)
+		f.Type = typ
+		p.current = f
+	case "UNIT":
+		f := p.family(name)
+		f.Unit = strings.TrimSpace(rest)
+		p.current = f
+	}
+	return false, nil
+}
+
+func (p *textParser) parseSample(line string) error {
+	end := strings.IndexAny(line, "{ \t")
+	if end <= 0 {
+		return p.error("sample has no value")
+	}
+	s := Sample{Name: line[:end], Labels: make([]Label, 0)}
+	rest := line[end:]
+
+	if strings.HasPrefix(rest, "{") {
+		labels, n, err := parseLabels(rest, p.format == FormatOpenMetrics)
+		if err != nil {
+			return p.error(err.Error())
+		}
+		s.Labels = labels
+		rest = rest[n:]
+	}
+
+	// OpenMetrics samples can end with an exemplar, which is not kept
+	if p.format == FormatOpenMetrics {
+		if i := strings.Index(rest, " # "); i >= 0 {
+			rest = rest[:i]
+		}
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || len(fields) > 2 {
+		return p.error("sample must have a value and an optional timestamp")
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return p.error("invalid value '" + fields[0] + "'")
+	}
+	s.Value = value
+
+	f := p.familyForSample(s.Name)
+	f.Samples = append(f.Samples, s)
+	return nil
+}
+
+// familyForSample finds the family of a sample, which is the family of the last metadata line if the sample name is
+// the family name with an optional suffix, or else a typed family the sample name has a suffix of. Samples without
+// metadata get an untyped family of their own.
+func (p *textParser) familyForSample(name string) *Family {
+	if p.current != nil && hasFamilyName(name, p.current.Name) {
+		return p.current
+	}
+	if f, ok := p.byName[name]; ok {
+		return f
+	}
+	for _, suffix := range sampleSuffixes {
+		if base, ok := strings.CutSuffix(name, suffix); ok {
+			if f, ok := p.byName[base]; ok && f.Type != TypeUntyped {
+				return f
+			}
+		}
+	}
+	return p.family(name)
+}
+
+func hasFamilyName(sample string, family string) bool {
+	if sample == family {
+		return true
+	}
+	suffix, ok := strings.CutPrefix(sample, family)
+	if !ok {
+		return false
+	}
+	for _, s := range sampleSuffixes {
+		if suffix == s {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLabels parses the labels at the start of s, which starts with '{'. It returns the labels and the length of
+// the label set including the braces.
+func parseLabels(s string, openMetrics bool) ([]Label, int, error) {
+	labels := make([]Label, 0)
+	i := 1
+	for {
+		for i < len(s) && (s[i] == ' ' || s[i] == ',') {
+			i++
+		}
+		if i >= len(s) {
+			return nil, 0, errors.New("unterminated label set")
+		}
+		if s[i] == '}' {
+			return labels, i + 1, nil
+		}
+
+		eq := strings.IndexByte(s[i:], '=')
+		if eq <= 0 {
+			return nil, 0, errors.New("label has no value")
+		}
+		name := strings.TrimSpace(s[i : i+eq])
+		if !validLabelName(name) {
+			return nil, 0, errors.New("invalid label name '" + name + "'")
+		}
+		i += eq + 1
+		if i >= len(s) || s[i] != '"' {
+			return nil, 0, errors.New("label value of '" + name + "' is not quoted")
+		}
+
+		var value strings.Builder
+		i++
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					value.WriteByte('\n')
+				case '\\', '"':
+					value.WriteByte(s[i])
+				default:
+					if openMetrics {
+						return nil, 0, errors.New("invalid escape in label value of '" + name + "'")
+					}
+					value.WriteByte('\\')
+					value.WriteByte(s[i])
+				}
+				continue
+			}
+			value.WriteByte(s[i])
+		}
+		if i >= len(s) {
+			return nil, 0, errors.New("unterminated label value of '" + name + "'")
+		}
+		i++
+		labels = append(labels, Label{Name: name, Value: value.String()})
+	}
+}
+
+func validLabelName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// unescape unescapes help text, where OpenMetrics also escapes double quotes
+func unescape(s string, openMetrics bool) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			switch s[i+1] {
+			case 'n':
+				b.WriteByte('\n')
+				i++
+				continue
+			case '\\':
+				b.WriteByte('\\')
+				i++
+				continue
+			case '"':
+				if openMetrics {
+					b.WriteByte('"')
+					i++
+					continue
+				}
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,160 @@
+package exposition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseText(t *testing.T) {
+	text := `# HELP capacity Total storage capacity
+# TYPE capacity gauge
+capacity{store="1",node_id="1"} 100
+capacity{store="2",node_id="1"} 200 1700000000000
+# TYPE sql_exec_latency histogram
+# HELP sql_exec_latency Latency of SQL statement execution
+sql_exec_latency_bucket{le="1000"} 1
+sql_exec_latency_bucket{le="+Inf"} 2
+sql_exec_latency_sum 1500
+sql_exec_latency_count 2
+# A comment that is not metadata
+# HELP jobs Help with a \\ backslash\nand a new line
+jobs{name="a \"quoted\", value",type="b\nc"} 0
+sys_uptime 10
+sys_uptime_total{} 10
+`
+	families, err := ParseText([]byte(text))
+	assert.NoError(t, err)
+	assert.Len(t, families, 5)
+
+	assert.Equal(t, Family{Name: "capacity", Help: "Total storage capacity", Type: TypeGauge, Samples: []Sample{
+		{Name: "capacity", Labels: []Label{{"store", "1"}, {"node_id", "1"}}, Value: 100},
+		{Name: "capacity", Labels: []Label{{"store", "2"}, {"node_id", "1"}}, Value: 200},
+	}}, families[0])
+
+	assert.Equal(t, "sql_exec_latency", families[1].Name)
+	assert.Equal(t, TypeHistogram, families[1].Type)
+	assert.Equal(t, "Latency of SQL statement execution", families[1].Help)
+	assert.Len(t, families[1].Samples, 4)
+	assert.Equal(t, Label{"le", "+Inf"}, families[1].Samples[1].Labels[0])
+
+	assert.Equal(t, "Help with a \\ backslash\nand a new line", families[2].Help)
+	assert.Equal(t, TypeUntyped, families[2].Type)
+	assert.Equal(t, []Label{{"name", `a "quoted", value`}, {"type", "b\nc"}}, families[2].Samples[0].Labels)
+
+	// Samples without metadata are untyped families of their own
+	assert.Equal(t, "sys_uptime", families[3].Name)
+	assert.Equal(t, TypeUntyped, families[3].Type)
+	assert.Equal(t, "sys_uptime_total", families[4].Name)
+}
+
+func TestParseOpenMetrics(t *testing.T) {
+	text := `# TYPE requests counter
+# UNIT requests requests
+# HELP requests Number of \"requests\"
+requests_total{path="/"} 10 # {trace_id="abc"} 1 1700000000
+requests_created{path="/"} 1700000000
+# TYPE build info
+build_info{version="v23.2.10"} 1
+# TYPE pending unknown
+pending 3
+# EOF
+ignored 1
+`
+	families, err := ParseOpenMetrics([]byte(text))
+	assert.NoError(t, err)
+	assert.Len(t, families, 3)
+
+	assert.Equal(t, Family{Name: "requests", Help: `Number of "requests"`, Type: TypeCounter, Unit: "requests",
+		Samples: []Sample{
+			{Name: "requests_total", Labels: []Label{{"path", "/"}}, Value: 10},
+			{Name: "requests_created", Labels: []Label{{"path", "/"}}, Value: 1700000000},
+		}}, families[0])
+	assert.Equal(t, TypeInfo, families[1].Type)
+	assert.Equal(t, "build_info", families[1].Samples[0].Name)
+	assert.Equal(t, TypeUntyped, families[2].Type)
+}
+
+func TestParseTextInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		format Format
+		line   int
+	}{
+		{"no value", "sys_uptime", FormatText, 1},
+		{"invalid value", "sys_uptime ten", FormatText, 1},
+		{"too many fields", "sys_uptime 1 2 3", FormatText, 1},
+		{"unknown type", "# HELP a A\n# TYPE a meter", FormatText, 2},
+		{"metadata without a name", "# TYPE  gauge", FormatText, 1},
+		{"unterminated label set", `capacity{store="1" 100`, FormatText, 1},
+		{"unterminated label value", `broken{name="unterminated} 0`, FormatText, 1},
+		{"unquoted label value", `capacity{store=1} 100`, FormatText, 1},
+		{"invalid label name", `capacity{1store="1"} 100`, FormatText, 1},
+		{"invalid escape", `capacity{store="\d"} 100`, FormatOpenMetrics, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.text), tt.format)
+			var parseErr *ParseError
+			if assert.ErrorAs(t, err, &parseErr) {
+				assert.Equal(t, tt.format, parseErr.Format)
+				assert.Equal(t, tt.line, parseErr.Line)
+			}
+		})
+	}
+}
+
+func TestParseLabels(t *testing.T) {
+	tests := []struct {
+		labels string
+		want   []Label
+		n      int
+	}{
+		{`{}`, []Label{}, 2},
+		{`{store="1",node_id="1"} 100`, []Label{{"store", "1"}, {"node_id", "1"}}, 23},
+		{`{node_id="1",le="+Inf",}`, []Label{{"node_id", "1"}, {"le", "+Inf"}}, 24},
+		{`{name="a \"quoted\", value",type="b\nc"}`, []Label{{"name", `a "quoted", value`}, {"type", "b\nc"}}, 40},
+		{`{path="C:\dir"}`, []Label{{"path", `C:\dir`}}, 15},
+	}
+	for _, tt := range tests {
+		t.Run(tt.labels, func(t *testing.T) {
+			labels, n, err := parseLabels(tt.labels, false)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, labels)
+			assert.Equal(t, tt.n, n)
+		})
+	}
+}
+
+func FuzzParseText(f *testing.F) {
+	f.Add([]byte("# HELP capacity Total storage capacity\n# TYPE capacity gauge\ncapacity{store=\"1\"} 100\n"))
+	f.Add([]byte("sql_exec_latency_bucket{le=\"+Inf\"} 2\nsql_exec_latency_sum 1500 1700000000000\n"))
+	f.Add([]byte("# HELP jobs a \\\\ b\\n\njobs{name=\"a \\\"b\\\"\"} NaN\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		families, err := ParseText(data)
+		if err != nil {
+			assert.IsType(t, &ParseError{}, err)
+			return
+		}
+		for _, fam := range families {
+			assert.NotEmpty(t, fam.Name)
+			assert.NotEmpty(t, fam.Type)
+		}
+	})
+}
+
+func FuzzParseOpenMetrics(f *testing.F) {
+	f.Add([]byte("# TYPE requests counter\n# UNIT requests requests\nrequests_total 10 # {trace_id=\"abc\"} 1\n# EOF\n"))
+	f.Add([]byte("# TYPE build info\nbuild_info{version=\"v23.2.10\"} 1\n# EOF\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		families, err := ParseOpenMetrics(data)
+		if err != nil {
+			assert.IsType(t, &ParseError{}, err)
+			return
+		}
+		for _, fam := range families {
+			assert.NotEmpty(t, fam.Name)
+		}
+	})
+}
@@ -34,6 +34,11 @@ func NewDbDatasource(url string) (*Db, error) {
 	}, nil
 }
 
+// NewDbFromPool returns a datasource that uses an existing pool, which is owned and closed by the caller
+func NewDbFromPool(pool *pgxpool.Pool) *Db {
+	return &Db{Pool: pool}
+}
+
 func (db *Db) SaveSettingIssue(setting string, issue Issue) error {
 	sql := "UPSERT INTO settings_github_issues (variable, id, number, url, title, created, closed, processed) VALUES ($1, $2, $3, $4, $5, $6, $7, now())"
 	_, err := db.Pool.Exec(context.Background(), sql, setting, issue.ID, issue.Number, issue.Url, issue.Title, issue.CreatedAt, issue.ClosedAt)
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/google/go-github/v65/github"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sirupsen/logrus"
 )
 
@@ -25,6 +26,11 @@ func NewManager(accessToken *string, url string) (*Manager, error) {
 	return &Manager{Provider: provider, Db: db}, err
 }
 
+// NewManagerFromPool returns a manager that uses an existing pool, which is owned and closed by the caller
+func NewManagerFromPool(accessToken *string, pool *pgxpool.Pool) *Manager {
+	return &Manager{Provider: NewProvider(accessToken), Db: NewDbFromPool(pool)}
+}
+
 func (m *Manager) SearchIssuesForSetting(setting string) ([]Issue, error) {
 	return m.Provider.SearchIssues(setting)
 }
@@ -0,0 +1,114 @@
+package host
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Shape is the CPU and memory size of a host, real or simulated
+type Shape struct {
+	Cpu         int
+	MemoryBytes int64
+}
+
+var byteUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30}, {"TiB", 1 << 40},
+	{"KB", 1000}, {"MB", 1000 * 1000}, {"GB", 1000 * 1000 * 1000}, {"TB", 1000 * 1000 * 1000 * 1000},
+	{"B", 1},
+}
+
+// GetShape gets the shape of the current host
+func GetShape() (Shape, error) {
+	memoryBytes, err := GetMemory()
+	if err != nil {
+		return Shape{}, err
+	}
+	return Shape{Cpu: GetCpu(), MemoryBytes: memoryBytes}, nil
+}
+
+// ParseBytes parses a byte size such as '4GiB', '512MB' or '1073741824'
+func ParseBytes(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	multiplier := int64(1)
+	for _, u := range byteUnits {
+		if strings.HasSuffix(strings.ToUpper(s), strings.ToUpper(u.suffix)) {
+			multiplier = u.multiplier
+			s = strings.TrimSpace(s[:len(s)-len(u.suffix)])
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size '%s': %w", s, err)
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("byte size '%s' must be positive", s)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// ParseCpuMatrix parses a comma-separated list of CPU counts, e.g., '2,4,8'
+func ParseCpuMatrix(s string) ([]int, error) {
+	cpus := make([]int, 0)
+	for _, part := range splitMatrix(s) {
+		cpu, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cpu count '%s': %w", part, err)
+		}
+		if cpu <= 0 {
+			return nil, fmt.Errorf("cpu count '%s' must be positive", part)
+		}
+		cpus = append(cpus, cpu)
+	}
+	return cpus, nil
+}
+
+// ParseMemoryMatrix parses a comma-separated list of memory sizes, e.g., '4GiB,8GiB'
+func ParseMemoryMatrix(s string) ([]int64, error) {
+	memories := make([]int64, 0)
+	for _, part := range splitMatrix(s) {
+		m, err := ParseBytes(part)
+		if err != nil {
+			return nil, err
+		}
+		memories = append(memories, m)
+	}
+	return memories, nil
+}
+
+// Matrix returns every combination of CPU count and memory size. An empty list of CPU counts or memory sizes
+// uses the value from the current host.
+func Matrix(cpus []int, memories []int64) ([]Shape, error) {
+	h, err := GetShape()
+	if err != nil {
+		return nil, err
+	}
+	if len(cpus) == 0 {
+		cpus = []int{h.Cpu}
+	}
+	if len(memories) == 0 {
+		memories = []int64{h.MemoryBytes}
+	}
+
+	shapes := make([]Shape, 0, len(cpus)*len(memories))
+	for _, cpu := range cpus {
+		for _, m := range memories {
+			shapes = append(shapes, Shape{Cpu: cpu, MemoryBytes: m})
+		}
+	}
+	return shapes, nil
+}
+
+func splitMatrix(s string) []string {
+	parts := make([]string, 0)
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
@@ -0,0 +1,50 @@
+package host
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseBytes(t *testing.T) {
+	tests := map[string]int64{
+		"1073741824": 1 << 30,
+		"4GiB":       4 << 30,
+		"512 MiB":    512 << 20,
+		"2gb":        2 * 1000 * 1000 * 1000,
+		"1.5GiB":     3 << 29,
+	}
+	for s, expected := range tests {
+		b, err := ParseBytes(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, expected, b, s)
+	}
+
+	_, err := ParseBytes("lots")
+	assert.Error(t, err)
+	_, err = ParseBytes("0GiB")
+	assert.Error(t, err)
+}
+
+func TestMatrix(t *testing.T) {
+	cpus, err := ParseCpuMatrix("2, 4,8")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 4, 8}, cpus)
+
+	memories, err := ParseMemoryMatrix("1GiB,2GiB")
+	assert.NoError(t, err)
+
+	shapes, err := Matrix(cpus, memories)
+	assert.NoError(t, err)
+	assert.Len(t, shapes, 6)
+	assert.Equal(t, Shape{Cpu: 2, MemoryBytes: 1 << 30}, shapes[0])
+	assert.Equal(t, Shape{Cpu: 8, MemoryBytes: 2 << 30}, shapes[5])
+
+	// Host values are used when a dimension is empty
+	shapes, err = Matrix(nil, memories)
+	assert.NoError(t, err)
+	assert.Len(t, shapes, 2)
+	assert.Equal(t, GetCpu(), shapes[0].Cpu)
+
+	_, err = ParseCpuMatrix("2,zero")
+	assert.Error(t, err)
+}
@@ -0,0 +1,74 @@
+package keywords
+
+import "sort"
+
+// ChangedKeyword is a keyword whose category changed between releases. The before category is empty for keywords
+// that are new in the second release.
+type ChangedKeyword struct {
+	Word           string   `json:"word"`
+	BeforeCategory Category `json:"before_category"`
+	AfterCategory  Category `json:"after_category"`
+	Description    string   `json:"description"`
+}
+
+// ComparedReleaseKeywords is the difference between the keywords of two releases. NewlyReserved lists the keywords
+// that are reserved (R or T) in the second release but were not reserved, or not keywords, in the first, since these
+// break unquoted table and column names.
+type ComparedReleaseKeywords struct {
+	NewlyReserved []ChangedKeyword `json:"newly_reserved"`
+	Added         []Keyword        `json:"added"`
+	Removed       []Keyword        `json:"removed"`
+	Changed       []ChangedKeyword `json:"changed"`
+}
+
+// CompareReleaseKeywords compares the keywords of two releases
+func CompareReleaseKeywords(k1 []Keyword, k2 []Keyword) ComparedReleaseKeywords {
+	k1indexed := make(map[string]Keyword)
+	for _, k := range k1 {
+		k1indexed[k.Word] = k
+	}
+	k2indexed := make(map[string]Keyword)
+	for _, k := range k2 {
+		k2indexed[k.Word] = k
+	}
+
+	c := ComparedReleaseKeywords{
+		NewlyReserved: make([]ChangedKeyword, 0),
+		Added:         make([]Keyword, 0),
+		Removed:       make([]Keyword, 0),
+		Changed:       make([]ChangedKeyword, 0),
+	}
+
+	for _, before := range k1 {
+		after, ok := k2indexed[before.Word]
+		if !ok { // exists in k1 but not k2
+			c.Removed = append(c.Removed, before)
+			continue
+		}
+		if before.Category == after.Category {
+			continue
+		}
+		changed := ChangedKeyword{Word: after.Word, BeforeCategory: before.Category, AfterCategory: after.Category,
+			Description: after.Description}
+		c.Changed = append(c.Changed, changed)
+		if after.IsReserved() && !before.IsReserved() {
+			c.NewlyReserved = append(c.NewlyReserved, changed)
+		}
+	}
+
+	for _, after := range k2 {
+		if _, ok := k1indexed[after.Word]; ok {
+			continue
+		}
+		c.Added = append(c.Added, after)
+		if after.IsReserved() {
+			c.NewlyReserved = append(c.NewlyReserved, ChangedKeyword{Word: after.Word,
+				AfterCategory: after.Category, Description: after.Description})
+		}
+	}
+
+	sort.Slice(c.NewlyReserved, func(i, j int) bool {
+		return c.NewlyReserved[i].Word < c.NewlyReserved[j].Word
+	})
+	return c
+}
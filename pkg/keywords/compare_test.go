@@ -0,0 +1,67 @@
+package keywords
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareReleaseKeywords(t *testing.T) {
+	before := []Keyword{
+		{Word: "analyse", Category: Reserved, Description: "reserved"},
+		{Word: "by", Category: Unreserved, Description: "unreserved"},
+		{Word: "collation", Category: TypeFuncName, Description: "reserved (can be function or type name)"},
+		{Word: "family", Category: Unreserved, Description: "unreserved"},
+		{Word: "index", Category: Unreserved, Description: "unreserved"},
+		{Word: "nothing", Category: Unreserved, Description: "unreserved"},
+		{Word: "overlaps", Category: ColName, Description: "unreserved (cannot be function or type name)"},
+		{Word: "similar", Category: Unreserved, Description: "unreserved"},
+	}
+	after := []Keyword{
+		{Word: "analyse", Category: Reserved, Description: "reserved"},
+		{Word: "by", Category: Unreserved, Description: "unreserved"},
+		{Word: "collation", Category: Reserved, Description: "reserved"},
+		{Word: "family", Category: ColName, Description: "unreserved (cannot be function or type name)"},
+		{Word: "index", Category: Reserved, Description: "reserved"},
+		{Word: "overlaps", Category: TypeFuncName, Description: "reserved (can be function or type name)"},
+		{Word: "similar", Category: TypeFuncName, Description: "reserved (can be function or type name)"},
+		{Word: "call", Category: Reserved, Description: "reserved"},
+		{Word: "concurrently", Category: TypeFuncName, Description: "reserved (can be function or type name)"},
+		{Word: "routines", Category: Unreserved, Description: "unreserved"},
+	}
+	c := CompareReleaseKeywords(before, after)
+
+	// collation was already reserved for table and column names, so moving from T to R is only a change
+	assert.Equal(t, []ChangedKeyword{
+		{Word: "call", AfterCategory: Reserved, Description: "reserved"},
+		{Word: "concurrently", AfterCategory: TypeFuncName, Description: "reserved (can be function or type name)"},
+		{Word: "index", BeforeCategory: Unreserved, AfterCategory: Reserved, Description: "reserved"},
+		{Word: "overlaps", BeforeCategory: ColName, AfterCategory: TypeFuncName,
+			Description: "reserved (can be function or type name)"},
+		{Word: "similar", BeforeCategory: Unreserved, AfterCategory: TypeFuncName,
+			Description: "reserved (can be function or type name)"},
+	}, c.NewlyReserved)
+
+	assert.Equal(t, []Keyword{
+		{Word: "call", Category: Reserved, Description: "reserved"},
+		{Word: "concurrently", Category: TypeFuncName, Description: "reserved (can be function or type name)"},
+		{Word: "routines", Category: Unreserved, Description: "unreserved"},
+	}, c.Added)
+	assert.Equal(t, []Keyword{{Word: "nothing", Category: Unreserved, Description: "unreserved"}}, c.Removed)
+	assert.Equal(t, []string{"collation", "family", "index", "overlaps", "similar"}, words(c.Changed))
+}
+
+func TestKeyword_IsReserved(t *testing.T) {
+	assert.True(t, Keyword{Category: Reserved}.IsReserved())
+	assert.True(t, Keyword{Category: TypeFuncName}.IsReserved())
+	assert.False(t, Keyword{Category: ColName}.IsReserved())
+	assert.False(t, Keyword{Category: Unreserved}.IsReserved())
+}
+
+func words(ks []ChangedKeyword) []string {
+	w := make([]string, len(ks))
+	for i, k := range ks {
+		w[i] = k.Word
+	}
+	return w
+}
@@ -0,0 +1,75 @@
+package keywords
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jonstjohn/crdb-settings/pkg/dbpgx"
+)
+
+type Db struct {
+	Url  string
+	Pool *pgxpool.Pool
+}
+
+const UpsertRawSql = `
+UPSERT INTO keywords_raw (release_name, word, category, description, updated)
+VALUES ($1, $2, $3, $4, now())
+`
+
+const SelectRawForReleaseSql = `
+SELECT word, category, description
+FROM keywords_raw
+WHERE release_name = $1
+ORDER BY word ASC
+`
+
+func NewDbDatasource(url string) (*Db, error) {
+	pool, err := dbpgx.NewPoolFromUrl(url)
+	if err != nil {
+		return nil, err
+	}
+	return &Db{
+		Url:  url,
+		Pool: pool,
+	}, nil
+}
+
+// NewDbFromPool returns a datasource that uses an existing pool, which is owned and closed by the caller
+func NewDbFromPool(pool *pgxpool.Pool) *Db {
+	return &Db{Pool: pool}
+}
+
+// SaveRawTx saves the keywords of a release in an existing transaction, so that they can be saved with other
+// artifacts
+func SaveRawTx(tx pgx.Tx, releaseName string, keywords []Keyword) error {
+	for _, k := range keywords {
+		_, err := tx.Exec(context.Background(), UpsertRawSql, releaseName, k.Word, string(k.Category), k.Description)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SelectRaw gets the keywords captured for a release, ordered by word
+func (db *Db) SelectRaw(releaseName string) ([]Keyword, error) {
+	rows, err := db.Pool.Query(context.Background(), SelectRawForReleaseSql, releaseName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keywords := make([]Keyword, 0)
+	for rows.Next() {
+		var k Keyword
+		var category string
+		if err := rows.Scan(&k.Word, &category, &k.Description); err != nil {
+			return nil, err
+		}
+		k.Category = Category(category)
+		keywords = append(keywords, k)
+	}
+	return keywords, rows.Err()
+}
@@ -0,0 +1,53 @@
+package keywords
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Category is the keyword category code reported by pg_get_keywords()
+type Category string
+
+const (
+	Unreserved   Category = "U"
+	ColName      Category = "C" // unreserved, but cannot be a function or type name
+	TypeFuncName Category = "T" // reserved, but can be a function or type name
+	Reserved     Category = "R"
+)
+
+// Keyword is a SQL keyword of a release
+type Keyword struct {
+	Word        string   `json:"word"`
+	Category    Category `json:"category"`
+	Description string   `json:"description"`
+}
+
+// IsReserved is true for keywords that cannot be used as table or column names without quoting, which are the
+// reserved keywords and the type_func_name keywords
+func (k Keyword) IsReserved() bool {
+	return k.Category == Reserved || k.Category == TypeFuncName
+}
+
+const KeywordsSql = `SELECT word, catcode, catdesc FROM pg_get_keywords() ORDER BY word`
+
+// GetLocalKeywords gets the keywords of a cluster, sorted by word
+func GetLocalKeywords(pool *pgxpool.Pool) ([]Keyword, error) {
+	rows, err := pool.Query(context.Background(), KeywordsSql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keywords := make([]Keyword, 0)
+	for rows.Next() {
+		var k Keyword
+		var category string
+		if err := rows.Scan(&k.Word, &category, &k.Description); err != nil {
+			return nil, err
+		}
+		k.Category = Category(category)
+		keywords = append(keywords, k)
+	}
+	return keywords, rows.Err()
+}
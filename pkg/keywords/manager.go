@@ -0,0 +1,47 @@
+package keywords
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jonstjohn/crdb-settings/pkg/releases"
+)
+
+type Manager struct {
+	Db *Db
+}
+
+func NewManager(url string) (*Manager, error) {
+	db, err := NewDbDatasource(url)
+	if err != nil {
+		return nil, err
+	}
+	return &Manager{Db: db}, nil
+}
+
+// NewManagerFromPool returns a manager that uses an existing pool, which is owned and closed by the caller
+func NewManagerFromPool(pool *pgxpool.Pool) *Manager {
+	return &Manager{Db: NewDbFromPool(pool)}
+}
+
+// GetKeywordsForRelease gets the keywords captured for a release, returning a releases.UnknownReleaseError if the
+// release does not exist
+func (m *Manager) GetKeywordsForRelease(releaseName string) ([]Keyword, error) {
+	rm := releases.NewReleasesManagerFromPool(m.Db.Pool)
+	if _, err := rm.GetRelease(releaseName); err != nil {
+		return nil, err
+	}
+	return m.Db.SelectRaw(releaseName)
+}
+
+func (m *Manager) CompareKeywordsForReleases(r1 string, r2 string) (ComparedReleaseKeywords, error) {
+	k1, err := m.GetKeywordsForRelease(r1)
+	if err != nil {
+		return ComparedReleaseKeywords{}, err
+	}
+
+	k2, err := m.GetKeywordsForRelease(r2)
+	if err != nil {
+		return ComparedReleaseKeywords{}, err
+	}
+
+	return CompareReleaseKeywords(k1, k2), nil
+}
@@ -1,25 +1,66 @@
 package metrics
 
+import (
+	"slices"
+
+	"github.com/jonstjohn/crdb-settings/pkg/textsim"
+)
+
+// Fields of a metric that are compared between releases
+const (
+	FieldHelp    = "help"
+	FieldType    = "type"
+	FieldLabels  = "labels"
+	FieldBuckets = "buckets"
+	FieldSeries  = "series"
+)
+
 type ReleaseMetric struct {
-	Release string `json:"release"`
-	Metric  string `json:"metric"`
-	Help    string `json:"help"`
-	Type    Type   `json:"type"`
+	Release string   `json:"release"`
+	Metric  string   `json:"metric"`
+	Help    string   `json:"help"`
+	Type    Type     `json:"type"`
+	Labels  []string `json:"labels"`
+	Buckets []string `json:"buckets"`
+	Series  int      `json:"series"`
 }
 
 type ChangedMetrics []ChangedMetric
 
+// ChangedMetric is a metric that exists in both releases with a different help text, type, set of labels, bucket
+// layout or number of series. Fields lists what changed, and the added and removed labels are only set for label
+// changes.
 type ChangedMetric struct {
-	Before ReleaseMetric `json:"before"`
-	After  ReleaseMetric `json:"after"`
+	Before        ReleaseMetric `json:"before"`
+	After         ReleaseMetric `json:"after"`
+	Fields        []string      `json:"fields"`
+	AddedLabels   []string      `json:"added_labels"`
+	RemovedLabels []string      `json:"removed_labels"`
+}
+
+type RenamedMetrics []RenamedMetric
+
+// RenamedMetric is a removed metric and an added metric of the same type with similar help text
+type RenamedMetric struct {
+	Before     ReleaseMetric `json:"before"`
+	After      ReleaseMetric `json:"after"`
+	Similarity float64       `json:"similarity"`
 }
 
 type ComparedReleaseMetrics struct {
 	Added   Metrics        `json:"added"`
 	Removed Metrics        `json:"removed"`
 	Changed ChangedMetrics `json:"changed"`
+	Renamed RenamedMetrics `json:"renamed"`
+}
+
+func newReleaseMetric(release string, m Metric) ReleaseMetric {
+	return ReleaseMetric{Release: release, Metric: m.Name, Help: m.Help, Type: m.Type, Labels: m.Labels,
+		Buckets: m.Buckets, Series: m.Series}
 }
 
+// CompareReleaseMetrics compares the metrics of two releases. Labels, buckets and series are only compared when they
+// were captured for both releases.
 func CompareReleaseMetrics(r1 string, r1metrics Metrics, r2 string, r2metrics Metrics) ComparedReleaseMetrics {
 
 	rs1indexed := make(map[string]Metric)
@@ -33,32 +74,44 @@ func CompareReleaseMetrics(r1 string, r1metrics Metrics, r2 string, r2metrics Me
 
 	added := Metrics{}
 	removed := Metrics{}
-	var changed ChangedMetrics
+	changed := ChangedMetrics{}
 
 	for _, r1m := range r1metrics {
-		if _, ok := rs2indexed[r1m.Name]; !ok { // exists in r1 but not r2
+		r2m, ok := rs2indexed[r1m.Name]
+		if !ok { // exists in r1 but not r2
 			removed = append(removed, r1m)
 			continue
 		}
 
-		if r2m, ok := rs2indexed[r1m.Name]; !ok {
-			if r1m.Name != r2m.Name || r1m.Help != r2m.Help {
-				changed = append(changed, ChangedMetric{
-					Before: ReleaseMetric{
-						Release: r1,
-						Metric:  r1m.Name,
-						Help:    r1m.Help,
-						Type:    r1m.Type,
-					},
-					After: ReleaseMetric{
-						Release: r2,
-						Metric:  r2m.Name,
-						Help:    r2m.Help,
-						Type:    r2m.Type,
-					},
-				})
+		c := ChangedMetric{
+			Before:        newReleaseMetric(r1, r1m),
+			After:         newReleaseMetric(r2, r2m),
+			Fields:        make([]string, 0),
+			AddedLabels:   make([]string, 0),
+			RemovedLabels: make([]string, 0),
+		}
+		if textsim.Clean(r1m.Help) != textsim.Clean(r2m.Help) {
+			c.Fields = append(c.Fields, FieldHelp)
+		}
+		if r1m.Type != r2m.Type {
+			c.Fields = append(c.Fields, FieldType)
+		}
+		if r1m.Labels != nil && r2m.Labels != nil {
+			c.AddedLabels = labelsNotIn(r2m.Labels, r1m.Labels)
+			c.RemovedLabels = labelsNotIn(r1m.Labels, r2m.Labels)
+			if len(c.AddedLabels) > 0 || len(c.RemovedLabels) > 0 {
+				c.Fields = append(c.Fields, FieldLabels)
 			}
 		}
+		if r1m.Buckets != nil && r2m.Buckets != nil && !slices.Equal(r1m.Buckets, r2m.Buckets) {
+			c.Fields = append(c.Fields, FieldBuckets)
+		}
+		if r1m.Series > 0 && r2m.Series > 0 && r1m.Series != r2m.Series {
+			c.Fields = append(c.Fields, FieldSeries)
+		}
+		if len(c.Fields) > 0 {
+			changed = append(changed, c)
+		}
 	}
 
 	for _, r2m := range r2metrics {
@@ -67,9 +120,61 @@ func CompareReleaseMetrics(r1 string, r1metrics Metrics, r2 string, r2metrics Me
 		}
 	}
 
+	renamed, removed, added := detectRenames(r1, removed, r2, added)
+
 	return ComparedReleaseMetrics{
 		Removed: removed,
 		Added:   added,
 		Changed: changed,
+		Renamed: renamed,
+	}
+}
+
+// detectRenames pairs each removed metric with the added metric of the same type that has the most similar help
+// text. The metrics that were not paired are returned as still removed and added.
+func detectRenames(r1 string, removed Metrics, r2 string, added Metrics) (RenamedMetrics, Metrics, Metrics) {
+	renamed := RenamedMetrics{}
+	paired := make(map[int]bool)
+	stillRemoved := Metrics{}
+
+	for _, r := range removed {
+		best, bestSimilarity := -1, textsim.RenameThreshold
+		for i, a := range added {
+			if paired[i] || a.Type != r.Type {
+				continue
+			}
+			if s := textsim.Jaccard(r.Help, a.Help); s >= bestSimilarity {
+				best, bestSimilarity = i, s
+			}
+		}
+		if best < 0 {
+			stillRemoved = append(stillRemoved, r)
+			continue
+		}
+		paired[best] = true
+		renamed = append(renamed, RenamedMetric{
+			Before:     newReleaseMetric(r1, r),
+			After:      newReleaseMetric(r2, added[best]),
+			Similarity: bestSimilarity,
+		})
+	}
+
+	stillAdded := Metrics{}
+	for i, a := range added {
+		if !paired[i] {
+			stillAdded = append(stillAdded, a)
+		}
+	}
+	return renamed, stillRemoved, stillAdded
+}
+
+// labelsNotIn returns the labels of l1 that are not in l2
+func labelsNotIn(l1 []string, l2 []string) []string {
+	diff := make([]string, 0)
+	for _, l := range l1 {
+		if !slices.Contains(l2, l) {
+			diff = append(diff, l)
+		}
 	}
+	return diff
 }
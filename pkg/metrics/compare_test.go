@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func metricsFromFile(t *testing.T, name string) Metrics {
+	b, err := os.ReadFile(name)
+	assert.NoError(t, err)
+	metrics, err := FromText(string(b))
+	assert.NoError(t, err)
+	return metrics
+}
+
+func TestCompareReleaseMetrics(t *testing.T) {
+	before := metricsFromFile(t, "testdata/compare_before.txt")
+	after := metricsFromFile(t, "testdata/compare_after.txt")
+	c := CompareReleaseMetrics("v23.1.0", before, "v23.2.0", after)
+
+	tests := []struct {
+		name          string
+		metric        string
+		fields        []string
+		addedLabels   []string
+		removedLabels []string
+	}{
+		{name: "help", metric: "sql_query_count", fields: []string{FieldHelp},
+			addedLabels: []string{}, removedLabels: []string{}},
+		{name: "type", metric: "txn_durations", fields: []string{FieldType, FieldLabels},
+			addedLabels: []string{"le"}, removedLabels: []string{}},
+		{name: "added label", metric: "capacity", fields: []string{FieldLabels},
+			addedLabels: []string{"tenant_id"}, removedLabels: []string{}},
+		{name: "removed label", metric: "changefeed_emitted_messages", fields: []string{FieldLabels},
+			addedLabels: []string{}, removedLabels: []string{"scope"}},
+		{name: "buckets", metric: "sql_exec_latency", fields: []string{FieldBuckets},
+			addedLabels: []string{}, removedLabels: []string{}},
+		{name: "series", metric: "replicas", fields: []string{FieldSeries},
+			addedLabels: []string{}, removedLabels: []string{}},
+	}
+	assert.Len(t, c.Changed, len(tests))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var changed *ChangedMetric
+			for i := range c.Changed {
+				if c.Changed[i].Before.Metric == tt.metric {
+					changed = &c.Changed[i]
+				}
+			}
+			if !assert.NotNil(t, changed) {
+				return
+			}
+			assert.Equal(t, tt.fields, changed.Fields)
+			assert.Equal(t, tt.addedLabels, changed.AddedLabels)
+			assert.Equal(t, tt.removedLabels, changed.RemovedLabels)
+			assert.Equal(t, "v23.1.0", changed.Before.Release)
+			assert.Equal(t, "v23.2.0", changed.After.Release)
+		})
+	}
+
+	// The trailing period on the help text is not a change
+	for _, ch := range c.Changed {
+		assert.NotEqual(t, "sql_conns", ch.Before.Metric)
+	}
+
+	// Similar help of the same type is a rename, but not when the type changed
+	assert.Len(t, c.Renamed, 1)
+	assert.Equal(t, "admission_granter_used_slots_kv", c.Renamed[0].Before.Metric)
+	assert.Equal(t, "admission_granter_used_slots_kv_work", c.Renamed[0].After.Metric)
+	assert.Equal(t, 1.0, c.Renamed[0].Similarity)
+
+	assert.Equal(t, []string{"distsender_rpc_sent_local"}, names(c.Removed))
+	assert.Equal(t, []string{"distsender_rpc_sent_local_replica", "kv_rangefeed_catchup_scan_nanos"}, names(c.Added))
+}
+
+func TestCompareReleaseMetricsUnknownLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		before  []string
+		after   []string
+		changed bool
+	}{
+		{"both unknown", nil, nil, false},
+		{"before unknown", nil, []string{"node_id"}, false},
+		{"after unknown", []string{"node_id"}, nil, false},
+		{"same", []string{"node_id"}, []string{"node_id"}, false},
+		{"different", []string{"node_id"}, []string{"node_id", "store"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := CompareReleaseMetrics(
+				"v23.1.0", Metrics{{Name: "capacity", Type: Gauge, Labels: tt.before}},
+				"v23.2.0", Metrics{{Name: "capacity", Type: Gauge, Labels: tt.after}})
+			assert.Equal(t, tt.changed, len(c.Changed) == 1)
+		})
+	}
+}
+
+func TestCompareReleaseMetricsUnknownBucketsAndSeries(t *testing.T) {
+	tests := []struct {
+		name    string
+		before  Metric
+		after   Metric
+		changed bool
+	}{
+		{"buckets unknown", Metric{Buckets: nil}, Metric{Buckets: []string{"1", "+Inf"}}, false},
+		{"same buckets", Metric{Buckets: []string{"1", "+Inf"}}, Metric{Buckets: []string{"1", "+Inf"}}, false},
+		{"series unknown", Metric{Series: 0}, Metric{Series: 2}, false},
+		{"same series", Metric{Series: 2}, Metric{Series: 2}, false},
+		{"different series", Metric{Series: 1}, Metric{Series: 2}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.before.Name, tt.after.Name = "sql_exec_latency", "sql_exec_latency"
+			c := CompareReleaseMetrics("v23.1.0", Metrics{tt.before}, "v23.2.0", Metrics{tt.after})
+			assert.Equal(t, tt.changed, len(c.Changed) == 1)
+		})
+	}
+}
+
+func names(ms Metrics) []string {
+	n := make([]string, len(ms))
+	for i, m := range ms {
+		n[i] = m.Name
+	}
+	return n
+}
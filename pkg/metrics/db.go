@@ -2,8 +2,11 @@ package metrics
 
 import (
 	"context"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jonstjohn/crdb-settings/pkg/dbpgx"
+	"github.com/jonstjohn/crdb-settings/pkg/releases"
+	"slices"
 	"time"
 )
 
@@ -14,60 +17,103 @@ type Db struct {
 
 type RawRow struct {
 	ReleaseName string
+	Nodes       int
 	Metric      string
 	Help        string
 	Type        string
+	Labels      []string
+	Buckets     []string
+	Series      *int
 	Updated     time.Time
 }
 
-const CreateDatabase = `
-CREATE DATABASE IF NOT EXISTS blatta
-`
+// toMetric converts a row to a metric, where a null series count is 0 for metrics captured before it was recorded
+func (r RawRow) toMetric() Metric {
+	m := Metric{Name: r.Metric, Help: r.Help, Type: Type(r.Type), Labels: r.Labels, Buckets: r.Buckets}
+	if r.Series != nil {
+		m.Series = *r.Series
+	}
+	return m
+}
 
-const CreateRawTable = `
-CREATE TABLE IF NOT EXISTS blatta.metrics_raw (
-    release_name string,
-	metric STRING NOT NULL,
-	type STRING NOT NULL,
-	help STRING NOT NULL,
-	updated TIMESTAMP NOT NULL DEFAULT now(),
-	PRIMARY KEY (release_name, metric),
-	INDEX (metric, release_name)
-)
-`
+// metricsFromRows converts the rows of a release to metrics, merging the rows of a metric captured with different
+// numbers of nodes, so that metrics only reported by a multi-node cluster are added to those of a single node
+func metricsFromRows(rows []RawRow) Metrics {
+	byNodes := make(map[int]Metrics)
+	nodes := make([]int, 0)
+	for _, row := range rows {
+		if _, ok := byNodes[row.Nodes]; !ok {
+			nodes = append(nodes, row.Nodes)
+		}
+		byNodes[row.Nodes] = append(byNodes[row.Nodes], row.toMetric())
+	}
+	slices.Sort(nodes)
+
+	nodeMetrics := make([]Metrics, len(nodes))
+	for i, n := range nodes {
+		nodeMetrics[i] = byNodes[n]
+	}
+	return MergeMetrics(nodeMetrics...)
+}
+
+// releaseMetricsFromRows converts the rows of a metric to one release metric per release, merging the rows of each
+// number of nodes like metricsFromRows. Releases that are not captured are skipped.
+func releaseMetricsFromRows(rows []RawRow, captured releases.Releases) []ReleaseMetric {
+	byRelease := make(map[string][]RawRow)
+	names := make([]string, 0)
+	for _, row := range rows {
+		if _, ok := byRelease[row.ReleaseName]; !ok {
+			names = append(names, row.ReleaseName)
+		}
+		byRelease[row.ReleaseName] = append(byRelease[row.ReleaseName], row)
+	}
+
+	rms := make([]ReleaseMetric, 0, len(names))
+	for _, r := range names {
+		if captured.GetReleaseForName(r) != nil {
+			rms = append(rms, newReleaseMetric(r, metricsFromRows(byRelease[r])[0]))
+		}
+	}
+	return rms
+}
 
 type SaveRunsRow struct {
 	ReleaseName string
+	Nodes       int
 	Updated     time.Time
 }
 
-const CreateSaveRunsTable = `
-CREATE TABLE IF NOT EXISTS blatta.metrics_save_runs (
-    release_name string,
-	updated TIMESTAMP NOT NULL DEFAULT now(),
-	PRIMARY KEY (release_name)
-)
-`
-
 const UpsertRaw = `
-UPSERT INTO blatta.metrics_raw (release_name, metric, type, help) VALUES ($1, $2, $3, $4)
+UPSERT INTO blatta.metrics_raw (release_name, nodes, metric, type, help, labels, buckets, series)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 `
 
 const UpsertSaveRun = `
-UPSERT INTO blatta.metrics_save_runs (release_name) VALUES ($1)
+UPSERT INTO blatta.metrics_save_runs (release_name, nodes, updated) VALUES ($1, $2, now())
 `
 
 const SelectMetricsForReleaseSql = `
-SELECT release_name, metric, type, help, updated
+SELECT release_name, nodes, metric, type, help, labels, buckets, series, updated
 FROM blatta.metrics_raw 
 WHERE release_name = $1
-ORDER BY metric ASC
+ORDER BY metric ASC, nodes ASC
+`
+
+const SelectRawForMetricSql = `
+SELECT release_name, nodes, metric, type, help, labels, buckets, series, updated
+FROM blatta.metrics_raw
+WHERE metric = $1
+ORDER BY release_name ASC, nodes ASC
+`
+
+const SelectCapturedReleaseNamesSql = `
+SELECT DISTINCT release_name FROM blatta.metrics_raw
 `
 
 const SelectSaveRunsForReleaseSql = `
-SELECT release_name, updated
+SELECT release_name, nodes, updated
 FROM blatta.metrics_save_runs
-WHERE release_name = $1
+WHERE release_name = $1 AND nodes = $2
 `
 
 func NewDbDatasource(url string) (*Db, error) {
@@ -81,76 +127,109 @@ func NewDbDatasource(url string) (*Db, error) {
 	}, nil
 }
 
-func (db *Db) Initialize() error {
-	err := db.createDatabaseIfNotExists()
-	if err != nil {
-		return err
-	}
-	err = db.createRawTableIfNotExists()
-	if err != nil {
-		return err
-	}
-	return db.createSaveRunsTableIfNotExists()
+// NewDbFromPool returns a datasource that uses an existing pool, which is owned and closed by the caller
+func NewDbFromPool(pool *pgxpool.Pool) *Db {
+	return &Db{Pool: pool}
 }
 
-func (db *Db) createDatabaseIfNotExists() error {
-	_, err := db.Pool.Exec(context.Background(), CreateDatabase)
+func (db *Db) UpsertRaw(releaseName string, nodes int, metric Metric) error {
+	_, err := db.Pool.Exec(context.Background(), UpsertRaw,
+		releaseName, nodes, metric.Name, metric.Type, metric.Help, metric.Labels, metric.Buckets,
+		metric.Series,
+	)
 	return err
 }
 
-func (db *Db) createRawTableIfNotExists() error {
-	_, err := db.Pool.Exec(context.Background(), CreateRawTable)
-	return err
+// UpsertRawTx saves the metrics of a release captured with a number of nodes in an existing transaction, so that
+// they can be saved with other artifacts
+func UpsertRawTx(tx pgx.Tx, releaseName string, nodes int, metrics Metrics) error {
+	for _, metric := range metrics {
+		_, err := tx.Exec(context.Background(), UpsertRaw,
+			releaseName, nodes, metric.Name, metric.Type, metric.Help, metric.Labels, metric.Buckets,
+			metric.Series,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (db *Db) createSaveRunsTableIfNotExists() error {
-	_, err := db.Pool.Exec(context.Background(), CreateSaveRunsTable)
+func (db *Db) UpsertSaveRun(releaseName string, nodes int) error {
+	_, err := db.Pool.Exec(context.Background(), UpsertSaveRun, releaseName, nodes)
 	return err
 }
 
-func (db *Db) UpsertRaw(releaseName string, metric Metric) error {
-	_, err := db.Pool.Exec(context.Background(), UpsertRaw,
-		releaseName, metric.Name, metric.Type, metric.Help,
-	)
+// UpsertSaveRunTx records a save run in an existing transaction, so that it is saved with the metrics of the run
+func UpsertSaveRunTx(tx pgx.Tx, releaseName string, nodes int) error {
+	_, err := tx.Exec(context.Background(), UpsertSaveRun, releaseName, nodes)
 	return err
 }
 
-func (db *Db) UpsertSaveRun(releaseName string) error {
-	_, err := db.Pool.Exec(context.Background(), UpsertSaveRun, releaseName)
-	return err
+func (db *Db) SelectRaw(releaseName string) ([]RawRow, error) {
+	return db.selectRawRows(SelectMetricsForReleaseSql, releaseName)
 }
 
-func (db *Db) SelectRaw(releaseName string) ([]RawRow, error) {
-	rows, err := db.Pool.Query(context.Background(), SelectMetricsForReleaseSql, releaseName)
+// SelectRawForMetric gets the rows of a metric for every release it was captured for
+func (db *Db) SelectRawForMetric(metric string) ([]RawRow, error) {
+	return db.selectRawRows(SelectRawForMetricSql, metric)
+}
+
+func (db *Db) selectRawRows(sql string, args ...any) ([]RawRow, error) {
+	rows, err := db.Pool.Query(context.Background(), sql, args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	rs := make([]RawRow, 0)
 
 	for rows.Next() {
 
 		var releaseName string
+		var nodes int
 		var metric string
 		var typ string
 		var help string
+		var labels []string
+		var buckets []string
+		var series *int
 		var updated time.Time
-		err := rows.Scan(&releaseName, &metric, &typ, &help, &updated)
+		err := rows.Scan(&releaseName, &nodes, &metric, &typ, &help, &labels, &buckets, &series, &updated)
 		if err != nil {
 			return nil, err
 		}
 		rs = append(rs, RawRow{
-			ReleaseName: releaseName, Metric: metric,
-			Type: typ, Help: help, Updated: updated,
+			ReleaseName: releaseName, Nodes: nodes, Metric: metric,
+			Type: typ, Help: help, Labels: labels, Buckets: buckets, Series: series, Updated: updated,
 		})
 	}
 
-	return rs, nil
+	return rs, rows.Err()
+}
+
+// GetCapturedReleaseNames gets the names of all releases that have had metrics captured
+func (db *Db) GetCapturedReleaseNames() ([]string, error) {
+	rows, err := db.Pool.Query(context.Background(), SelectCapturedReleaseNamesSql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
 }
 
-func (db *Db) SelectSaveRuns(releaseName string) ([]SaveRunsRow, error) {
+func (db *Db) SelectSaveRuns(releaseName string, nodes int) ([]SaveRunsRow, error) {
 
-	rows, err := db.Pool.Query(context.Background(), SelectSaveRunsForReleaseSql, releaseName)
+	rows, err := db.Pool.Query(context.Background(), SelectSaveRunsForReleaseSql, releaseName, nodes)
 	if err != nil {
 		return nil, err
 	}
@@ -160,13 +239,14 @@ func (db *Db) SelectSaveRuns(releaseName string) ([]SaveRunsRow, error) {
 	for rows.Next() {
 
 		var releaseName string
+		var nodes int
 		var updated time.Time
-		err := rows.Scan(&releaseName, &updated)
+		err := rows.Scan(&releaseName, &nodes, &updated)
 		if err != nil {
 			return nil, err
 		}
 		rs = append(rs, SaveRunsRow{
-			ReleaseName: releaseName, Updated: updated,
+			ReleaseName: releaseName, Nodes: nodes, Updated: updated,
 		})
 	}
 
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"fmt"
+	"github.com/jonstjohn/crdb-settings/pkg/releases"
+)
+
+// MetricDetail is a metric with the help and type of the most recent release it appears in, and every release it
+// appears in ordered by version
+type MetricDetail struct {
+	Name         string   `json:"name"`
+	Help         string   `json:"help"`
+	Type         Type     `json:"type"`
+	ReleaseNames []string `json:"releases"`
+}
+
+// GenerateMetricDetail builds the detail of a metric from the metric for each release it appears in
+func GenerateMetricDetail(name string, metrics []ReleaseMetric, rels releases.Releases) (MetricDetail, error) {
+	d := MetricDetail{Name: name, ReleaseNames: make([]string, 0)}
+
+	byRelease := make(map[string]ReleaseMetric)
+	for _, m := range metrics {
+		if rels.GetReleaseForName(m.Release) == nil {
+			return d, fmt.Errorf("release '%s' for metric '%s' not found", m.Release, name)
+		}
+		byRelease[m.Release] = m
+	}
+
+	ordered := make(releases.Releases, len(rels))
+	copy(ordered, rels)
+	ordered.SortBy(releases.SortByVersion)
+
+	for _, r := range ordered {
+		if m, ok := byRelease[r.Name]; ok {
+			d.ReleaseNames = append(d.ReleaseNames, r.Name)
+			d.Help = m.Help
+			d.Type = m.Type
+		}
+	}
+	return d, nil
+}
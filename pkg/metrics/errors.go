@@ -0,0 +1,12 @@
+package metrics
+
+import "fmt"
+
+// UnknownMetricError is returned when a metric has not been captured for any release
+type UnknownMetricError struct {
+	Name string
+}
+
+func (e *UnknownMetricError) Error() string {
+	return fmt.Sprintf("unknown metric '%s'", e.Name)
+}
@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jonstjohn/crdb-settings/pkg/releases"
+	"github.com/jonstjohn/crdb-settings/pkg/textsim"
+)
+
+type MetricChangeType int
+
+const (
+	FirstSeen MetricChangeType = iota
+	LastSeen
+	Changed
+)
+
+func (t MetricChangeType) String() string {
+	switch t {
+	case FirstSeen:
+		return "first_seen"
+	case LastSeen:
+		return "last_seen"
+	case Changed:
+		return "changed"
+	}
+	return fmt.Sprintf("unknown(%d)", int(t))
+}
+
+func (t MetricChangeType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// MetricHistory is the timeline of a metric. FirstSeen and LastSeen are the first and last releases the metric
+// appears in, by version.
+type MetricHistory struct {
+	Metric    string                `json:"metric"`
+	FirstSeen string                `json:"first_seen"`
+	LastSeen  string                `json:"last_seen"`
+	Changes   []MetricHistoryChange `json:"changes"`
+}
+
+// MetricHistoryChange is a single entry in the timeline of a metric. FirstSeen entries only have an After metric and
+// LastSeen entries only have a Before metric, where ToRelease is the first release the metric was missing from.
+type MetricHistoryChange struct {
+	Type        MetricChangeType `json:"type"`
+	FromRelease string           `json:"from_release"`
+	ToRelease   string           `json:"to_release"`
+	Fields      []string         `json:"fields"`
+	Before      *ReleaseMetric   `json:"before"`
+	After       *ReleaseMetric   `json:"after"`
+}
+
+// GenerateMetricHistory builds a chronological timeline of the help and type of a metric. The metrics are the metric
+// for each release it appears in, and rels is every release that metrics have been captured for, which is needed to
+// find the release where the metric disappeared. Releases are ordered by version, not release date.
+func GenerateMetricHistory(name string, metrics []ReleaseMetric, rels releases.Releases) (MetricHistory, error) {
+	h := MetricHistory{Metric: name, Changes: make([]MetricHistoryChange, 0)}
+
+	byRelease := make(map[string]ReleaseMetric)
+	for _, m := range metrics {
+		if rels.GetReleaseForName(m.Release) == nil {
+			return h, fmt.Errorf("release '%s' for metric '%s' not found", m.Release, name)
+		}
+		byRelease[m.Release] = m
+	}
+
+	ordered := make(releases.Releases, len(rels))
+	copy(ordered, rels)
+	ordered.SortBy(releases.SortByVersion)
+
+	var previous *ReleaseMetric
+	for _, r := range ordered {
+		current, ok := byRelease[r.Name]
+
+		switch {
+		case ok && previous == nil: // appeared (or re-appeared) in this release
+			if h.FirstSeen == "" {
+				h.FirstSeen = r.Name
+			}
+			h.Changes = append(h.Changes, MetricHistoryChange{
+				Type:      FirstSeen,
+				ToRelease: r.Name,
+				After:     &current,
+			})
+		case !ok && previous != nil: // disappeared in this release
+			h.Changes = append(h.Changes, MetricHistoryChange{
+				Type:        LastSeen,
+				FromRelease: previous.Release,
+				ToRelease:   r.Name,
+				Before:      previous,
+			})
+		case ok && previous != nil:
+			if fields := changedHistoryFields(*previous, current); len(fields) > 0 {
+				h.Changes = append(h.Changes, MetricHistoryChange{
+					Type:        Changed,
+					FromRelease: previous.Release,
+					ToRelease:   r.Name,
+					Fields:      fields,
+					Before:      previous,
+					After:       &current,
+				})
+			}
+		}
+
+		if ok {
+			h.LastSeen = r.Name
+			previous = &current
+		} else {
+			previous = nil
+		}
+	}
+
+	return h, nil
+}
+
+// changedHistoryFields returns the fields of the history that differ between two releases of the same metric
+func changedHistoryFields(before ReleaseMetric, after ReleaseMetric) []string {
+	fields := make([]string, 0)
+	if textsim.Clean(before.Help) != textsim.Clean(after.Help) {
+		fields = append(fields, FieldHelp)
+	}
+	if before.Type != after.Type {
+		fields = append(fields, FieldType)
+	}
+	return fields
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"github.com/jonstjohn/crdb-settings/pkg/releases"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var capturedReleases = releases.Releases{
+	{Name: "v23.2.0", Major: 23, Minor: 2},
+	{Name: "v22.2.0", Major: 22, Minor: 2},
+	{Name: "v23.1.0", Major: 23, Minor: 1},
+	{Name: "v23.1.10", Major: 23, Minor: 1, Patch: 10},
+	{Name: "v24.1.0", Major: 24, Minor: 1},
+}
+
+func TestGenerateMetricHistory(t *testing.T) {
+	metrics := []ReleaseMetric{
+		// out of order on purpose, history should be ordered by version
+		{Release: "v23.1.10", Metric: "sys_uptime", Help: "Process uptime in seconds", Type: Gauge},
+		{Release: "v22.2.0", Metric: "sys_uptime", Help: "Process uptime", Type: Counter},
+		{Release: "v23.1.0", Metric: "sys_uptime", Help: "Process uptime.", Type: Counter, Series: 1},
+		{Release: "v23.2.0", Metric: "sys_uptime", Help: "Process uptime in seconds", Type: Gauge, Series: 2},
+	}
+
+	h, err := GenerateMetricHistory("sys_uptime", metrics, capturedReleases)
+	assert.NoError(t, err)
+	assert.Equal(t, "sys_uptime", h.Metric)
+	assert.Equal(t, "v22.2.0", h.FirstSeen)
+	assert.Equal(t, "v23.2.0", h.LastSeen)
+	assert.Len(t, h.Changes, 3)
+
+	assert.Equal(t, FirstSeen, h.Changes[0].Type)
+	assert.Equal(t, "v22.2.0", h.Changes[0].ToRelease)
+	assert.Nil(t, h.Changes[0].Before)
+
+	// The trailing period on the help and series changes are not history changes
+	assert.Equal(t, Changed, h.Changes[1].Type)
+	assert.Equal(t, "v23.1.0", h.Changes[1].FromRelease)
+	assert.Equal(t, "v23.1.10", h.Changes[1].ToRelease)
+	assert.Equal(t, []string{FieldHelp, FieldType}, h.Changes[1].Fields)
+
+	assert.Equal(t, LastSeen, h.Changes[2].Type)
+	assert.Equal(t, "v23.2.0", h.Changes[2].FromRelease)
+	assert.Equal(t, "v24.1.0", h.Changes[2].ToRelease)
+	assert.Nil(t, h.Changes[2].After)
+}
+
+func TestGenerateMetricHistoryUnknownRelease(t *testing.T) {
+	_, err := GenerateMetricHistory("sys_uptime", []ReleaseMetric{{Release: "v99.1.0", Metric: "sys_uptime"}},
+		capturedReleases)
+	assert.Error(t, err)
+}
+
+func TestGenerateMetricDetail(t *testing.T) {
+	metrics := []ReleaseMetric{
+		{Release: "v24.1.0", Metric: "sys_uptime", Help: "Process uptime in seconds", Type: Gauge},
+		{Release: "v22.2.0", Metric: "sys_uptime", Help: "Process uptime", Type: Counter},
+		{Release: "v23.1.0", Metric: "sys_uptime", Help: "Process uptime", Type: Counter},
+	}
+
+	d, err := GenerateMetricDetail("sys_uptime", metrics, capturedReleases)
+	assert.NoError(t, err)
+	assert.Equal(t, MetricDetail{Name: "sys_uptime", Help: "Process uptime in seconds", Type: Gauge,
+		ReleaseNames: []string{"v22.2.0", "v23.1.0", "v24.1.0"}}, d)
+
+	_, err = GenerateMetricDetail("sys_uptime", []ReleaseMetric{{Release: "v99.1.0"}}, capturedReleases)
+	assert.Error(t, err)
+}
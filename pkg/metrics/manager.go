@@ -1,8 +1,11 @@
 package metrics
 
 import (
+	"context"
 	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jonstjohn/crdb-settings/pkg/crdbcluster"
+	"github.com/jonstjohn/crdb-settings/pkg/migrate"
 	"github.com/jonstjohn/crdb-settings/pkg/releases"
 	"github.com/sirupsen/logrus"
 	"math"
@@ -11,7 +14,9 @@ import (
 )
 
 type Manager struct {
-	Db *Db
+	Db          *Db
+	Binaries    crdbcluster.BinaryProvider // optional, cockroach binaries used to capture metrics
+	Parallelism int                        // number of releases captured at once, one if not set
 }
 
 func NewManager(url string) (*Manager, error) {
@@ -23,62 +28,84 @@ func NewManager(url string) (*Manager, error) {
 	return &Manager{Db: db}, err
 }
 
+// NewManagerFromPool returns a manager that uses an existing pool, which is owned and closed by the caller
+func NewManagerFromPool(pool *pgxpool.Pool) *Manager {
+	return &Manager{Db: NewDbFromPool(pool)}
+}
+
+// InitializeDatabase applies all pending schema migrations, which includes the metrics database and tables
 func (m *Manager) InitializeDatabase() error {
-	return m.Db.Initialize()
+	mm, err := migrate.NewManagerFromPool(m.Db.Pool)
+	if err != nil {
+		return err
+	}
+	return mm.Up()
 }
 
+// GetMetricsForRelease gets the metrics captured for a release, returning a releases.UnknownReleaseError if the
+// release does not exist
 func (m *Manager) GetMetricsForRelease(releaseName string) ([]Metric, error) {
+	rm := releases.NewReleasesManagerFromPool(m.Db.Pool)
+	if _, err := rm.GetRelease(releaseName); err != nil {
+		return nil, err
+	}
+
 	rows, err := m.Db.SelectRaw(releaseName)
 	if err != nil {
 		return nil, err
 	}
+	return metricsFromRows(rows), nil
+}
 
-	ms := make([]Metric, 0)
-	for _, row := range rows {
-		ms = append(ms, Metric{Name: row.Metric, Help: row.Help, Type: Type(row.Type)})
+// SaveMetricsForRelease captures and saves the metrics for one or more releases using a test cluster with the given
+// number of nodes, skipping releases that already have a save run for that number of nodes. Metrics from a
+// multi-node cluster are added to the metrics already saved for the release. Releases are captured by Parallelism
+// workers, and a release that fails does not stop the others; a crdbcluster.CaptureError lists the releases that
+// failed.
+func (m *Manager) SaveMetricsForRelease(ctx context.Context, releaseName string, nodes int) error {
+	if _, err := crdbcluster.NodesOpts(nodes); err != nil {
+		return err
+	}
+	rs, err := m.getReleasesNames(releaseName)
+	if err != nil {
+		return err
 	}
-	return ms, err
+	logrus.Info(fmt.Sprintf("Found %d releases that are candidate for updating with %d node(s)", len(rs), nodes))
+
+	pool := crdbcluster.NewPool(m.Parallelism, nodes, m.Binaries)
+	results := pool.Run(ctx, rs, m.saveMetricsForRelease)
+	return crdbcluster.ResultsError(results)
 }
 
-func (m *Manager) SaveMetricsForRelease(releaseName string) error {
-	rs, err := m.getReleasesNames(releaseName)
+// saveMetricsForRelease captures and saves the metrics of a release, unless they have already been captured, using
+// the cluster manager of a worker
+func (m *Manager) saveMetricsForRelease(ctx context.Context, cm *crdbcluster.Manager, r string) error {
+	nodes := cm.NodeCount()
+	runs, err := m.Db.SelectSaveRuns(r, nodes)
 	if err != nil {
 		return err
 	}
-	logrus.Info(fmt.Sprintf("Found %d releases that are candidate for updating", len(rs)))
+	if len(runs) > 0 {
+		logrus.Info(fmt.Sprintf("Save run already exists for '%s' with %d node(s), skipping", r, nodes))
+		return nil
+	}
 
-	// Iterate over releases
-	for _, r := range rs {
-		runs, err := m.Db.SelectSaveRuns(r)
-		if err != nil {
-			return err
-		}
-		if len(runs) > 0 {
-			logrus.Info(fmt.Sprintf("Save run already exists for '%s', skipping", r))
-			continue
-		}
+	metrics, err := MetricsFromCluster(cm, r)
+	if err != nil {
+		return err
+	}
 
-		metrics, err := m.GenerateMetricsForRelease(r)
-		if err != nil {
+	// Upsert
+	for _, metric := range metrics {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
-
-		// Upsert
-		for _, metric := range metrics {
-			err := m.Db.UpsertRaw(r, metric)
-			if err != nil {
-				return err
-			}
-		}
-
-		err = m.Db.UpsertSaveRun(r)
-		if err != nil {
+		if err := m.Db.UpsertRaw(r, nodes, metric); err != nil {
 			return err
 		}
-
 	}
 
-	return nil
+	return m.Db.UpsertSaveRun(r, nodes)
 }
 
 func (m *Manager) GetMetrics(releaseName string) ([]Metric, error) {
@@ -86,31 +113,28 @@ func (m *Manager) GetMetrics(releaseName string) ([]Metric, error) {
 	if err != nil {
 		return nil, err
 	}
-
-	ms := make([]Metric, 0)
-	for _, row := range rows {
-		ms = append(ms, Metric{Name: row.Metric, Help: row.Help, Type: Type(row.Type)})
-	}
-
-	return ms, nil
-
+	return metricsFromRows(rows), nil
 }
 
-func (m *Manager) GenerateMetricsForRelease(releaseName string) ([]Metric, error) {
-
-	cm := crdbcluster.NewManager()
-
+// MetricsFromCluster starts a test cluster for the release with an existing cluster manager, scrapes the metrics of
+// every node and stops the cluster, so that the manager can be used for another release
+func MetricsFromCluster(cm *crdbcluster.Manager, releaseName string) (Metrics, error) {
 	err := cm.StartTestCluster(releaseName)
 	if err != nil {
 		return nil, err
 	}
 
-	output, err := cm.GetMetricsEndpointOutput()
+	nodeFamilies, err := cm.GetMetricsFamilies()
 	if err != nil {
+		cm.CleanupTestCluster()
 		return nil, err
 	}
 
-	metrics := FromText(string(output))
+	nodeMetrics := make([]Metrics, len(nodeFamilies))
+	for i, families := range nodeFamilies {
+		nodeMetrics[i] = FromFamilies(families)
+	}
+	metrics := MergeMetrics(nodeMetrics...)
 
 	err = cm.CleanupTestCluster()
 	if err != nil {
@@ -134,6 +158,51 @@ func (m *Manager) CompareMetricsForReleases(r1 string, r2 string) (ComparedRelea
 	return CompareReleaseMetrics(r1, r1metrics, r2, r2metrics), nil
 }
 
+// GetMetricDetail gets the help and type of a metric and every release it appears in, returning an
+// UnknownMetricError if the metric has not been captured
+func (m *Manager) GetMetricDetail(metric string) (MetricDetail, error) {
+	rms, captured, err := m.getReleaseMetrics(metric)
+	if err != nil {
+		return MetricDetail{}, err
+	}
+	return GenerateMetricDetail(metric, rms, captured)
+}
+
+// HistoryForMetric generates a timeline of when a metric first appeared, changed help or type and disappeared,
+// returning an UnknownMetricError if the metric has not been captured
+func (m *Manager) HistoryForMetric(metric string) (MetricHistory, error) {
+	rms, captured, err := m.getReleaseMetrics(metric)
+	if err != nil {
+		return MetricHistory{}, err
+	}
+	return GenerateMetricHistory(metric, rms, captured)
+}
+
+// getReleaseMetrics gets a metric for every release it was captured for and every release that metrics have been
+// captured for. Only releases that we know about are included.
+func (m *Manager) getReleaseMetrics(metric string) ([]ReleaseMetric, releases.Releases, error) {
+	rows, err := m.Db.SelectRawForMetric(metric)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil, &UnknownMetricError{Name: metric}
+	}
+
+	names, err := m.Db.GetCapturedReleaseNames()
+	if err != nil {
+		return nil, nil, err
+	}
+	rm := releases.NewReleasesManagerFromPool(m.Db.Pool)
+	rels, err := rm.GetReleases()
+	if err != nil {
+		return nil, nil, err
+	}
+	captured := rels.FilterForNames(names)
+
+	return releaseMetricsFromRows(rows, captured), captured, nil
+}
+
 func (m *Manager) getReleasesNames(release string) ([]string, error) {
 	if release == "all" || strings.HasPrefix(release, "recent-") {
 		cnt := math.MaxInt
@@ -145,10 +214,7 @@ func (m *Manager) getReleasesNames(release string) ([]string, error) {
 				return nil, err
 			}
 		}
-		rm, err := releases.NewReleasesManager(m.Db.Url)
-		if err != nil {
-			return nil, err
-		}
+		rm := releases.NewReleasesManagerFromPool(m.Db.Pool)
 		return rm.GetRecentReleaseNames(cnt)
 	} else {
 		return []string{release}, nil
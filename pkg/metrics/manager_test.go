@@ -1,16 +1,15 @@
 package metrics
 
 import (
+	"context"
 	"github.com/cockroachdb/cockroach-go/v2/testserver"
+	"github.com/jonstjohn/crdb-settings/pkg/crdbcluster"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
 
 func TestManager_GetMetricsFromClusterForRelease(t *testing.T) {
-	m, err := NewManager("")
-	assert.NoError(t, err)
-
-	metrics, err := m.GenerateMetricsForRelease("v23.2.10")
+	metrics, err := MetricsFromCluster(crdbcluster.NewManager(), "v23.2.10")
 	assert.NoError(t, err)
 
 	assert.Equal(t, 1578, len(metrics))
@@ -31,7 +30,7 @@ func TestManager_SaveMetricsForRelease(t *testing.T) {
 	m, err := NewManager(url)
 	assert.NoError(t, err)
 	assert.NoError(t, m.InitializeDatabase())
-	err = m.SaveMetricsForRelease("v23.2.10")
+	err = m.SaveMetricsForRelease(context.Background(), "v23.2.10", 1)
 	assert.NoError(t, err)
 
 	metrics, err := m.GetMetrics("v23.2.10")
@@ -1,9 +1,58 @@
 package metrics
 
+import (
+	"slices"
+	"sort"
+)
+
 type Metrics []Metric
 
+// Metric is a metric of a release. Labels are the label names used by the samples of the metric, which is nil for
+// metrics captured before labels were recorded. Buckets are the upper bounds of a histogram's buckets as reported,
+// and Series is the number of distinct label sets of the metric on a single node, or 0 if it is not known.
 type Metric struct {
-	Name string `json:"name"`
-	Help string `json:"help"`
-	Type Type   `json:"type"`
+	Name    string   `json:"name"`
+	Help    string   `json:"help"`
+	Type    Type     `json:"type"`
+	Labels  []string `json:"labels"`
+	Buckets []string `json:"buckets"`
+	Series  int      `json:"series"`
+}
+
+// MergeMetrics combines the metrics scraped from several nodes into a single list sorted by name. Metrics that are
+// reported by more than one node are only included once, using the help, type and buckets of the first node that
+// reported them, the labels reported by any node and the most series reported by a node.
+func MergeMetrics(nodes ...Metrics) Metrics {
+	index := make(map[string]int)
+	merged := make(Metrics, 0)
+	for _, ms := range nodes {
+		for _, m := range ms {
+			i, ok := index[m.Name]
+			if !ok {
+				index[m.Name] = len(merged)
+				m.Labels = slices.Clone(m.Labels)
+				merged = append(merged, m)
+				continue
+			}
+			merged[i].Labels = unionLabels(merged[i].Labels, m.Labels)
+			merged[i].Series = max(merged[i].Series, m.Series)
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].Name < merged[j].Name
+	})
+	return merged
+}
+
+// unionLabels returns the sorted label names in either list, keeping nil if neither list is known
+func unionLabels(l1 []string, l2 []string) []string {
+	if l1 == nil && l2 == nil {
+		return nil
+	}
+	union := append(slices.Clone(l1), l2...)
+	if union == nil {
+		union = make([]string, 0)
+	}
+	slices.Sort(union)
+	return slices.Compact(union)
 }
@@ -2,8 +2,11 @@ package metrics
 
 import (
 	"bufio"
+	"github.com/jonstjohn/crdb-settings/pkg/exposition"
+	"github.com/jonstjohn/crdb-settings/pkg/releases"
 	"github.com/stretchr/testify/assert"
 	"os"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -21,10 +24,187 @@ func TestMetricsFromOutput(t *testing.T) {
 		s = append(s, scanner.Text())
 	}
 
-	metrics := FromText(strings.Join(s, "\n"))
-	assert.Equal(t, 1597, len(metrics))
-	assert.Equal(t, "kv_rangefeed_budget_allocation_failed", metrics[0].Name)
-	assert.Equal(t, "Number of times RangeFeed failed because memory budget was exceeded", metrics[0].Help)
-	assert.Equal(t, Counter, metrics[0].Type)
+	metrics, err := FromText(strings.Join(s, "\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1598, len(metrics))
+	assert.Equal(t, "abortspanbytes", metrics[0].Name)
+
+	i := slices.IndexFunc(metrics, func(m Metric) bool { return m.Name == "kv_rangefeed_budget_allocation_failed" })
+	assert.GreaterOrEqual(t, i, 0)
+	assert.Equal(t, "Number of times RangeFeed failed because memory budget was exceeded", metrics[i].Help)
+	assert.Equal(t, Type(Counter), metrics[i].Type)
+	assert.Equal(t, []string{"node_id", "store"}, metrics[i].Labels)
+
+	i = slices.IndexFunc(metrics, func(m Metric) bool { return m.Name == "admission_wait_durations_elastic_cpu_bulk_normal_pri" })
+	assert.Equal(t, Histogram, metrics[i].Type)
+	assert.Equal(t, "10000", metrics[i].Buckets[0])
+	assert.Equal(t, "+Inf", metrics[i].Buckets[len(metrics[i].Buckets)-1])
+	assert.Equal(t, 1, metrics[i].Series)
+
+}
+
+func TestMergeMetrics(t *testing.T) {
+	n1 := Metrics{
+		{Name: "sys_uptime", Help: "Process uptime", Type: Gauge},
+		{Name: "liveness_livenodes", Help: "Number of live nodes in the cluster", Type: Gauge},
+	}
+	n2 := Metrics{
+		{Name: "liveness_livenodes", Help: "Live nodes", Type: Gauge},
+		{Name: "ranges_underreplicated", Help: "Number of ranges with fewer live replicas than the replication target", Type: Gauge},
+	}
+
+	merged := MergeMetrics(n1, n2)
+	assert.Len(t, merged, 3)
+	assert.Equal(t, "liveness_livenodes", merged[0].Name)
+	assert.Equal(t, "Number of live nodes in the cluster", merged[0].Help)
+	assert.Equal(t, "ranges_underreplicated", merged[1].Name)
+	assert.Equal(t, "sys_uptime", merged[2].Name)
+
+	assert.Empty(t, MergeMetrics())
+}
+
+func TestMergeMetricsLabels(t *testing.T) {
+	n1 := Metrics{{Name: "capacity", Type: Gauge, Labels: []string{"node_id", "store"}, Series: 1}, {Name: "sys_uptime"}}
+	n2 := Metrics{{Name: "capacity", Type: Gauge, Labels: []string{"node_id", "tenant_id"}, Series: 2}, {Name: "sys_uptime"}}
+
+	merged := MergeMetrics(n1, n2)
+	assert.Equal(t, []string{"node_id", "store", "tenant_id"}, merged[0].Labels)
+	assert.Equal(t, 2, merged[0].Series)
+	assert.Nil(t, merged[1].Labels)
+	assert.Equal(t, []string{"node_id", "store"}, n1[0].Labels)
+}
+
+func TestMetricsFromRows(t *testing.T) {
+	series := 3
+	rows := []RawRow{
+		{ReleaseName: "v24.1.0", Nodes: 3, Metric: "capacity", Type: "GAUGE", Help: "Total storage capacity",
+			Labels: []string{"node_id", "store"}, Series: &series},
+		{ReleaseName: "v24.1.0", Nodes: 1, Metric: "capacity", Type: "GAUGE", Help: "Storage capacity",
+			Labels: []string{"store"}},
+		{ReleaseName: "v24.1.0", Nodes: 3, Metric: "liveness_livenodes", Type: "GAUGE"},
+	}
+
+	ms := metricsFromRows(rows)
+	assert.Len(t, ms, 2)
+	// The single node row comes first, whatever the order of the rows
+	assert.Equal(t, "Storage capacity", ms[0].Help)
+	assert.Equal(t, []string{"node_id", "store"}, ms[0].Labels)
+	assert.Equal(t, 3, ms[0].Series)
+	assert.Equal(t, "liveness_livenodes", ms[1].Name)
+
+	assert.Empty(t, metricsFromRows(nil))
+}
+
+func TestReleaseMetricsFromRows(t *testing.T) {
+	series := 3
+	rows := []RawRow{
+		{ReleaseName: "v23.1.0", Nodes: 1, Metric: "capacity", Type: string(Gauge), Help: "Storage capacity",
+			Labels: []string{"store"}},
+		{ReleaseName: "v23.1.0", Nodes: 3, Metric: "capacity", Type: string(Gauge), Help: "Total storage capacity",
+			Labels: []string{"node_id", "store"}, Series: &series},
+		{ReleaseName: "v24.1.0", Nodes: 3, Metric: "capacity", Type: string(Gauge), Help: "Total storage capacity"},
+		{ReleaseName: "v99.1.0", Nodes: 1, Metric: "capacity", Type: string(Gauge), Help: "Storage capacity"},
+	}
+	captured := releases.Releases{{Name: "v23.1.0", Major: 23, Minor: 1}, {Name: "v24.1.0", Major: 24, Minor: 1}}
+
+	// Every number of nodes of a release is merged, with the help from the single node row
+	assert.Equal(t, []ReleaseMetric{
+		{Release: "v23.1.0", Metric: "capacity", Help: "Storage capacity", Type: Gauge,
+			Labels: []string{"node_id", "store"}, Series: 3},
+		{Release: "v24.1.0", Metric: "capacity", Help: "Total storage capacity", Type: Gauge},
+	}, releaseMetricsFromRows(rows, captured))
+}
+
+func TestFromText(t *testing.T) {
+	tests := []struct {
+		name    string
+		section string
+		typ     Type
+		labels  []string
+		buckets []string
+		series  int
+	}{
+		{
+			name: "gauge",
+			section: `# HELP replicas Number of replicas
+# TYPE replicas gauge
+replicas{store="1",node_id="1"} 10
+replicas{store="2",node_id="1"} 10`,
+			typ: Gauge, labels: []string{"node_id", "store"}, series: 2,
+		},
+		{
+			name: "histogram",
+			section: `# HELP sql_exec_latency Latency of SQL statement execution
+# TYPE sql_exec_latency histogram
+sql_exec_latency_bucket{node_id="1",le="1000"} 0
+sql_exec_latency_bucket{node_id="1",le="+Inf"} 0
+sql_exec_latency_sum{node_id="1"} 0
+sql_exec_latency_count{node_id="1"} 0`,
+			typ: Histogram, labels: []string{"le", "node_id"}, buckets: []string{"1000", "+Inf"}, series: 1,
+		},
+		{
+			name: "summary",
+			section: `# HELP gc_pause GC pause durations
+# TYPE gc_pause summary
+gc_pause{quantile="0.5"} 1
+gc_pause{quantile="0.99"} 2
+gc_pause_sum 3
+gc_pause_count 2`,
+			typ: Summary, labels: []string{"quantile"}, series: 1,
+		},
+		{
+			name: "no help",
+			section: `# TYPE sys_uptime gauge
+sys_uptime{node_id="1"} 10`,
+			typ: Gauge, labels: []string{"node_id"}, series: 1,
+		},
+		{
+			name: "untyped",
+			section: `# HELP build_timestamp Build time
+build_timestamp{tag="v23.2.10"} 1`,
+			typ: Untyped, labels: []string{"tag"}, series: 1,
+		},
+		{
+			name:    "no metadata",
+			section: `sys_uptime{node_id="1"} 10`,
+			typ:     Untyped, labels: []string{"node_id"}, series: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics, err := FromText(tt.section)
+			assert.NoError(t, err)
+			assert.Len(t, metrics, 1)
+			m := metrics[0]
+			assert.Equal(t, tt.typ, m.Type)
+			assert.Equal(t, tt.labels, m.Labels)
+			assert.Equal(t, tt.buckets, m.Buckets)
+			assert.Equal(t, tt.series, m.Series)
+		})
+	}
+}
+
+func TestFromTextInvalid(t *testing.T) {
+	_, err := FromText(`broken{name="unterminated} 0`)
+	var parseErr *exposition.ParseError
+	assert.ErrorAs(t, err, &parseErr)
+}
+
+func TestFromFamilies(t *testing.T) {
+	families := []exposition.Family{
+		{Name: "sys_uptime", Type: exposition.TypeGauge, Samples: []exposition.Sample{{Name: "sys_uptime", Value: 1}}},
+		{Name: "requests", Type: exposition.TypeGaugeHistogram, Samples: []exposition.Sample{
+			{Name: "requests_bucket", Labels: []exposition.Label{{Name: "le", Value: "1"}}},
+			{Name: "requests_bucket", Labels: []exposition.Label{{Name: "le", Value: "+Inf"}}},
+		}},
+	}
 
+	metrics := FromFamilies(families)
+	assert.Len(t, metrics, 2)
+	assert.Equal(t, "requests", metrics[0].Name)
+	assert.Equal(t, GaugeHistogram, metrics[0].Type)
+	assert.Equal(t, []string{"1", "+Inf"}, metrics[0].Buckets)
+	assert.Equal(t, 1, metrics[0].Series)
+	assert.Equal(t, []string{}, metrics[1].Labels)
+	assert.Equal(t, 1, metrics[1].Series)
 }
@@ -1,64 +1,77 @@
 package metrics
 
 import (
-	"bufio"
-	"fmt"
 	"sort"
 	"strings"
-)
-
-type Type string
 
-const (
-	Counter   Type = "counter"
-	Gauge          = "gauge"
-	Histogram      = "histogram"
+	"github.com/jonstjohn/crdb-settings/pkg/exposition"
 )
 
-type LineType int
+type Type string
 
+// Metric types of the Prometheus exposition formats. Metrics without a TYPE line are untyped.
 const (
-	Help LineType = iota
-	MetricType
-	Data
+	Counter        Type = exposition.TypeCounter
+	Gauge          Type = exposition.TypeGauge
+	Histogram      Type = exposition.TypeHistogram
+	GaugeHistogram Type = exposition.TypeGaugeHistogram
+	Summary        Type = exposition.TypeSummary
+	Info           Type = exposition.TypeInfo
+	StateSet       Type = exposition.TypeStateSet
+	Untyped        Type = exposition.TypeUntyped
 )
 
-func FromText(text string) Metrics {
-	scanner := bufio.NewScanner(strings.NewReader(text))
-	var section []string
-	var metrics Metrics
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.HasPrefix(line, "# HELP") {
-			if section != nil {
-				metrics = append(metrics, parseSection(section))
-			}
-			section = nil
-			section = append(section, line)
-		} else {
-			section = append(section, line)
-		}
+// FromText parses metrics in the Prometheus text format, returning an exposition.ParseError if the text is not valid
+func FromText(text string) (Metrics, error) {
+	families, err := exposition.ParseText([]byte(text))
+	if err != nil {
+		return nil, err
 	}
+	return FromFamilies(families), nil
+}
 
+// FromFamilies converts parsed metric families, in any exposition format, to metrics sorted by name
+func FromFamilies(families []exposition.Family) Metrics {
+	metrics := make(Metrics, len(families))
+	for i, f := range families {
+		metrics[i] = fromFamily(f)
+	}
 	sort.Slice(metrics, func(i, j int) bool {
 		return metrics[i].Name < metrics[j].Name
 	})
-
 	return metrics
 }
 
-func parseSection(section []string) Metric {
-	m := Metric{}
-	for _, line := range section {
-		if strings.HasPrefix(line, "# HELP") {
-			name, help, _ := strings.Cut(line[len("# HELP "):], " ")
-			m.Name = name
-			m.Help = help
-		} else if strings.HasPrefix(line, "# TYPE") {
-			_, typ, _ := strings.Cut(line[len(fmt.Sprintf("# TYPE %s", m.Name)):], " ")
-			m.Type = Type(typ)
+// fromFamily gets the labels, histogram buckets and number of series of a family. The le label of histograms and
+// the quantile label of summaries do not make a new series.
+func fromFamily(f exposition.Family) Metric {
+	m := Metric{Name: f.Name, Help: f.Help, Type: Type(f.Type), Labels: make([]string, 0)}
+	isHistogram := m.Type == Histogram || m.Type == GaugeHistogram
+	labels := make(map[string]bool)
+	series := make(map[string]bool)
+	buckets := make(map[string]bool)
+	for _, s := range f.Samples {
+		id := make([]string, 0, len(s.Labels))
+		for _, l := range s.Labels {
+			labels[l.Name] = true
+			switch {
+			case l.Name == "le" && isHistogram:
+				if !buckets[l.Value] {
+					buckets[l.Value] = true
+					m.Buckets = append(m.Buckets, l.Value)
+				}
+			case l.Name == "quantile" && m.Type == Summary:
+			default:
+				id = append(id, l.Name+"="+l.Value)
+			}
 		}
+		sort.Strings(id)
+		series[strings.Join(id, ",")] = true
+	}
+	for l := range labels {
+		m.Labels = append(m.Labels, l)
 	}
+	sort.Strings(m.Labels)
+	m.Series = len(series)
 	return m
 }
@@ -0,0 +1,101 @@
+package migrate
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jonstjohn/crdb-settings/pkg/dbpgx"
+	"time"
+)
+
+type Db struct {
+	Url  string
+	Pool *pgxpool.Pool
+}
+
+const CreateSchemaMigrationsTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INT NOT NULL PRIMARY KEY,
+	name STRING NOT NULL,
+	applied TIMESTAMP NOT NULL DEFAULT now()
+)
+`
+
+const SelectAppliedSql = `
+SELECT version, applied FROM schema_migrations ORDER BY version
+`
+
+const InsertAppliedSql = `
+INSERT INTO schema_migrations (version, name) VALUES ($1, $2)
+`
+
+const DeleteAppliedSql = `
+DELETE FROM schema_migrations WHERE version = $1
+`
+
+func NewDbDatasource(url string) (*Db, error) {
+	pool, err := dbpgx.NewPoolFromUrl(url)
+	if err != nil {
+		return nil, err
+	}
+	return &Db{
+		Url:  url,
+		Pool: pool,
+	}, nil
+}
+
+// NewDbFromPool returns a datasource that uses an existing pool, which is owned and closed by the caller
+func NewDbFromPool(pool *pgxpool.Pool) *Db {
+	return &Db{Pool: pool}
+}
+
+func (db *Db) createSchemaMigrationsTableIfNotExists() error {
+	_, err := db.Pool.Exec(context.Background(), CreateSchemaMigrationsTable)
+	return err
+}
+
+// GetApplied gets the versions of all applied migrations and when they were applied
+func (db *Db) GetApplied() (map[int]time.Time, error) {
+	rows, err := db.Pool.Query(context.Background(), SelectAppliedSql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var t time.Time
+		if err := rows.Scan(&version, &t); err != nil {
+			return nil, err
+		}
+		applied[version] = t
+	}
+	return applied, rows.Err()
+}
+
+// Apply runs the up statements for a migration and records it in a single transaction
+func (db *Db) Apply(m Migration) error {
+	return pgx.BeginFunc(context.Background(), db.Pool, func(tx pgx.Tx) error {
+		for _, stmt := range m.Up {
+			if _, err := tx.Exec(context.Background(), stmt); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec(context.Background(), InsertAppliedSql, m.Version, m.Name)
+		return err
+	})
+}
+
+// Revert runs the down statements for a migration and removes its record in a single transaction
+func (db *Db) Revert(m Migration) error {
+	return pgx.BeginFunc(context.Background(), db.Pool, func(tx pgx.Tx) error {
+		for _, stmt := range m.Down {
+			if _, err := tx.Exec(context.Background(), stmt); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec(context.Background(), DeleteAppliedSql, m.Version)
+		return err
+	})
+}
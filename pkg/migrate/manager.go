@@ -0,0 +1,88 @@
+package migrate
+
+import (
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type Manager struct {
+	Db         *Db
+	Migrations []Migration
+}
+
+func NewManager(url string) (*Manager, error) {
+	if err := Validate(Migrations); err != nil {
+		return nil, err
+	}
+
+	db, err := NewDbDatasource(url)
+	if err != nil {
+		return nil, err
+	}
+	return &Manager{Db: db, Migrations: Migrations}, err
+}
+
+// NewManagerFromPool returns a manager that uses an existing pool, which is owned and closed by the caller
+func NewManagerFromPool(pool *pgxpool.Pool) (*Manager, error) {
+	if err := Validate(Migrations); err != nil {
+		return nil, err
+	}
+	return &Manager{Db: NewDbFromPool(pool), Migrations: Migrations}, nil
+}
+
+// Up applies all pending migrations in order, stopping at the first failure
+func (m *Manager) Up() error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	pending := Pending(m.Migrations, applied)
+	logrus.Info(fmt.Sprintf("Found %d pending migrations", len(pending)))
+
+	for _, mig := range pending {
+		logrus.Info(fmt.Sprintf("Applying migration %d '%s'", mig.Version, mig.Name))
+		if err := m.Db.Apply(mig); err != nil {
+			return fmt.Errorf("migration %d '%s' failed: %w", mig.Version, mig.Name, err)
+		}
+	}
+	return nil
+}
+
+// Down rolls back the most recently applied migrations, up to steps migrations. Nothing is rolled back if any of
+// them is irreversible.
+func (m *Manager) Down(steps int) error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	rollback, err := Rollback(m.Migrations, applied, steps)
+	if err != nil {
+		return err
+	}
+	for _, mig := range rollback {
+		logrus.Info(fmt.Sprintf("Rolling back migration %d '%s'", mig.Version, mig.Name))
+		if err := m.Db.Revert(mig); err != nil {
+			return fmt.Errorf("rollback of migration %d '%s' failed: %w", mig.Version, mig.Name, err)
+		}
+	}
+	return nil
+}
+
+func (m *Manager) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	return Statuses(m.Migrations, applied), nil
+}
+
+func (m *Manager) applied() (map[int]time.Time, error) {
+	if err := m.Db.createSchemaMigrationsTableIfNotExists(); err != nil {
+		return nil, err
+	}
+	return m.Db.GetApplied()
+}
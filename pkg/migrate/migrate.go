@@ -0,0 +1,95 @@
+package migrate
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// The migrate package manages the database schema through an ordered list of versioned migrations. Applied
+// migrations are recorded in the schema_migrations table.
+
+type Migration struct {
+	Version      int
+	Name         string
+	Up           []string
+	Down         []string
+	Irreversible bool // cannot be rolled back, such as when rows are deleted, and has no down statements
+}
+
+// IrreversibleError is returned when a rollback includes a migration that cannot be rolled back
+type IrreversibleError struct {
+	Version int
+	Name    string
+}
+
+func (e *IrreversibleError) Error() string {
+	return fmt.Sprintf("migration %d '%s' is irreversible and cannot be rolled back", e.Version, e.Name)
+}
+
+type Status struct {
+	Version int        `json:"version"`
+	Name    string     `json:"name"`
+	Applied *time.Time `json:"applied"`
+}
+
+// Validate checks that migrations have unique, increasing versions and up statements, with down statements unless
+// they are irreversible
+func Validate(migrations []Migration) error {
+	for i, m := range migrations {
+		if i > 0 && m.Version <= migrations[i-1].Version {
+			return fmt.Errorf("migration %d '%s' is out of order", m.Version, m.Name)
+		}
+		if len(m.Up) == 0 {
+			return fmt.Errorf("migration %d '%s' must have up statements", m.Version, m.Name)
+		}
+		if m.Irreversible && len(m.Down) > 0 {
+			return fmt.Errorf("migration %d '%s' is irreversible and must not have down statements", m.Version, m.Name)
+		}
+		if !m.Irreversible && len(m.Down) == 0 {
+			return fmt.Errorf("migration %d '%s' must have down statements", m.Version, m.Name)
+		}
+	}
+	return nil
+}
+
+// Pending returns the migrations that have not been applied, in the order they should be applied
+func Pending(migrations []Migration, applied map[int]time.Time) []Migration {
+	pending := make([]Migration, 0)
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; !ok {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
+
+// Rollback returns up to steps applied migrations in the order they should be rolled back, most recent first. An
+// IrreversibleError is returned if any of them is irreversible, so that nothing is rolled back.
+func Rollback(migrations []Migration, applied map[int]time.Time, steps int) ([]Migration, error) {
+	rollback := make([]Migration, 0)
+	for _, m := range slices.Backward(migrations) {
+		if len(rollback) >= steps {
+			break
+		}
+		if _, ok := applied[m.Version]; ok {
+			if m.Irreversible {
+				return nil, &IrreversibleError{Version: m.Version, Name: m.Name}
+			}
+			rollback = append(rollback, m)
+		}
+	}
+	return rollback, nil
+}
+
+// Statuses returns the status of every migration
+func Statuses(migrations []Migration, applied map[int]time.Time) []Status {
+	statuses := make([]Status, len(migrations))
+	for i, m := range migrations {
+		statuses[i] = Status{Version: m.Version, Name: m.Name}
+		if t, ok := applied[m.Version]; ok {
+			statuses[i].Applied = &t
+		}
+	}
+	return statuses
+}
@@ -0,0 +1,70 @@
+package migrate
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMigrationsValid(t *testing.T) {
+	assert.NoError(t, Validate(Migrations))
+}
+
+func TestValidateOutOfOrder(t *testing.T) {
+	ms := []Migration{
+		{Version: 2, Name: "b", Up: []string{"SELECT 1"}, Down: []string{"SELECT 1"}},
+		{Version: 1, Name: "a", Up: []string{"SELECT 1"}, Down: []string{"SELECT 1"}},
+	}
+	assert.Error(t, Validate(ms))
+}
+
+func TestValidateIrreversible(t *testing.T) {
+	ms := []Migration{{Version: 1, Name: "a", Up: []string{"SELECT 1"}, Irreversible: true}}
+	assert.NoError(t, Validate(ms))
+
+	ms[0].Down = []string{"SELECT 1"}
+	assert.Error(t, Validate(ms))
+
+	ms[0].Irreversible = false
+	assert.NoError(t, Validate(ms))
+
+	ms[0].Down = nil
+	assert.Error(t, Validate(ms))
+}
+
+func TestRollbackIrreversible(t *testing.T) {
+	ms := []Migration{{Version: 1}, {Version: 2, Name: "b", Irreversible: true}, {Version: 3}}
+	applied := map[int]time.Time{1: time.Now(), 2: time.Now(), 3: time.Now()}
+
+	rollback, err := Rollback(ms, applied, 1)
+	assert.NoError(t, err)
+	assert.Len(t, rollback, 1)
+
+	// Nothing is rolled back once an irreversible migration would be reached
+	rollback, err = Rollback(ms, applied, 2)
+	assert.Nil(t, rollback)
+	assert.Equal(t, &IrreversibleError{Version: 2, Name: "b"}, err)
+}
+
+func TestPendingAndRollback(t *testing.T) {
+	ms := []Migration{{Version: 1}, {Version: 2}, {Version: 3}}
+	applied := map[int]time.Time{1: time.Now(), 2: time.Now()}
+
+	pending := Pending(ms, applied)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, 3, pending[0].Version)
+
+	rollback, err := Rollback(ms, applied, 1)
+	assert.NoError(t, err)
+	assert.Len(t, rollback, 1)
+	assert.Equal(t, 2, rollback[0].Version)
+
+	rollback, err = Rollback(ms, applied, 5)
+	assert.NoError(t, err)
+	assert.Len(t, rollback, 2)
+	assert.Equal(t, 1, rollback[1].Version)
+
+	statuses := Statuses(ms, applied)
+	assert.NotNil(t, statuses[0].Applied)
+	assert.Nil(t, statuses[2].Applied)
+}
@@ -0,0 +1,330 @@
+package migrate
+
+// Migrations is the ordered list of schema migrations. Migrations that have been released must never be edited,
+// add a new migration with the next version instead. The initial migrations use IF NOT EXISTS so that databases
+// that were created by hand before migrations existed can be brought under management without changes.
+var Migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_releases",
+		Up: []string{`
+CREATE TABLE IF NOT EXISTS releases (
+	name STRING NOT NULL PRIMARY KEY,
+	withdrawn BOOL NOT NULL,
+	cloud_only BOOL NOT NULL,
+	release_type STRING NOT NULL,
+	release_date TIMESTAMP NOT NULL,
+	major_version STRING NOT NULL,
+	major INT NOT NULL,
+	minor INT NOT NULL,
+	patch INT NOT NULL,
+	beta_rc STRING,
+	beta_rc_version INT,
+	INDEX (release_date),
+	INDEX (major, minor, patch)
+)`,
+		},
+		Down: []string{`DROP TABLE IF EXISTS releases`},
+	},
+	{
+		Version: 2,
+		Name:    "create_settings_raw",
+		Up: []string{`
+CREATE TABLE IF NOT EXISTS settings_raw (
+	release_name STRING,
+	cpu INT,
+	memory_bytes INT,
+	variable STRING NOT NULL,
+	value STRING NOT NULL,
+	type STRING NOT NULL,
+	public BOOL NOT NULL,
+	description STRING NOT NULL,
+	default_value STRING NOT NULL,
+	origin STRING NOT NULL,
+	key STRING NOT NULL,
+	updated TIMESTAMP NOT NULL DEFAULT now(),
+	PRIMARY KEY (release_name, variable, cpu, memory_bytes),
+	INDEX (variable, release_name)
+)`,
+		},
+		Down: []string{`DROP TABLE IF EXISTS settings_raw`},
+	},
+	{
+		Version: 3,
+		Name:    "create_save_runs",
+		Up: []string{`
+CREATE TABLE IF NOT EXISTS save_runs (
+	release_name STRING,
+	cpu INT,
+	memory_bytes INT,
+	updated TIMESTAMP NOT NULL DEFAULT now(),
+	PRIMARY KEY (release_name, cpu, memory_bytes)
+)`,
+		},
+		Down: []string{`DROP TABLE IF EXISTS save_runs`},
+	},
+	{
+		Version: 4,
+		Name:    "create_settings_summary",
+		Up: []string{`
+CREATE TABLE IF NOT EXISTS settings_summary (
+	variable STRING NOT NULL PRIMARY KEY,
+	value STRING NOT NULL,
+	type STRING NOT NULL,
+	public BOOL NOT NULL,
+	description STRING NOT NULL,
+	default_value STRING NOT NULL,
+	origin STRING NOT NULL,
+	key STRING NOT NULL,
+	first_releases STRING[] NULL,
+	last_releases STRING[] NULL,
+	value_changes JSONB NULL,
+	description_changes JSONB NULL
+)`,
+		},
+		Down: []string{`DROP TABLE IF EXISTS settings_summary`},
+	},
+	{
+		Version: 5,
+		Name:    "add_settings_summary_host_dependent",
+		Up: []string{`
+ALTER TABLE settings_summary ADD COLUMN IF NOT EXISTS host_dependent BOOL NOT NULL DEFAULT false`,
+		},
+		Down: []string{`ALTER TABLE settings_summary DROP COLUMN IF EXISTS host_dependent`},
+	},
+	{
+		Version: 6,
+		Name:    "create_settings_github",
+		Up: []string{`
+CREATE TABLE IF NOT EXISTS settings_github_issues (
+	variable STRING NOT NULL,
+	id INT8 NOT NULL,
+	number INT NOT NULL,
+	url STRING NOT NULL,
+	title STRING NOT NULL,
+	created TIMESTAMP NULL,
+	closed TIMESTAMP NULL,
+	processed TIMESTAMP NOT NULL DEFAULT now(),
+	PRIMARY KEY (variable, id)
+)`, `
+CREATE TABLE IF NOT EXISTS settings_github_processed (
+	variable STRING NOT NULL PRIMARY KEY,
+	processed TIMESTAMP NOT NULL DEFAULT now()
+)`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS settings_github_processed`,
+			`DROP TABLE IF EXISTS settings_github_issues`,
+		},
+	},
+	{
+		Version: 7,
+		Name:    "create_metrics",
+		Up: []string{`
+CREATE DATABASE IF NOT EXISTS blatta`, `
+CREATE TABLE IF NOT EXISTS blatta.metrics_raw (
+	release_name STRING,
+	metric STRING NOT NULL,
+	type STRING NOT NULL,
+	help STRING NOT NULL,
+	updated TIMESTAMP NOT NULL DEFAULT now(),
+	PRIMARY KEY (release_name, metric),
+	INDEX (metric, release_name)
+)`, `
+CREATE TABLE IF NOT EXISTS blatta.metrics_save_runs (
+	release_name STRING,
+	updated TIMESTAMP NOT NULL DEFAULT now(),
+	PRIMARY KEY (release_name)
+)`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS blatta.metrics_save_runs`,
+			`DROP TABLE IF EXISTS blatta.metrics_raw`,
+		},
+	},
+	{
+		Version: 8,
+		Name:    "add_save_runs_nodes",
+		Up: []string{
+			`ALTER TABLE save_runs ADD COLUMN IF NOT EXISTS nodes INT NOT NULL DEFAULT 1`,
+			`ALTER TABLE blatta.metrics_save_runs ADD COLUMN IF NOT EXISTS nodes INT NOT NULL DEFAULT 1`,
+		},
+		Down: []string{
+			`ALTER TABLE blatta.metrics_save_runs DROP COLUMN IF EXISTS nodes`,
+			`ALTER TABLE save_runs DROP COLUMN IF EXISTS nodes`,
+		},
+	},
+	{
+		// The primary key change is separate from adding the column since CockroachDB does not allow both in the
+		// same transaction. Dropping and adding the constraint in one statement avoids keeping the old primary key
+		// as a unique index, which would prevent a save run per node count.
+		Version: 9,
+		Name:    "key_save_runs_by_nodes",
+		Up: []string{
+			`ALTER TABLE save_runs DROP CONSTRAINT save_runs_pkey,
+	ADD CONSTRAINT save_runs_pkey PRIMARY KEY (release_name, cpu, memory_bytes, nodes)`,
+			`ALTER TABLE blatta.metrics_save_runs DROP CONSTRAINT metrics_save_runs_pkey,
+	ADD CONSTRAINT metrics_save_runs_pkey PRIMARY KEY (release_name, nodes)`,
+		},
+		Down: []string{
+			`ALTER TABLE blatta.metrics_save_runs DROP CONSTRAINT metrics_save_runs_pkey,
+	ADD CONSTRAINT metrics_save_runs_pkey PRIMARY KEY (release_name)`,
+			`ALTER TABLE save_runs DROP CONSTRAINT save_runs_pkey,
+	ADD CONSTRAINT save_runs_pkey PRIMARY KEY (release_name, cpu, memory_bytes)`,
+		},
+	},
+	{
+		// Labels are null for metrics captured before they were recorded, so that they are not reported as changed
+		Version: 10,
+		Name:    "add_metrics_labels",
+		Up: []string{
+			`ALTER TABLE blatta.metrics_raw ADD COLUMN IF NOT EXISTS labels STRING[]`,
+		},
+		Down: []string{
+			`ALTER TABLE blatta.metrics_raw DROP COLUMN IF EXISTS labels`,
+		},
+	},
+	{
+		Version: 11,
+		Name:    "add_metrics_buckets_series",
+		Up: []string{
+			`ALTER TABLE blatta.metrics_raw ADD COLUMN IF NOT EXISTS buckets STRING[]`,
+			`ALTER TABLE blatta.metrics_raw ADD COLUMN IF NOT EXISTS series INT`,
+		},
+		Down: []string{
+			`ALTER TABLE blatta.metrics_raw DROP COLUMN IF EXISTS series`,
+			`ALTER TABLE blatta.metrics_raw DROP COLUMN IF EXISTS buckets`,
+		},
+	},
+	{
+		// A capture run records every artifact captured from a single test cluster, which are saved together
+		Version: 12,
+		Name:    "create_capture_runs_and_session_variables",
+		Up: []string{`
+CREATE TABLE IF NOT EXISTS session_variables_raw (
+	release_name STRING NOT NULL,
+	variable STRING NOT NULL,
+	value STRING NOT NULL,
+	type STRING NOT NULL DEFAULT '',
+	description STRING NOT NULL DEFAULT '',
+	updated TIMESTAMP NOT NULL DEFAULT now(),
+	PRIMARY KEY (release_name, variable),
+	INDEX (variable, release_name)
+)`, `
+CREATE TABLE IF NOT EXISTS capture_runs (
+	release_name STRING NOT NULL,
+	cpu INT NOT NULL,
+	memory_bytes INT NOT NULL,
+	nodes INT NOT NULL,
+	artifacts STRING[] NOT NULL,
+	updated TIMESTAMP NOT NULL DEFAULT now(),
+	PRIMARY KEY (release_name, cpu, memory_bytes, nodes)
+)`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS capture_runs`,
+			`DROP TABLE IF EXISTS session_variables_raw`,
+		},
+	},
+	{
+		// Column names and types are kept in ordinal order in parallel arrays, so that each table is one row
+		Version: 13,
+		Name:    "create_catalog_tables_and_functions",
+		Up: []string{`
+CREATE TABLE IF NOT EXISTS catalog_tables_raw (
+	release_name STRING NOT NULL,
+	schema_name STRING NOT NULL,
+	table_name STRING NOT NULL,
+	column_names STRING[] NOT NULL,
+	column_types STRING[] NOT NULL,
+	updated TIMESTAMP NOT NULL DEFAULT now(),
+	PRIMARY KEY (release_name, schema_name, table_name)
+)`, `
+CREATE TABLE IF NOT EXISTS builtin_functions_raw (
+	release_name STRING NOT NULL,
+	function STRING NOT NULL,
+	signature STRING NOT NULL,
+	category STRING NOT NULL DEFAULT '',
+	details STRING NOT NULL DEFAULT '',
+	updated TIMESTAMP NOT NULL DEFAULT now(),
+	PRIMARY KEY (release_name, function, signature)
+)`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS builtin_functions_raw`,
+			`DROP TABLE IF EXISTS catalog_tables_raw`,
+		},
+	},
+	{
+		Version: 14,
+		Name:    "create_keywords",
+		Up: []string{`
+CREATE TABLE IF NOT EXISTS keywords_raw (
+	release_name STRING NOT NULL,
+	word STRING NOT NULL,
+	category STRING NOT NULL,
+	description STRING NOT NULL DEFAULT '',
+	updated TIMESTAMP NOT NULL DEFAULT now(),
+	PRIMARY KEY (release_name, word)
+)`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS keywords_raw`,
+		},
+	},
+	{
+		// Raw settings and metrics captured before the node count was recorded were captured with a single node
+		Version: 15,
+		Name:    "add_raw_nodes",
+		Up: []string{
+			`ALTER TABLE settings_raw ADD COLUMN IF NOT EXISTS nodes INT NOT NULL DEFAULT 1`,
+			`ALTER TABLE blatta.metrics_raw ADD COLUMN IF NOT EXISTS nodes INT NOT NULL DEFAULT 1`,
+		},
+		Down: []string{
+			`ALTER TABLE blatta.metrics_raw DROP COLUMN IF EXISTS nodes`,
+			`ALTER TABLE settings_raw DROP COLUMN IF EXISTS nodes`,
+		},
+	},
+	{
+		// Keyed by nodes like the save runs, so that a capture with another node count does not overwrite the
+		// settings and metrics of a release. Separate from adding the column for the same reason as version 9.
+		// Irreversible, since the previous key fails once a release has been captured with several node counts.
+		Version:      16,
+		Name:         "key_raw_by_nodes",
+		Irreversible: true,
+		Up: []string{
+			`ALTER TABLE settings_raw DROP CONSTRAINT settings_raw_pkey,
+	ADD CONSTRAINT settings_raw_pkey PRIMARY KEY (release_name, variable, cpu, memory_bytes, nodes)`,
+			`ALTER TABLE blatta.metrics_raw DROP CONSTRAINT metrics_raw_pkey,
+	ADD CONSTRAINT metrics_raw_pkey PRIMARY KEY (release_name, metric, nodes)`,
+		},
+	},
+	{
+		// Session variables, the catalog and keywords are saved by release, so a release has a single capture run.
+		// The most recent run of each release is kept, releases with artifacts missing from it are captured again.
+		// Irreversible, since deleted runs can't be restored.
+		Version:      17,
+		Name:         "delete_capture_runs_per_shape",
+		Irreversible: true,
+		Up: []string{`
+DELETE FROM capture_runs
+WHERE (release_name, cpu, memory_bytes, nodes) NOT IN (
+	SELECT DISTINCT ON (release_name) release_name, cpu, memory_bytes, nodes
+	FROM capture_runs
+	ORDER BY release_name, updated DESC
+)`,
+		},
+	},
+	{
+		// Separate from deleting the runs since CockroachDB does not allow a schema change after a write in the same
+		// transaction. The shape and node count are kept to record what the release was captured with. Irreversible
+		// along with version 17, since a run per release can't be split back into runs per shape.
+		Version:      18,
+		Name:         "key_capture_runs_by_release",
+		Irreversible: true,
+		Up: []string{
+			`ALTER TABLE capture_runs DROP CONSTRAINT capture_runs_pkey,
+	ADD CONSTRAINT capture_runs_pkey PRIMARY KEY (release_name)`,
+		},
+	},
+}
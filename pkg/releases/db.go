@@ -2,6 +2,8 @@ package releases
 
 import (
 	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jonstjohn/crdb-settings/pkg/dbpgx"
 	"time"
@@ -23,6 +25,11 @@ func NewDbDatasource(url string) (*Db, error) {
 	}, nil
 }
 
+// NewDbFromPool returns a datasource that uses an existing pool, which is owned and closed by the caller
+func NewDbFromPool(pool *pgxpool.Pool) *Db {
+	return &Db{Pool: pool}
+}
+
 // GetReleases gets releases from the database pool connection
 func (db *Db) GetReleases() (Releases, error) {
 	rows, err := db.getReleasesRows()
@@ -49,6 +56,21 @@ func (db *Db) GetReleases() (Releases, error) {
 	return rels, nil
 }
 
+// GetRelease gets a single release by name, returning nil if the release does not exist
+func (db *Db) GetRelease(name string) (*Release, error) {
+	var r Release
+	err := db.Pool.QueryRow(context.Background(), SelectReleaseSql, name).Scan(
+		&r.Name, &r.Withdrawn, &r.CloudOnly, &r.ReleaseType, &r.ReleaseDate, &r.MajorVersion,
+		&r.Major, &r.Minor, &r.Patch, &r.BetaRc, &r.BetaRcVersion)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
 func (db *Db) SaveReleases(rels Releases) error {
 	for _, r := range rels {
 
@@ -111,24 +133,6 @@ type SqlExecutor struct {
 	Pool *pgxpool.Pool
 }
 
-const CREATE_TABLE = `
-CREATE TABLE IF NOT EXISTS releases (
-	name STRING NOT NULL PRIMARY KEY,
-	withdrawn BOOL NOT NULL,
-	cloud_only BOOL NOT NULL,
-	release_type STRING NOT NULL,
-	release_date TIMESTAMP NOT NULL,
-	major_version STRING NOT NULL,
-	major INT NOT NULL,
-	minor INT NOT NULL,
-	patch INT NOT NULL,
-	beta_rc STRING,
-	beta_rc_version INT,
-	INDEX (release_date),
-	INDEX (major, minor, patch)
-)	
-`
-
 const SelectAllReleasesSql = `
 SELECT
 	name,
@@ -147,6 +151,24 @@ FROM
 ORDER BY major DESC, minor DESC, patch DESC, beta_rc = '' DESC, beta_rc DESC, beta_rc_version DESC
 `
 
+const SelectReleaseSql = `
+SELECT
+	name,
+	withdrawn,
+	cloud_only,
+	release_type,
+	release_date,
+	major_version,
+	major,
+	minor,
+	patch,
+	COALESCE(beta_rc, ''),
+	COALESCE(beta_rc_version, 0)
+FROM
+	releases
+WHERE name = $1
+`
+
 const UPSERT = `
 UPSERT INTO releases (
 	name, withdrawn, cloud_only,
@@ -170,11 +192,6 @@ ORDER BY major DESC, minor DESC, patch DESC
 LIMIT 1
 `
 
-func (db *Db) CreateTable() error {
-	_, err := db.Pool.Exec(context.Background(), CREATE_TABLE)
-	return err
-}
-
 func (db *Db) UpsertRelease(r Release) error {
 	_, err := db.Pool.Exec(context.Background(), UPSERT,
 		r.Name, r.Withdrawn, r.CloudOnly,
@@ -0,0 +1,22 @@
+package releases
+
+import "fmt"
+
+// UnknownReleaseError is returned when a release is not in the releases table
+type UnknownReleaseError struct {
+	Name string
+}
+
+func (e *UnknownReleaseError) Error() string {
+	return fmt.Sprintf("unknown release '%s'", e.Name)
+}
+
+// InvalidReleaseNameError is returned when a release name can never match a release, such as a name without a
+// version
+type InvalidReleaseNameError struct {
+	Name string
+}
+
+func (e *InvalidReleaseNameError) Error() string {
+	return fmt.Sprintf("invalid release name '%s', expected a name like 'v23.2.10'", e.Name)
+}
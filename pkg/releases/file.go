@@ -0,0 +1,93 @@
+package releases
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// File provides releases from a local copy of the releases YAML, for use without network access. The path can be
+// a single YAML file or a directory, in which case every .yml and .yaml file in the directory is read.
+type File struct {
+	Path string
+}
+
+func NewFileDataSource(path string) *File {
+	return &File{Path: path}
+}
+
+// NewYamlDataSource returns the provider for a releases YAML location, which can be a local file or directory,
+// a file:// URL or an http(s):// URL. An empty location uses the releases YAML maintained by the docs team.
+func NewYamlDataSource(location string) (Provider, error) {
+	switch {
+	case location == "":
+		return NewRemoteDataSource(), nil
+	case strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://"):
+		return &Remote{Url: location}, nil
+	case strings.HasPrefix(location, "file://"):
+		u, err := url.Parse(location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file URL '%s': %w", location, err)
+		}
+		return NewFileDataSource(u.Path), nil
+	default:
+		return NewFileDataSource(location), nil
+	}
+}
+
+func (f *File) GetReleases() (Releases, error) {
+	remoteReleases, err := f.GetRemoteReleases()
+	if err != nil {
+		return Releases{}, err
+	}
+	return releasesFromRemoteReleases(remoteReleases), nil
+}
+
+// GetRemoteReleases reads the releases as they appear in the YAML, using the same format as Remote
+func (f *File) GetRemoteReleases() ([]RemoteRelease, error) {
+	paths, err := f.paths()
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]RemoteRelease, 0)
+	for _, p := range paths {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("could not read release data: %w", err)
+		}
+		rs, err := parseRemoteReleases(b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+		data = append(data, rs...)
+	}
+	return data, nil
+}
+
+func (f *File) paths() ([]string, error) {
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read release data: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{f.Path}, nil
+	}
+
+	paths := make([]string, 0)
+	for _, pattern := range []string{"*.yml", "*.yaml"} {
+		matches, err := filepath.Glob(filepath.Join(f.Path, pattern))
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, matches...)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no release YAML files found in '%s'", f.Path)
+	}
+	slices.Sort(paths)
+	return paths, nil
+}
@@ -0,0 +1,52 @@
+package releases
+
+import (
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileGetReleases(t *testing.T) {
+	f := NewFileDataSource("testdata/yaml/releases.yml")
+	rs, err := f.GetReleases()
+	assert.NoError(t, err)
+	assert.Len(t, rs, 3)
+
+	rc := rs.GetReleaseForName("v23.2.0-rc.1")
+	assert.NotNil(t, rc)
+	assert.Equal(t, 23, rc.Major)
+	assert.Equal(t, 2, rc.Minor)
+	assert.Equal(t, "rc", rc.BetaRc)
+	assert.Equal(t, 1, rc.BetaRcVersion)
+	assert.Equal(t, "Testing", rc.ReleaseType)
+	assert.Equal(t, 2024, rc.ReleaseDate.Year())
+
+	assert.True(t, rs.GetReleaseForName("v23.2.1").Withdrawn)
+}
+
+func TestFileGetReleasesFromDirectory(t *testing.T) {
+	f := NewFileDataSource("testdata/yaml")
+	rs, err := f.GetReleases()
+	assert.NoError(t, err)
+	assert.Len(t, rs, 4)
+	assert.NotNil(t, rs.GetReleaseForName("v24.1.0"))
+}
+
+func TestNewYamlDataSource(t *testing.T) {
+	abs, err := filepath.Abs("testdata/yaml/releases.yml")
+	assert.NoError(t, err)
+
+	p, err := NewYamlDataSource("file://" + abs)
+	assert.NoError(t, err)
+	assert.Equal(t, &File{Path: abs}, p)
+	rs, err := p.GetReleases()
+	assert.NoError(t, err)
+	assert.Len(t, rs, 3)
+
+	p, err = NewYamlDataSource("")
+	assert.NoError(t, err)
+	assert.Equal(t, releaseDataURL, p.(*Remote).Url)
+
+	_, err = NewFileDataSource("testdata/missing.yml").GetReleases()
+	assert.Error(t, err)
+}
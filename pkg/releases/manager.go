@@ -1,5 +1,7 @@
 package releases
 
+import "github.com/jackc/pgx/v5/pgxpool"
+
 type Manager struct {
 	Db *Db
 }
@@ -13,6 +15,11 @@ func NewReleasesManager(url string) (*Manager, error) {
 	return &Manager{Db: db}, err
 }
 
+// NewReleasesManagerFromPool returns a manager that uses an existing pool, which is owned and closed by the caller
+func NewReleasesManagerFromPool(pool *pgxpool.Pool) *Manager {
+	return &Manager{Db: NewDbFromPool(pool)}
+}
+
 func (rm *Manager) GetReleases() (Releases, error) {
 	rows, err := rm.Db.getReleasesRows()
 	if err != nil {
@@ -38,13 +45,33 @@ func (rm *Manager) GetReleases() (Releases, error) {
 	return rels, nil
 }
 
+// GetRelease gets a single release by name. An InvalidReleaseNameError is returned for names that can never match a
+// release and an UnknownReleaseError for releases that are not in the database.
+func (rm *Manager) GetRelease(name string) (Release, error) {
+	r, err := rm.Db.GetRelease(name)
+	if err != nil {
+		return Release{}, err
+	}
+	if r == nil {
+		if !namePattern.MatchString(name) {
+			return Release{}, &InvalidReleaseNameError{Name: name}
+		}
+		return Release{}, &UnknownReleaseError{Name: name}
+	}
+	return *r, nil
+}
+
 func (rm *Manager) UpdateReleases() error {
-	remote := NewRemoteDataSource()
-	releasesFromRemote, err := remote.GetReleases()
+	return rm.UpdateReleasesFromProvider(NewRemoteDataSource())
+}
+
+// UpdateReleasesFromProvider saves releases from any provider, such as a local YAML file
+func (rm *Manager) UpdateReleasesFromProvider(p Provider) error {
+	rels, err := p.GetReleases()
 	if err != nil {
 		return err
 	}
-	return rm.Db.SaveReleases(releasesFromRemote)
+	return rm.Db.SaveReleases(rels)
 }
 
 func (rm *Manager) GetRecentReleaseNames(cnt int) ([]string, error) {
@@ -78,7 +78,6 @@ func (r *Release) CompareVersion(r2 *Release) int {
 	} else {
 		return 1
 	}
-	return 1
 }
 
 func (rs *Releases) SortBy(sort SortBy) {
@@ -196,3 +195,21 @@ func (rs *Releases) LastReleasePerMajorVersion() Releases {
 	rsmv.SortBy(SortByVersion)
 	return rsmv
 }
+
+// Between returns the production releases from one release to another, including both, sorted by version. Withdrawn,
+// cloud only and testing releases are skipped unless they are one of the ends of the range.
+func (rs Releases) Between(from Release, to Release) Releases {
+	between := make(Releases, 0)
+	for _, r := range rs {
+		if r.CompareVersion(&from) < 0 || r.CompareVersion(&to) > 0 {
+			continue
+		}
+		isEnd := r.Name == from.Name || r.Name == to.Name
+		if !isEnd && (r.Withdrawn || r.CloudOnly || r.ReleaseType != "Production") {
+			continue
+		}
+		between = append(between, r)
+	}
+	between.SortBy(SortByVersion)
+	return between
+}
@@ -146,3 +146,26 @@ func TestReleasesSortBy(t *testing.T) {
 	rs.SortBy(SortByReleaseDate)
 	assert.Equal(t, "", rs[0].BetaRc)
 }
+
+func TestReleasesBetween(t *testing.T) {
+	rs := Releases{
+		{Name: "v24.1.0", Major: 24, Minor: 1, ReleaseType: "Production"},
+		{Name: "v23.2.0", Major: 23, Minor: 2, ReleaseType: "Production"},
+		{Name: "v23.2.1", Major: 23, Minor: 2, Patch: 1, ReleaseType: "Production", Withdrawn: true},
+		{Name: "v23.2.2", Major: 23, Minor: 2, Patch: 2, ReleaseType: "Production", CloudOnly: true},
+		{Name: "v24.1.0-beta.1", Major: 24, Minor: 1, BetaRc: "beta", BetaRcVersion: 1, ReleaseType: "Testing"},
+		{Name: "v23.1.0", Major: 23, Minor: 1, ReleaseType: "Production"},
+		{Name: "v22.2.0", Major: 22, Minor: 2, ReleaseType: "Production"},
+	}
+
+	names := func(rs Releases) []string {
+		n := make([]string, len(rs))
+		for i, r := range rs {
+			n[i] = r.Name
+		}
+		return n
+	}
+	assert.Equal(t, []string{"v23.1.0", "v23.2.0", "v24.1.0"}, names(rs.Between(rs[5], rs[0])))
+	assert.Equal(t, []string{"v23.2.0", "v24.1.0-beta.1"}, names(rs.Between(rs[1], rs[4])))
+	assert.Empty(t, rs.Between(rs[0], rs[5]))
+}
@@ -32,10 +32,12 @@ const releaseDataURL = "https://raw.githubusercontent.com/cockroachdb/docs/main/
 var namePattern = regexp.MustCompile(`^v(\d+).(\d+).(\d+)-?(beta|rc|alpha)?\.?(\d+)?$`)
 var majorVersionPattern = regexp.MustCompile(`^v(\d+).(\d+)$`)
 
-type Remote struct{}
+type Remote struct {
+	Url string
+}
 
 func NewRemoteDataSource() *Remote {
-	return &Remote{}
+	return &Remote{Url: releaseDataURL}
 }
 
 type CustomTime struct {
@@ -89,13 +91,17 @@ func ServerForVersion(v string) (testserver.TestServer, error) {
 }
 
 func (r *Remote) GetReleases() (Releases, error) {
-
-	rels := Releases{}
 	remoteReleases, err := r.GetRemoteReleases()
 	if err != nil {
-		return rels, nil
+		return Releases{}, err
 	}
+	return releasesFromRemoteReleases(remoteReleases), nil
+}
 
+// releasesFromRemoteReleases converts releases parsed from the releases YAML into releases, including the version
+// parsed from the release name
+func releasesFromRemoteReleases(remoteReleases []RemoteRelease) Releases {
+	rels := Releases{}
 	for _, rel := range remoteReleases {
 		v := rel.Version()
 		rels = append(rels, Release{
@@ -113,23 +119,34 @@ func (r *Remote) GetReleases() (Releases, error) {
 		})
 	}
 
-	return rels, nil
+	return rels
 }
 
 func (r *Remote) GetRemoteReleases() ([]RemoteRelease, error) {
-	resp, err := http.Get(releaseDataURL)
+	url := r.Url
+	if url == "" {
+		url = releaseDataURL
+	}
+	resp, err := http.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("could not download release data: %w", err)
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not download release data from '%s': %s", url, resp.Status)
+	}
 
 	var blob bytes.Buffer
 	if _, err := io.Copy(&blob, resp.Body); err != nil {
 		return nil, fmt.Errorf("error reading response body: %w", err)
 	}
 
+	return parseRemoteReleases(blob.Bytes())
+}
+
+func parseRemoteReleases(b []byte) ([]RemoteRelease, error) {
 	var data []RemoteRelease
-	if err := yaml.Unmarshal(blob.Bytes(), &data); err != nil { //nolint:yaml
+	if err := yaml.Unmarshal(b, &data); err != nil { //nolint:yaml
 		return nil, fmt.Errorf("failed to YAML parse release data: %w", err)
 	}
 
@@ -214,7 +231,6 @@ func (r *Remote) GetReleaseSortedByVersion() ([]RemoteRelease, error) {
 		} else {
 			return false
 		}
-		return true
 	})
 	return releases, nil
 }
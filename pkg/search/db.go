@@ -0,0 +1,112 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jonstjohn/crdb-settings/pkg/dbpgx"
+)
+
+type Db struct {
+	Url  string
+	Pool *pgxpool.Pool
+}
+
+// settingsSearchSql selects the settings where every term matches the variable or the description from the most
+// recent release, with every release they appear in from the most recent. Rows are aggregated before filtering, so
+// that the filter sees the same description that is returned and ranked. The %s is replaced by the term conditions.
+const settingsSearchSql = `
+SELECT variable, description, releases
+FROM (
+	SELECT
+		variable,
+		(array_agg(description ORDER BY major DESC, minor DESC, patch DESC, beta_rc = '' DESC, beta_rc DESC, beta_rc_version DESC))[1] AS description,
+		array_agg(release_name ORDER BY major DESC, minor DESC, patch DESC, beta_rc = '' DESC, beta_rc DESC, beta_rc_version DESC) AS releases
+	FROM (
+		SELECT DISTINCT
+			s.variable, s.release_name, s.description,
+			r.major, r.minor, r.patch, r.beta_rc, r.beta_rc_version
+		FROM settings_raw s INNER JOIN releases r ON s.release_name = r.name
+	) AS releases_settings
+	GROUP BY variable
+) AS recent_settings
+WHERE %s
+`
+
+// metricsSearchSql is the same as settingsSearchSql, for metrics and their help text
+const metricsSearchSql = `
+SELECT metric, help, releases
+FROM (
+	SELECT
+		metric,
+		(array_agg(help ORDER BY major DESC, minor DESC, patch DESC, beta_rc = '' DESC, beta_rc DESC, beta_rc_version DESC))[1] AS help,
+		array_agg(release_name ORDER BY major DESC, minor DESC, patch DESC, beta_rc = '' DESC, beta_rc DESC, beta_rc_version DESC) AS releases
+	FROM (
+		SELECT DISTINCT
+			m.metric, m.release_name, m.help,
+			r.major, r.minor, r.patch, r.beta_rc, r.beta_rc_version
+		FROM blatta.metrics_raw m INNER JOIN releases r ON m.release_name = r.name
+	) AS releases_metrics
+	GROUP BY metric
+) AS recent_metrics
+WHERE %s
+`
+
+func NewDbDatasource(url string) (*Db, error) {
+	pool, err := dbpgx.NewPoolFromUrl(url)
+	if err != nil {
+		return nil, err
+	}
+	return &Db{
+		Url:  url,
+		Pool: pool,
+	}, nil
+}
+
+// NewDbFromPool returns a datasource that uses an existing pool, which is owned and closed by the caller
+func NewDbFromPool(pool *pgxpool.Pool) *Db {
+	return &Db{Pool: pool}
+}
+
+// SearchSettings gets the settings where every term is in the variable or the most recent description
+func (db *Db) SearchSettings(terms []string) (Results, error) {
+	where, args := termConditions(terms, "variable", "description")
+	return db.search(KindSetting, fmt.Sprintf(settingsSearchSql, where), args)
+}
+
+// SearchMetrics gets the metrics where every term is in the metric name or the most recent help
+func (db *Db) SearchMetrics(terms []string) (Results, error) {
+	where, args := termConditions(terms, "metric", "help")
+	return db.search(KindMetric, fmt.Sprintf(metricsSearchSql, where), args)
+}
+
+func (db *Db) search(kind string, sql string, args []any) (Results, error) {
+	rows, err := db.Pool.Query(context.Background(), sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make(Results, 0)
+	for rows.Next() {
+		r := Result{Kind: kind}
+		if err := rows.Scan(&r.Name, &r.Description, &r.Releases); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// termConditions returns a condition requiring every term to be in the name or description column
+func termConditions(terms []string, nameColumn string, descriptionColumn string) (string, []any) {
+	conditions := make([]string, len(terms))
+	args := make([]any, len(terms))
+	for i, t := range terms {
+		args[i] = "%" + escapeLike(t) + "%"
+		conditions[i] = fmt.Sprintf("(%s ILIKE $%d OR %s ILIKE $%d)", nameColumn, i+1, descriptionColumn, i+1)
+	}
+	return strings.Join(conditions, " AND "), args
+}
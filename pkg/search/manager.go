@@ -0,0 +1,43 @@
+package search
+
+import "github.com/jackc/pgx/v5/pgxpool"
+
+type Manager struct {
+	Db *Db
+}
+
+func NewManager(url string) (*Manager, error) {
+	db, err := NewDbDatasource(url)
+	if err != nil {
+		return nil, err
+	}
+	return &Manager{Db: db}, err
+}
+
+// NewManagerFromPool returns a manager that uses an existing pool, which is owned and closed by the caller
+func NewManagerFromPool(pool *pgxpool.Pool) *Manager {
+	return &Manager{Db: NewDbFromPool(pool)}
+}
+
+// Search finds the settings and metrics where every term of the query is in the name or description, ranked by
+// where the terms match. A limit of zero uses DefaultLimit.
+func (m *Manager) Search(query string, limit int) (Results, error) {
+	if err := validate(query, limit); err != nil {
+		return nil, err
+	}
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+	terms := Terms(query)
+
+	settings, err := m.Db.SearchSettings(terms)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := m.Db.SearchMetrics(terms)
+	if err != nil {
+		return nil, err
+	}
+
+	return Rank(terms, append(settings, metrics...), limit), nil
+}
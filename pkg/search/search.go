@@ -0,0 +1,126 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	DefaultLimit = 25
+	MaxLimit     = 100
+)
+
+// Kinds of search results
+const (
+	KindSetting = "setting"
+	KindMetric  = "metric"
+)
+
+// Weights for a term matching the name or the description of an item, names are weighted higher since they are
+// what people usually half remember
+const (
+	nameWeight        = 3
+	descriptionWeight = 1
+	exactNameBonus    = 10
+)
+
+type Results []Result
+
+// Result is a setting or metric that matches every search term. Releases are ordered from the most recent and the
+// description is from the most recent release.
+type Result struct {
+	Kind        string   `json:"kind"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Score       int      `json:"score"`
+	Releases    []string `json:"releases"`
+}
+
+// InvalidQueryError is returned for queries that cannot be searched, such as an empty query
+type InvalidQueryError struct {
+	Message string
+}
+
+func (e *InvalidQueryError) Error() string {
+	return e.Message
+}
+
+// Terms splits a query into lower case terms, ignoring duplicates
+func Terms(query string) []string {
+	terms := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, t := range strings.Fields(strings.ToLower(query)) {
+		if !seen[t] {
+			seen[t] = true
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
+
+func validate(query string, limit int) error {
+	if len(Terms(query)) == 0 {
+		return &InvalidQueryError{Message: "query must include at least one term"}
+	}
+	if limit < 0 || limit > MaxLimit {
+		return &InvalidQueryError{Message: fmt.Sprintf("limit must be between 0 and %d, got %d", MaxLimit, limit)}
+	}
+	return nil
+}
+
+// Score ranks an item for the query terms. Every term must match the name or description, otherwise the score is
+// zero. A name that is exactly the query scores highest.
+func Score(terms []string, name string, description string) int {
+	name = strings.ToLower(name)
+	description = strings.ToLower(description)
+
+	score := 0
+	for _, t := range terms {
+		inName := strings.Contains(name, t)
+		inDescription := strings.Contains(description, t)
+		if !inName && !inDescription {
+			return 0
+		}
+		if inName {
+			score += nameWeight
+		}
+		if inDescription {
+			score += descriptionWeight
+		}
+	}
+	if name == strings.Join(terms, " ") {
+		score += exactNameBonus
+	}
+	return score
+}
+
+// Rank scores the results, drops those that do not match and sorts them by score, then kind and name. At most
+// limit results are returned.
+func Rank(terms []string, results Results, limit int) Results {
+	ranked := make(Results, 0)
+	for _, r := range results {
+		r.Score = Score(terms, r.Name, r.Description)
+		if r.Score > 0 {
+			ranked = append(ranked, r)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		if ranked[i].Kind != ranked[j].Kind {
+			return ranked[i].Kind > ranked[j].Kind // settings before metrics
+		}
+		return ranked[i].Name < ranked[j].Name
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
+
+// escapeLike escapes the LIKE wildcards so they match literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
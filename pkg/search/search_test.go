@@ -0,0 +1,83 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerms(t *testing.T) {
+	assert.Equal(t, []string{"rangefeed", "budget"}, Terms("  Rangefeed budget RANGEFEED "))
+	assert.Empty(t, Terms(" \t"))
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, validate("rangefeed", 0))
+	assert.NoError(t, validate("rangefeed", MaxLimit))
+
+	var invalid *InvalidQueryError
+	assert.ErrorAs(t, validate("", 10), &invalid)
+	assert.ErrorAs(t, validate("rangefeed", -1), &invalid)
+	assert.ErrorAs(t, validate("rangefeed", MaxLimit+1), &invalid)
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name        string
+		terms       []string
+		item        string
+		description string
+		score       int
+	}{
+		{"name", []string{"rangefeed"}, "kv.rangefeed.enabled", "enables rangefeeds", 4},
+		{"description only", []string{"budget"}, "kv.rangefeed.enabled", "memory budget", 1},
+		{"every term must match", []string{"rangefeed", "budget"}, "kv.rangefeed.enabled", "enables", 0},
+		{"terms across fields", []string{"rangefeed", "budget"}, "kv.rangefeed.enabled", "memory budget", 4},
+		{"case insensitive", []string{"rangefeed"}, "KV.RangeFeed.Enabled", "", 3},
+		{"exact name", []string{"kv.rangefeed.enabled"}, "kv.rangefeed.enabled", "", 13},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.score, Score(tt.terms, tt.item, tt.description))
+		})
+	}
+}
+
+func TestRank(t *testing.T) {
+	results := Results{
+		{Kind: KindMetric, Name: "rangefeed_catchup_scans", Description: "rangefeed catchup scans"},
+		{Kind: KindSetting, Name: "kv.closed_timestamp.target", Description: "used by rangefeed"},
+		{Kind: KindSetting, Name: "kv.rangefeed.enabled", Description: "enables rangefeeds"},
+		{Kind: KindSetting, Name: "sql.defaults.distsql", Description: "distsql"},
+	}
+
+	ranked := Rank([]string{"rangefeed"}, results, 10)
+	assert.Equal(t, []string{"kv.rangefeed.enabled", "rangefeed_catchup_scans", "kv.closed_timestamp.target"},
+		names(ranked))
+	assert.Equal(t, []int{4, 4, 1}, []int{ranked[0].Score, ranked[1].Score, ranked[2].Score})
+
+	assert.Len(t, Rank([]string{"rangefeed"}, results, 1), 1)
+}
+
+func TestTermConditions(t *testing.T) {
+	where, args := termConditions([]string{"rangefeed", "100%"}, "s.variable", "s.description")
+	assert.Equal(t, "(s.variable ILIKE $1 OR s.description ILIKE $1) AND (s.variable ILIKE $2 OR s.description ILIKE $2)",
+		where)
+	assert.Equal(t, []any{"%rangefeed%", `%100\%%`}, args)
+}
+
+func TestSearchSqlFiltersRecentText(t *testing.T) {
+	// The terms are matched after aggregating, against the same description or help that is ranked
+	for _, sql := range []string{settingsSearchSql, metricsSearchSql} {
+		assert.NotContains(t, sql, "HAVING")
+		assert.Regexp(t, `GROUP BY \w+\n\) AS recent_\w+\nWHERE %s\n$`, sql)
+	}
+}
+
+func names(results Results) []string {
+	n := make([]string, len(results))
+	for i, r := range results {
+		n[i] = r.Name
+	}
+	return n
+}
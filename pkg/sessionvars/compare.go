@@ -0,0 +1,94 @@
+package sessionvars
+
+// Fields of a session variable that are compared between releases
+const (
+	FieldValue       = "value"
+	FieldType        = "type"
+	FieldDescription = "description"
+)
+
+type ReleaseSessionVariable struct {
+	Release     string `json:"release"`
+	Variable    string `json:"variable"`
+	Value       string `json:"value"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+type ChangedSessionVariables []ChangedSessionVariable
+
+// ChangedSessionVariable is a session variable that exists in both releases with a different default value, type or
+// description. Fields lists what changed.
+type ChangedSessionVariable struct {
+	Before ReleaseSessionVariable `json:"before"`
+	After  ReleaseSessionVariable `json:"after"`
+	Fields []string               `json:"fields"`
+}
+
+type ComparedReleaseSessionVariables struct {
+	Added   []SessionVariable       `json:"added"`
+	Removed []SessionVariable       `json:"removed"`
+	Changed ChangedSessionVariables `json:"changed"`
+}
+
+func newReleaseSessionVariable(release string, v SessionVariable) ReleaseSessionVariable {
+	return ReleaseSessionVariable{Release: release, Variable: v.Variable, Value: v.Value, Type: v.Type,
+		Description: v.Description}
+}
+
+// CompareReleaseSessionVariables compares the session variables of two releases. Types and descriptions are only
+// compared when both releases have them, since pg_settings does not list every session variable.
+func CompareReleaseSessionVariables(r1 string, r1vars []SessionVariable, r2 string,
+	r2vars []SessionVariable) ComparedReleaseSessionVariables {
+
+	r1indexed := make(map[string]SessionVariable)
+	for _, v := range r1vars {
+		r1indexed[v.Variable] = v
+	}
+	r2indexed := make(map[string]SessionVariable)
+	for _, v := range r2vars {
+		r2indexed[v.Variable] = v
+	}
+
+	added := make([]SessionVariable, 0)
+	removed := make([]SessionVariable, 0)
+	changed := ChangedSessionVariables{}
+
+	for _, v1 := range r1vars {
+		v2, ok := r2indexed[v1.Variable]
+		if !ok { // exists in r1 but not r2
+			removed = append(removed, v1)
+			continue
+		}
+
+		fields := make([]string, 0)
+		if v1.Value != v2.Value {
+			fields = append(fields, FieldValue)
+		}
+		if v1.Type != "" && v2.Type != "" && v1.Type != v2.Type {
+			fields = append(fields, FieldType)
+		}
+		if v1.Description != "" && v2.Description != "" && v1.Description != v2.Description {
+			fields = append(fields, FieldDescription)
+		}
+		if len(fields) > 0 {
+			changed = append(changed, ChangedSessionVariable{
+				Before: newReleaseSessionVariable(r1, v1),
+				After:  newReleaseSessionVariable(r2, v2),
+				Fields: fields,
+			})
+		}
+	}
+
+	for _, v2 := range r2vars {
+		if _, ok := r1indexed[v2.Variable]; !ok {
+			added = append(added, v2)
+		}
+	}
+
+	return ComparedReleaseSessionVariables{
+		Added:   added,
+		Removed: removed,
+		Changed: changed,
+	}
+}
@@ -0,0 +1,46 @@
+package sessionvars
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareReleaseSessionVariables(t *testing.T) {
+	before := []SessionVariable{
+		{Variable: "default_transaction_isolation", Value: "serializable", Type: "string",
+			Description: "default isolation level"},
+		{Variable: "optimizer_use_histograms", Value: "off"},
+		{Variable: "optimizer_use_multicol_stats", Value: "on", Type: "bool"},
+		{Variable: "experimental_enable_hash_sharded_indexes", Value: "off"},
+		{Variable: "application_name", Value: "", Type: "string", Description: "application name"},
+	}
+	after := []SessionVariable{
+		{Variable: "default_transaction_isolation", Value: "serializable", Type: "string",
+			Description: "default transaction isolation level"},
+		{Variable: "optimizer_use_histograms", Value: "on"},
+		{Variable: "optimizer_use_multicol_stats", Value: "on"},
+		{Variable: "application_name", Value: "", Type: "string", Description: "application name"},
+		{Variable: "optimizer_use_lock_op_for_serializable", Value: "off"},
+	}
+	c := CompareReleaseSessionVariables("v23.1.0", before, "v23.2.0", after)
+
+	assert.Equal(t, []SessionVariable{{Variable: "optimizer_use_lock_op_for_serializable", Value: "off"}}, c.Added)
+	assert.Equal(t, []SessionVariable{{Variable: "experimental_enable_hash_sharded_indexes", Value: "off"}}, c.Removed)
+
+	// A type missing from pg_settings in one release is not a change
+	assert.Equal(t, ChangedSessionVariables{
+		{
+			Before: ReleaseSessionVariable{Release: "v23.1.0", Variable: "default_transaction_isolation",
+				Value: "serializable", Type: "string", Description: "default isolation level"},
+			After: ReleaseSessionVariable{Release: "v23.2.0", Variable: "default_transaction_isolation",
+				Value: "serializable", Type: "string", Description: "default transaction isolation level"},
+			Fields: []string{FieldDescription},
+		},
+		{
+			Before: ReleaseSessionVariable{Release: "v23.1.0", Variable: "optimizer_use_histograms", Value: "off"},
+			After:  ReleaseSessionVariable{Release: "v23.2.0", Variable: "optimizer_use_histograms", Value: "on"},
+			Fields: []string{FieldValue},
+		},
+	}, c.Changed)
+}
@@ -0,0 +1,128 @@
+package sessionvars
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jonstjohn/crdb-settings/pkg/dbpgx"
+)
+
+type Db struct {
+	Url  string
+	Pool *pgxpool.Pool
+}
+
+// RawSessionVariable is a session variable as captured for a release
+type RawSessionVariable struct {
+	ReleaseName string
+	Variable    string
+	Value       string
+	Type        string
+	Description string
+	Updated     time.Time
+}
+
+const UpsertRawSql = `
+UPSERT INTO session_variables_raw (release_name, variable, value, type, description, updated)
+VALUES ($1, $2, $3, $4, $5, now())
+`
+
+const SelectRawForReleaseSql = `
+SELECT release_name, variable, value, type, description, updated
+FROM session_variables_raw
+WHERE release_name = $1
+ORDER BY variable ASC
+`
+
+const SelectRawForVariableSql = `
+SELECT release_name, variable, value, type, description, updated
+FROM session_variables_raw
+WHERE variable = $1
+ORDER BY release_name ASC
+`
+
+const SelectCapturedReleaseNamesSql = `
+SELECT DISTINCT release_name FROM session_variables_raw
+`
+
+func NewDbDatasource(url string) (*Db, error) {
+	pool, err := dbpgx.NewPoolFromUrl(url)
+	if err != nil {
+		return nil, err
+	}
+	return &Db{
+		Url:  url,
+		Pool: pool,
+	}, nil
+}
+
+// NewDbFromPool returns a datasource that uses an existing pool, which is owned and closed by the caller
+func NewDbFromPool(pool *pgxpool.Pool) *Db {
+	return &Db{Pool: pool}
+}
+
+// SaveRawTx saves the session variables of a release in an existing transaction, so that they can be saved with
+// other artifacts
+func SaveRawTx(tx pgx.Tx, releaseName string, vars []SessionVariable) error {
+	for _, v := range vars {
+		_, err := tx.Exec(context.Background(), UpsertRawSql, releaseName, v.Variable, v.Value, v.Type, v.Description)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SelectRaw gets the session variables captured for a release, ordered by variable
+func (db *Db) SelectRaw(releaseName string) ([]RawSessionVariable, error) {
+	return db.selectRawRows(SelectRawForReleaseSql, releaseName)
+}
+
+// SelectRawForVariable gets the rows of a session variable for every release it was captured for
+func (db *Db) SelectRawForVariable(variable string) ([]RawSessionVariable, error) {
+	return db.selectRawRows(SelectRawForVariableSql, variable)
+}
+
+func (db *Db) selectRawRows(sql string, args ...any) ([]RawSessionVariable, error) {
+	rows, err := db.Pool.Query(context.Background(), sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rs := make([]RawSessionVariable, 0)
+	for rows.Next() {
+		var r RawSessionVariable
+		err := rows.Scan(&r.ReleaseName, &r.Variable, &r.Value, &r.Type, &r.Description, &r.Updated)
+		if err != nil {
+			return nil, err
+		}
+		rs = append(rs, r)
+	}
+	return rs, rows.Err()
+}
+
+// GetCapturedReleaseNames gets the names of all releases that have had session variables captured
+func (db *Db) GetCapturedReleaseNames() ([]string, error) {
+	rows, err := db.Pool.Query(context.Background(), SelectCapturedReleaseNamesSql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+func (r RawSessionVariable) toSessionVariable() SessionVariable {
+	return SessionVariable{Variable: r.Variable, Value: r.Value, Type: r.Type, Description: r.Description}
+}
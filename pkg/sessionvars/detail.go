@@ -0,0 +1,56 @@
+package sessionvars
+
+import (
+	"fmt"
+
+	"github.com/jonstjohn/crdb-settings/pkg/releases"
+)
+
+// SessionVariableDetail is a session variable with the type and description of the most recent release it appears
+// in, and its default value in every release it appears in ordered by version
+type SessionVariableDetail struct {
+	Variable    string         `json:"variable"`
+	Type        string         `json:"type"`
+	Description string         `json:"description"`
+	Values      []ReleaseValue `json:"values"`
+}
+
+// ReleaseValue is the default value of a session variable in a release
+type ReleaseValue struct {
+	Release string `json:"release"`
+	Value   string `json:"value"`
+}
+
+// GenerateSessionVariableDetail builds the detail of a session variable from the variable for each release it
+// appears in
+func GenerateSessionVariableDetail(variable string, vars []ReleaseSessionVariable,
+	rels releases.Releases) (SessionVariableDetail, error) {
+	d := SessionVariableDetail{Variable: variable, Values: make([]ReleaseValue, 0)}
+
+	byRelease := make(map[string]ReleaseSessionVariable)
+	for _, v := range vars {
+		if rels.GetReleaseForName(v.Release) == nil {
+			return d, fmt.Errorf("release '%s' for session variable '%s' not found", v.Release, variable)
+		}
+		byRelease[v.Release] = v
+	}
+
+	ordered := make(releases.Releases, len(rels))
+	copy(ordered, rels)
+	ordered.SortBy(releases.SortByVersion)
+
+	for _, r := range ordered {
+		v, ok := byRelease[r.Name]
+		if !ok {
+			continue
+		}
+		d.Values = append(d.Values, ReleaseValue{Release: r.Name, Value: v.Value})
+		if v.Type != "" {
+			d.Type = v.Type
+		}
+		if v.Description != "" {
+			d.Description = v.Description
+		}
+	}
+	return d, nil
+}
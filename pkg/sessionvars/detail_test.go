@@ -0,0 +1,42 @@
+package sessionvars
+
+import (
+	"testing"
+
+	"github.com/jonstjohn/crdb-settings/pkg/releases"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateSessionVariableDetail(t *testing.T) {
+	captured := releases.Releases{
+		{Name: "v23.2.0", Major: 23, Minor: 2},
+		{Name: "v22.2.0", Major: 22, Minor: 2},
+		{Name: "v23.1.0", Major: 23, Minor: 1},
+	}
+	vars := []ReleaseSessionVariable{
+		// out of order on purpose, values should be ordered by version
+		{Release: "v23.2.0", Variable: "optimizer_use_histograms", Value: "on", Type: "bool",
+			Description: "use histograms"},
+		{Release: "v22.2.0", Variable: "optimizer_use_histograms", Value: "off"},
+		{Release: "v23.1.0", Variable: "optimizer_use_histograms", Value: "off", Type: "bool",
+			Description: "use histograms in the optimizer"},
+	}
+
+	d, err := GenerateSessionVariableDetail("optimizer_use_histograms", vars, captured)
+	assert.NoError(t, err)
+	assert.Equal(t, SessionVariableDetail{
+		Variable:    "optimizer_use_histograms",
+		Type:        "bool",
+		Description: "use histograms",
+		Values: []ReleaseValue{
+			{Release: "v22.2.0", Value: "off"},
+			{Release: "v23.1.0", Value: "off"},
+			{Release: "v23.2.0", Value: "on"},
+		},
+	}, d)
+
+	// A release that was not captured is an error
+	_, err = GenerateSessionVariableDetail("optimizer_use_histograms",
+		append(vars, ReleaseSessionVariable{Release: "v99.1.0", Variable: "optimizer_use_histograms"}), captured)
+	assert.Error(t, err)
+}
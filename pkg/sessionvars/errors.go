@@ -0,0 +1,12 @@
+package sessionvars
+
+import "fmt"
+
+// UnknownSessionVariableError is returned when a session variable has not been captured for any release
+type UnknownSessionVariableError struct {
+	Variable string
+}
+
+func (e *UnknownSessionVariableError) Error() string {
+	return fmt.Sprintf("unknown session variable '%s'", e.Variable)
+}
@@ -0,0 +1,89 @@
+package sessionvars
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jonstjohn/crdb-settings/pkg/releases"
+)
+
+type Manager struct {
+	Db *Db
+}
+
+func NewManager(url string) (*Manager, error) {
+	db, err := NewDbDatasource(url)
+	if err != nil {
+		return nil, err
+	}
+	return &Manager{Db: db}, nil
+}
+
+// NewManagerFromPool returns a manager that uses an existing pool, which is owned and closed by the caller
+func NewManagerFromPool(pool *pgxpool.Pool) *Manager {
+	return &Manager{Db: NewDbFromPool(pool)}
+}
+
+// GetSessionVariablesForRelease gets the session variables captured for a release, returning a
+// releases.UnknownReleaseError if the release does not exist
+func (m *Manager) GetSessionVariablesForRelease(releaseName string) ([]SessionVariable, error) {
+	rm := releases.NewReleasesManagerFromPool(m.Db.Pool)
+	if _, err := rm.GetRelease(releaseName); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.Db.SelectRaw(releaseName)
+	if err != nil {
+		return nil, err
+	}
+	vars := make([]SessionVariable, len(rows))
+	for i, row := range rows {
+		vars[i] = row.toSessionVariable()
+	}
+	return vars, nil
+}
+
+func (m *Manager) CompareSessionVariablesForReleases(r1 string, r2 string) (ComparedReleaseSessionVariables, error) {
+	r1vars, err := m.GetSessionVariablesForRelease(r1)
+	if err != nil {
+		return ComparedReleaseSessionVariables{}, err
+	}
+
+	r2vars, err := m.GetSessionVariablesForRelease(r2)
+	if err != nil {
+		return ComparedReleaseSessionVariables{}, err
+	}
+
+	return CompareReleaseSessionVariables(r1, r1vars, r2, r2vars), nil
+}
+
+// GetSessionVariableDetail gets the type, description and default value per release of a session variable,
+// returning an UnknownSessionVariableError if the variable has not been captured
+func (m *Manager) GetSessionVariableDetail(variable string) (SessionVariableDetail, error) {
+	rows, err := m.Db.SelectRawForVariable(variable)
+	if err != nil {
+		return SessionVariableDetail{}, err
+	}
+	if len(rows) == 0 {
+		return SessionVariableDetail{}, &UnknownSessionVariableError{Variable: variable}
+	}
+
+	rm := releases.NewReleasesManagerFromPool(m.Db.Pool)
+	rels, err := rm.GetReleases()
+	if err != nil {
+		return SessionVariableDetail{}, err
+	}
+	names, err := m.Db.GetCapturedReleaseNames()
+	if err != nil {
+		return SessionVariableDetail{}, err
+	}
+	captured := rels.FilterForNames(names)
+
+	// Only releases that we know about are included
+	vars := make([]ReleaseSessionVariable, 0, len(rows))
+	for _, row := range rows {
+		if captured.GetReleaseForName(row.ReleaseName) == nil {
+			continue
+		}
+		vars = append(vars, newReleaseSessionVariable(row.ReleaseName, row.toSessionVariable()))
+	}
+	return GenerateSessionVariableDetail(variable, vars, captured)
+}
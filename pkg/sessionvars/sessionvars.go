@@ -0,0 +1,95 @@
+package sessionvars
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// IgnoredSessionVariables are session variables whose values are different for every session
+var IgnoredSessionVariables = []string{
+	"session_id",
+}
+
+// SessionVariable is a session variable of a new session with its default value. The type and description come from
+// pg_settings and are empty for variables it does not list.
+type SessionVariable struct {
+	Variable    string `json:"variable"`
+	Value       string `json:"value"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+const ShowAllSql = `SHOW ALL`
+
+const PgSettingsSql = `SELECT name, vartype, short_desc FROM pg_catalog.pg_settings`
+
+// GetLocalSessionVariables gets the session variables of a new session on a cluster, sorted by variable, using
+// SHOW ALL for the values and pg_settings for the types and descriptions
+func GetLocalSessionVariables(pool *pgxpool.Pool) ([]SessionVariable, error) {
+	rows, err := pool.Query(context.Background(), ShowAllSql)
+	if err != nil {
+		return nil, err
+	}
+	vars := make([]SessionVariable, 0)
+	for rows.Next() {
+		var v SessionVariable
+		if err := rows.Scan(&v.Variable, &v.Value); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		vars = append(vars, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = pool.Query(context.Background(), PgSettingsSql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	pgSettings := make(map[string]SessionVariable)
+	for rows.Next() {
+		var name string
+		var typ, desc *string
+		if err := rows.Scan(&name, &typ, &desc); err != nil {
+			return nil, err
+		}
+		s := SessionVariable{Variable: name}
+		if typ != nil {
+			s.Type = *typ
+		}
+		if desc != nil {
+			s.Description = *desc
+		}
+		pgSettings[name] = s
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return mergePgSettings(vars, pgSettings), nil
+}
+
+// mergePgSettings adds the type and description from pg_settings to the session variables, removes the ignored
+// variables and sorts them by variable
+func mergePgSettings(vars []SessionVariable, pgSettings map[string]SessionVariable) []SessionVariable {
+	merged := make([]SessionVariable, 0, len(vars))
+	for _, v := range vars {
+		if slices.Contains(IgnoredSessionVariables, v.Variable) {
+			continue
+		}
+		if s, ok := pgSettings[v.Variable]; ok {
+			v.Type = s.Type
+			v.Description = s.Description
+		}
+		merged = append(merged, v)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].Variable < merged[j].Variable
+	})
+	return merged
+}
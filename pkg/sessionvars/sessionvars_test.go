@@ -0,0 +1,25 @@
+package sessionvars
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePgSettings(t *testing.T) {
+	vars := []SessionVariable{
+		{Variable: "session_id", Value: "17b3c2f1"},
+		{Variable: "optimizer_use_histograms", Value: "on"},
+		{Variable: "default_transaction_isolation", Value: "serializable"},
+	}
+	pgSettings := map[string]SessionVariable{
+		"default_transaction_isolation": {Variable: "default_transaction_isolation", Type: "string",
+			Description: "default isolation level"},
+	}
+
+	assert.Equal(t, []SessionVariable{
+		{Variable: "default_transaction_isolation", Value: "serializable", Type: "string",
+			Description: "default isolation level"},
+		{Variable: "optimizer_use_histograms", Value: "on"},
+	}, mergePgSettings(vars, pgSettings))
+}
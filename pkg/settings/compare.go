@@ -1,10 +1,20 @@
 package settings
 
 import (
+	"fmt"
 	"slices"
+	"sort"
 	"strings"
+
+	"github.com/jonstjohn/crdb-settings/pkg/textsim"
 )
 
+// ReleaseRangeSeparator separates the first and last release of a range, such as v23.1.0...v24.1.0
+const ReleaseRangeSeparator = "..."
+
+// MaxCompareReleases is the most releases that can be compared at once
+const MaxCompareReleases = 50
+
 type ChangedSettings []ChangedSetting
 
 type ChangedSetting struct {
@@ -12,10 +22,132 @@ type ChangedSetting struct {
 	After  ReleaseSetting `json:"after"`
 }
 
+// Ways a renamed setting was matched to its previous name
+const (
+	RenameMatchedByKey         = "key"
+	RenameMatchedByDescription = "description"
+)
+
+type RenamedSettings []RenamedSetting
+
+// RenamedSetting is a setting whose name changed between releases. MatchedBy is key when both names have the same
+// key, or description when the names were matched by similar descriptions.
+type RenamedSetting struct {
+	Before    ReleaseSetting `json:"before"`
+	After     ReleaseSetting `json:"after"`
+	MatchedBy string         `json:"matched_by"`
+}
+
 type ComparedReleaseSettings struct {
 	Added   ReleaseSettings `json:"added"`
 	Removed ReleaseSettings `json:"removed"`
 	Changed ChangedSettings `json:"changed"`
+	Renamed RenamedSettings `json:"renamed"`
+}
+
+// SettingValues is a row of the comparison matrix, with one value for each compared release in order. The value is
+// nil for releases that do not have the setting.
+type SettingValues struct {
+	Variable string    `json:"variable"`
+	Values   []*string `json:"values"`
+}
+
+// ComparisonStep is the comparison of two consecutive releases of a multi-release comparison
+type ComparisonStep struct {
+	From    string                  `json:"from"`
+	To      string                  `json:"to"`
+	Changes ComparedReleaseSettings `json:"changes"`
+}
+
+// MultiReleaseComparison compares the settings of an ordered list of releases, such as the hops of an upgrade
+type MultiReleaseComparison struct {
+	Releases []string         `json:"releases"`
+	Matrix   []SettingValues  `json:"matrix"`
+	Steps    []ComparisonStep `json:"steps"`
+}
+
+// InvalidReleaseListError is returned for a list or range of releases that cannot be compared
+type InvalidReleaseListError struct {
+	Expression string
+	Message    string
+}
+
+func (e *InvalidReleaseListError) Error() string {
+	return fmt.Sprintf("invalid releases '%s': %s", e.Expression, e.Message)
+}
+
+// ParseReleaseList parses a comma separated list of releases, or a range of two releases such as
+// v23.1.0...v24.1.0. For a range, the first and last release are returned and isRange is true.
+func ParseReleaseList(expr string) (names []string, isRange bool, err error) {
+	if from, to, ok := strings.Cut(expr, ReleaseRangeSeparator); ok {
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if from == "" || to == "" || strings.Contains(to, ReleaseRangeSeparator) {
+			return nil, false, &InvalidReleaseListError{Expression: expr,
+				Message: "a range must have a first and last release, such as v23.1.0...v24.1.0"}
+		}
+		return []string{from, to}, true, nil
+	}
+
+	names = make([]string, 0)
+	for _, n := range strings.Split(expr, ",") {
+		if n = strings.TrimSpace(n); n != "" {
+			names = append(names, n)
+		}
+	}
+	if err := validateReleaseList(expr, names); err != nil {
+		return nil, false, err
+	}
+	return names, false, nil
+}
+
+func validateReleaseList(expr string, names []string) error {
+	if len(names) < 2 {
+		return &InvalidReleaseListError{Expression: expr, Message: "at least two releases must be compared"}
+	}
+	if len(names) > MaxCompareReleases {
+		return &InvalidReleaseListError{Expression: expr, Message: fmt.Sprintf(
+			"at most %d releases can be compared, got %d", MaxCompareReleases, len(names))}
+	}
+	return nil
+}
+
+// CompareMultipleReleaseSettings builds the value matrix for the releases, with the settings sorted by variable, and
+// compares each consecutive pair of releases. The settings are in the same order as the release names.
+func CompareMultipleReleaseSettings(names []string, rss []ReleaseSettings) MultiReleaseComparison {
+	values := make(map[string][]*string)
+	for i, rs := range rss {
+		for _, r := range rs {
+			if slices.Contains(IgnoredSettings, r.Variable) {
+				continue
+			}
+			if _, ok := values[r.Variable]; !ok {
+				values[r.Variable] = make([]*string, len(names))
+			}
+			if values[r.Variable][i] == nil { // the first value wins if a release has several
+				v := r.Value
+				values[r.Variable][i] = &v
+			}
+		}
+	}
+
+	matrix := make([]SettingValues, 0, len(values))
+	for variable, vs := range values {
+		matrix = append(matrix, SettingValues{Variable: variable, Values: vs})
+	}
+	sort.Slice(matrix, func(i, j int) bool {
+		return matrix[i].Variable < matrix[j].Variable
+	})
+
+	steps := make([]ComparisonStep, 0)
+	for i := 1; i < len(rss); i++ {
+		steps = append(steps, ComparisonStep{
+			From:    names[i-1],
+			To:      names[i],
+			Changes: CompareReleaseSettings(rss[i-1], rss[i]),
+		})
+	}
+
+	return MultiReleaseComparison{Releases: names, Matrix: matrix, Steps: steps}
 }
 
 func CompareReleaseSettings(rs1 ReleaseSettings, rs2 ReleaseSettings) ComparedReleaseSettings {
@@ -41,7 +173,7 @@ func CompareReleaseSettings(rs1 ReleaseSettings, rs2 ReleaseSettings) ComparedRe
 			continue
 		}
 		if r2, ok := rs2indexed[r1.Variable]; ok {
-			if r1.Value != r2.Value || cleanDescription(r1.Description) != cleanDescription(r2.Description) {
+			if r1.Value != r2.Value || textsim.Clean(r1.Description) != textsim.Clean(r2.Description) {
 				changed = append(changed, ChangedSetting{Before: r1, After: r2})
 				continue
 			}
@@ -58,13 +190,71 @@ func CompareReleaseSettings(rs1 ReleaseSettings, rs2 ReleaseSettings) ComparedRe
 		}
 	}
 
+	renamed, removed, added := detectRenames(removed, added)
+
 	return ComparedReleaseSettings{
 		Removed: removed,
 		Added:   added,
 		Changed: changed,
+		Renamed: renamed,
 	}
 }
 
-func cleanDescription(desc string) string {
-	return strings.Trim(strings.TrimSpace(desc), ".")
+// settingKey returns the key of a setting, which is the variable for releases from before settings had keys
+func settingKey(s ReleaseSetting) string {
+	if s.Key != "" {
+		return s.Key
+	}
+	return s.Variable
+}
+
+// detectRenames pairs removed settings with added settings of the same key, then pairs the remaining settings of
+// the same type by the most similar description. The settings that were not paired are returned as still removed
+// and added.
+func detectRenames(removed ReleaseSettings, added ReleaseSettings) (RenamedSettings, ReleaseSettings, ReleaseSettings) {
+	renamed := RenamedSettings{}
+	paired := make(map[int]bool) // indexes of added settings that have been paired
+	stillRemoved := ReleaseSettings{}
+
+	addedByKey := make(map[string]int)
+	for i, a := range added {
+		if _, ok := addedByKey[settingKey(a)]; !ok {
+			addedByKey[settingKey(a)] = i
+		}
+	}
+	unmatched := ReleaseSettings{}
+	for _, r := range removed {
+		if i, ok := addedByKey[settingKey(r)]; ok && !paired[i] {
+			paired[i] = true
+			renamed = append(renamed, RenamedSetting{Before: r, After: added[i], MatchedBy: RenameMatchedByKey})
+			continue
+		}
+		unmatched = append(unmatched, r)
+	}
+
+	for _, r := range unmatched {
+		best, bestSimilarity := -1, textsim.RenameThreshold
+		for i, a := range added {
+			if paired[i] || a.Type != r.Type {
+				continue
+			}
+			if s := textsim.Jaccard(r.Description, a.Description); s >= bestSimilarity {
+				best, bestSimilarity = i, s
+			}
+		}
+		if best < 0 {
+			stillRemoved = append(stillRemoved, r)
+			continue
+		}
+		paired[best] = true
+		renamed = append(renamed, RenamedSetting{Before: r, After: added[best], MatchedBy: RenameMatchedByDescription})
+	}
+
+	stillAdded := ReleaseSettings{}
+	for i, a := range added {
+		if !paired[i] {
+			stillAdded = append(stillAdded, a)
+		}
+	}
+	return renamed, stillRemoved, stillAdded
 }
@@ -0,0 +1,113 @@
+package settings
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReleaseList(t *testing.T) {
+	tests := []struct {
+		expr    string
+		names   []string
+		isRange bool
+		invalid bool
+	}{
+		{expr: "v22.2.0,v23.1.0, v23.2.0", names: []string{"v22.2.0", "v23.1.0", "v23.2.0"}},
+		{expr: "v23.1.0...v24.1.0", names: []string{"v23.1.0", "v24.1.0"}, isRange: true},
+		{expr: "v23.1.0", invalid: true},
+		{expr: "v23.1.0,", invalid: true},
+		{expr: "...v24.1.0", invalid: true},
+		{expr: "v23.1.0...v23.2.0...v24.1.0", invalid: true},
+		{expr: strings.Repeat("v23.1.0,", MaxCompareReleases+1), invalid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			names, isRange, err := ParseReleaseList(tt.expr)
+			if tt.invalid {
+				var invalid *InvalidReleaseListError
+				assert.ErrorAs(t, err, &invalid)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.names, names)
+			assert.Equal(t, tt.isRange, isRange)
+		})
+	}
+}
+
+func TestCompareMultipleReleaseSettings(t *testing.T) {
+	names := []string{"v22.2.0", "v23.1.0", "v23.2.0"}
+	rss := []ReleaseSettings{
+		{
+			{ReleaseName: "v22.2.0", Variable: "kv.old", Value: "true"},
+			{ReleaseName: "v22.2.0", Variable: "sql.rate", Value: "8"},
+			{ReleaseName: "v22.2.0", Variable: "cluster.secret", Value: "a"},
+		},
+		{
+			{ReleaseName: "v23.1.0", Variable: "sql.rate", Value: "16"},
+			{ReleaseName: "v23.1.0", Variable: "cluster.secret", Value: "b"},
+		},
+		{
+			{ReleaseName: "v23.2.0", Variable: "sql.rate", Value: "16"},
+			{ReleaseName: "v23.2.0", Variable: "kv.new", Value: "false"},
+		},
+	}
+
+	c := CompareMultipleReleaseSettings(names, rss)
+	assert.Equal(t, names, c.Releases)
+
+	str := func(s string) *string { return &s }
+	assert.Equal(t, []SettingValues{
+		{Variable: "kv.new", Values: []*string{nil, nil, str("false")}},
+		{Variable: "kv.old", Values: []*string{str("true"), nil, nil}},
+		{Variable: "sql.rate", Values: []*string{str("8"), str("16"), str("16")}},
+	}, c.Matrix)
+
+	assert.Len(t, c.Steps, 2)
+	assert.Equal(t, "v22.2.0", c.Steps[0].From)
+	assert.Equal(t, "v23.1.0", c.Steps[0].To)
+	assert.Len(t, c.Steps[0].Changes.Removed, 1)
+	assert.Len(t, c.Steps[0].Changes.Changed, 1)
+	assert.Empty(t, c.Steps[0].Changes.Added)
+	assert.Equal(t, "v23.2.0", c.Steps[1].To)
+	assert.Len(t, c.Steps[1].Changes.Added, 1)
+	assert.Empty(t, c.Steps[1].Changes.Changed)
+}
+
+func TestCompareReleaseSettingsRenamed(t *testing.T) {
+	rs1 := ReleaseSettings{
+		{Variable: "kv.old_name", Value: "1", Type: "i", Key: "kv.old_name"},
+		{Variable: "sql.stats.old", Value: "true", Type: "b", Description: "enables automatic table statistics"},
+		{Variable: "sql.removed", Value: "true", Type: "b", Description: "enables the removed feature"},
+		{Variable: "sql.retyped", Value: "1", Type: "i", Description: "the rate of retries"},
+	}
+	rs2 := ReleaseSettings{
+		{Variable: "kv.new_name", Value: "2", Type: "i", Key: "kv.old_name"},
+		{Variable: "sql.stats.new", Value: "true", Type: "b", Description: "Enables automatic table statistics."},
+		{Variable: "sql.added", Value: "false", Type: "b", Description: "enables a new feature"},
+		{Variable: "sql.retyped_new", Value: "1s", Type: "d", Description: "the rate of retries"},
+	}
+
+	c := CompareReleaseSettings(rs1, rs2)
+	assert.Len(t, c.Renamed, 2)
+	assert.Equal(t, "kv.old_name", c.Renamed[0].Before.Variable)
+	assert.Equal(t, "kv.new_name", c.Renamed[0].After.Variable)
+	assert.Equal(t, RenameMatchedByKey, c.Renamed[0].MatchedBy)
+	assert.Equal(t, "sql.stats.old", c.Renamed[1].Before.Variable)
+	assert.Equal(t, "sql.stats.new", c.Renamed[1].After.Variable)
+	assert.Equal(t, RenameMatchedByDescription, c.Renamed[1].MatchedBy)
+
+	// a similar description of another type is not a rename
+	assert.Equal(t, []string{"sql.removed", "sql.retyped"}, variables(c.Removed))
+	assert.Equal(t, []string{"sql.added", "sql.retyped_new"}, variables(c.Added))
+}
+
+func variables(rs ReleaseSettings) []string {
+	v := make([]string, len(rs))
+	for i, r := range rs {
+		v[i] = r.Variable
+	}
+	return v
+}
@@ -2,11 +2,11 @@ package settings
 
 type Provider interface {
 	GetRawSettings() (RawSettings, error)
-	GetSettingsSummary() (Summaries, error)
+	GetSettingSummaries() (Summaries, error)
 }
 
 type Persister interface {
 	SaveRawSettings(RawSettings) error
 	SaveSettingsSummaries(Summaries) error
-	SaveRun(string, int, int64) error
+	SaveRun(string, int, int64, int) error
 }
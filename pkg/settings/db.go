@@ -3,6 +3,8 @@ package settings
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jonstjohn/crdb-settings/pkg/dbpgx"
 	"time"
@@ -13,70 +15,23 @@ type Db struct {
 	Pool *pgxpool.Pool
 }
 
-/*
-type SettingsSummaryRow struct {
-	Name           string
-	Value              string
-	Type               string
-	Public             bool
-	Description        string
-	DefaultValue       string
-	Origin             string
-	Key                string
-	FirstReleases      []string
-	LastReleases       []string
-	ValueChanges       []settings.Change
-	DescriptionChanges []settings.Change
-}
-
-*/
-
-const CreateRawTable = `
-CREATE TABLE settings_raw (
-    release_name string,
-	cpu int,
-	memory_bytes int,
-	variable STRING NOT NULL,
-	value STRING NOT NULL,
-	type STRING NOT NULL,
-	public BOOL NOT NULL,
-	description STRING NOT NULL,
-	default_value STRING NOT NULL,
-	origin STRING NOT NULL,
-	key STRING NOT NULL,
-	updated TIMESTAMP NOT NULL DEFAULT now(),
-	PRIMARY KEY (release_name, variable, cpu, memory_bytes),
-	INDEX (variable, release_name)
-)
-`
-
 const UpsertRaw = `
 UPSERT INTO settings_raw (
 	release_name, cpu, memory_bytes,
-	variable, value, type,
-	public, description, default_value,
-	origin, key)
+	nodes, variable, value,
+	type, public, description,
+	default_value, origin, key)
 VALUES (
 	$1, $2, $3,
 	$4, $5, $6,
 	$7, $8, $9,
-	$10, $11
-)
-`
-
-const CreateSaveRunTable = `
-CREATE TABLE save_runs (
-    release_name string,
-	cpu int,
-	memory_bytes int,
-	updated TIMESTAMP NOT NULL DEFAULT now(),
-	PRIMARY KEY (release_name, cpu, memory_bytes)
+	$10, $11, $12
 )
 `
 
 const UpsertSaveRun = `
-UPSERT INTO save_runs (release_name, cpu, memory_bytes, updated)
-VALUES ($1, $2, $3, now())
+UPSERT INTO save_runs (release_name, cpu, memory_bytes, nodes, updated)
+VALUES ($1, $2, $3, $4, now())
 `
 
 const OrderedRawSettingsSql = `
@@ -84,6 +39,7 @@ SELECT
 	settings_raw.release_name,
 	settings_raw.cpu,
 	settings_raw.memory_bytes,
+	settings_raw.nodes,
 	settings_raw.variable,
 	settings_raw.value,
 	settings_raw.type,
@@ -98,69 +54,105 @@ FROM
 ORDER BY
 	settings_raw.variable, releases.major, releases.minor, releases.patch,
 	releases.beta_rc, releases.beta_rc_version, settings_raw.cpu,
-	settings_raw.memory_bytes
+	settings_raw.memory_bytes, settings_raw.nodes
 `
 
-const SelectSettingsForVersionSql = `
-SELECT DISTINCT
-	settings_raw.release_name,
-	settings_raw.variable,
-	settings_raw.value,
-	settings_raw.type,
-	settings_raw.public,
-	settings_raw.description,
-	settings_raw.default_value,
-	settings_raw.origin,
-	settings_raw.key
+const SelectRawSettingsForSettingSql = `
+SELECT
+	release_name,
+	cpu,
+	memory_bytes,
+	nodes,
+	variable,
+	value,
+	type,
+	public,
+	description,
+	default_value,
+	origin,
+	key,
+	updated
 FROM
 	settings_raw
 WHERE
-	settings_raw.release_name = $1
-ORDER BY public DESC, variable ASC
+	variable = $1
+ORDER BY release_name, cpu, memory_bytes, nodes
 `
 
-const CreateSummaryTable = `
-CREATE TABLE settings_summary (
-	variable STRING NOT NULL PRIMARY KEY,
-	value STRING NOT NULL,
-	type STRING NOT NULL,
-	public BOOL NOT NULL,
-	description STRING NOT NULL,
-	default_value STRING NOT NULL,
-	origin STRING NOT NULL,
-	key STRING NOT NULL,
-	first_releases STRING[] NULL,
-	last_releases STRING[] NULL,
-	value_changes JSONB NULL,
-	description_changes JSONB NULL)
+// SelectRawSettingsForSettingKeySql selects a setting under every name it has had, by matching the key of the
+// setting. Settings captured before keys were added use the variable as the key.
+const SelectRawSettingsForSettingKeySql = `
+WITH keys AS (
+	SELECT DISTINCT COALESCE(NULLIF(key, ''), variable) AS k FROM settings_raw WHERE variable = $1
+)
+SELECT
+	release_name,
+	cpu,
+	memory_bytes,
+	nodes,
+	variable,
+	value,
+	type,
+	public,
+	description,
+	default_value,
+	origin,
+	key,
+	updated
+FROM
+	settings_raw
+WHERE
+	variable = $1 OR COALESCE(NULLIF(key, ''), variable) IN (SELECT k FROM keys)
+ORDER BY release_name, cpu, memory_bytes, nodes
+`
+
+const SelectCapturedReleaseNamesSql = `
+SELECT DISTINCT release_name FROM settings_raw
 `
 
 const UpsertSummarySql = `
 UPSERT INTO settings_summary (
-	variable, value, type, 
+	variable, value, type,
 	public, description, default_value,
-    origin, key, first_releases,
-	last_releases, value_changes, description_changes)
+	origin, key, first_releases,
+	last_releases, host_dependent, value_changes,
+	description_changes)
 VALUES (
 	$1, $2, $3,
 	$4, $5, $6,
 	$7, $8, $9,
-	$10, $11, $12)
+	$10, $11, $12,
+	$13)
+`
+
+const DeleteSummariesSql = `
+DELETE FROM settings_summary WHERE true
 `
 
 const SelectSummarySql = `
-SELECT variable, value, type, 
+SELECT variable, value, type,
 	public, description, default_value,
-    origin, key, first_releases,
-	last_releases, value_changes, description_changes
+	origin, key, first_releases,
+	last_releases, host_dependent, value_changes,
+	description_changes
 FROM settings_summary
 ORDER BY variable
 `
 
+const SelectSummaryForSettingSql = `
+SELECT variable, value, type,
+	public, description, default_value,
+	origin, key, first_releases,
+	last_releases, host_dependent, value_changes,
+	description_changes
+FROM settings_summary
+WHERE variable = $1
+`
+
 const CountSaveRun = `
 SELECT count(*)
 FROM save_runs
-WHERE release_name = $1 AND cpu = $2 AND memory_bytes = $3
+WHERE release_name = $1 AND cpu = $2 AND memory_bytes = $3 AND nodes = $4
 `
 
 func NewDbDatasource(url string) (*Db, error) {
@@ -174,11 +166,23 @@ func NewDbDatasource(url string) (*Db, error) {
 	}, nil
 }
 
-func (db *Db) GetRawSettingsForVersion(version string) (RawSettings, error) {
-	rows, err := db.Pool.Query(context.Background(), SelectSettingsForVersionSql, version)
+// NewDbFromPool returns a datasource that uses an existing pool, which is owned and closed by the caller
+func NewDbFromPool(pool *pgxpool.Pool) *Db {
+	return &Db{Pool: pool}
+}
+
+// GetRawSettingsForVersion gets the settings for a release that match the list options, including one more setting
+// than the limit if there is a next page
+func (db *Db) GetRawSettingsForVersion(version string, opts ListOptions) (RawSettings, error) {
+	sql, args, err := listSettingsSql(version, opts)
 	if err != nil {
 		return nil, err
 	}
+	rows, err := db.Pool.Query(context.Background(), sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
 	sets := make([]RawSetting, 0)
 
@@ -192,8 +196,9 @@ func (db *Db) GetRawSettingsForVersion(version string) (RawSettings, error) {
 		var defaultValue string
 		var origin string
 		var key string
+		var sortKey string
 		err := rows.Scan(&releaseName, &variable, &value, &typ, &public,
-			&description, &defaultValue, &origin, &key)
+			&description, &defaultValue, &origin, &key, &sortKey)
 		if err != nil {
 			return nil, err
 		}
@@ -210,7 +215,7 @@ func (db *Db) GetRawSettingsForVersion(version string) (RawSettings, error) {
 		})
 	}
 
-	return sets, nil
+	return sets, rows.Err()
 }
 
 func (db *Db) GetRawSettings() (RawSettings, error) {
@@ -219,6 +224,7 @@ func (db *Db) GetRawSettings() (RawSettings, error) {
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	sets := make([]RawSetting, 0)
 
@@ -226,6 +232,7 @@ func (db *Db) GetRawSettings() (RawSettings, error) {
 		var releaseName string
 		var cpu int
 		var memoryBytes int64
+		var nodes int
 		var variable string
 		var value string
 		var typ string
@@ -235,7 +242,7 @@ func (db *Db) GetRawSettings() (RawSettings, error) {
 		var origin string
 		var key string
 		var updated time.Time
-		err := rows.Scan(&releaseName, &cpu, &memoryBytes, &variable, &value, &typ, &public,
+		err := rows.Scan(&releaseName, &cpu, &memoryBytes, &nodes, &variable, &value, &typ, &public,
 			&description, &defaultValue, &origin, &key, &updated)
 		if err != nil {
 			return nil, err
@@ -244,6 +251,7 @@ func (db *Db) GetRawSettings() (RawSettings, error) {
 			ReleaseName:  releaseName,
 			Cpu:          cpu,
 			MemoryBytes:  memoryBytes,
+			Nodes:        nodes,
 			Variable:     variable,
 			Value:        value,
 			Type:         typ,
@@ -256,62 +264,119 @@ func (db *Db) GetRawSettings() (RawSettings, error) {
 		})
 	}
 
-	return sets, nil
+	return sets, rows.Err()
 }
 
-/*
-func (db *Db) GetSettingSummaries() (settings.Summaries, error) {
+// GetRawSettingsForSetting gets the raw settings for a single setting across all releases and host sizes
+func (db *Db) GetRawSettingsForSetting(setting string) (RawSettings, error) {
+	return db.getRawSettings(SelectRawSettingsForSettingSql, setting)
+}
+
+// GetRawSettingsForSettingKey gets a setting for every release, including releases where it had another name
+func (db *Db) GetRawSettingsForSettingKey(setting string) (RawSettings, error) {
+	return db.getRawSettings(SelectRawSettingsForSettingKeySql, setting)
+}
+
+func (db *Db) getRawSettings(sql string, setting string) (RawSettings, error) {
+	rows, err := db.Pool.Query(context.Background(), sql, setting)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sets := make([]RawSetting, 0)
+
+	for rows.Next() {
+		var r RawSetting
+		err := rows.Scan(&r.ReleaseName, &r.Cpu, &r.MemoryBytes, &r.Nodes, &r.Variable, &r.Value, &r.Type, &r.Public,
+			&r.Description, &r.DefaultValue, &r.Origin, &r.Key, &r.Updated)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, r)
+	}
+
+	return sets, rows.Err()
+}
+
+// GetCapturedReleaseNames gets the names of all releases that have had settings captured
+func (db *Db) GetCapturedReleaseNames() ([]string, error) {
+	rows, err := db.Pool.Query(context.Background(), SelectCapturedReleaseNamesSql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+func (db *Db) GetSettingSummaries() (Summaries, error) {
 	rows, err := db.Pool.Query(context.Background(), SelectSummarySql)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	summaries := make([]settings.Summary, 0)
+	summaries := make(Summaries, 0)
 
 	for rows.Next() {
-		var variable string
-		var value string
-		var typ string
-		var public bool
-		var description string
-		var defaultValue string
-		var origin string
-		var key string
-		var firstReleases []string
-		var lastReleases []string
-		var valueChanges []settings.Change
-		var descriptionChanges []settings.Change
-
-		err := rows.Scan(&variable, &value, &typ, &public,
-			&description, &defaultValue, &origin, &key,
-			&firstReleases, &lastReleases,
-			&value, &descriptionChanges)
+		summary, err := scanSummary(rows)
 		if err != nil {
 			return nil, err
 		}
-		sets = append(sets, settings.RawSetting{
-			ReleaseName:  releaseName,
-			Cpu:          cpu,
-			MemoryBytes:  memoryBytes,
-			Name:     variable,
-			Value:        value,
-			Type:         typ,
-			Public:       public,
-			Description:  description,
-			DefaultValue: defaultValue,
-			Origin:       origin,
-			Key:          key,
-		})
+		summaries = append(summaries, summary)
 	}
-	return nil
+	return summaries, rows.Err()
 }
 
-*/
+// GetSettingSummary gets the summary for a single setting, returning nil if the setting has not been summarized
+func (db *Db) GetSettingSummary(setting string) (*Summary, error) {
+	summary, err := scanSummary(db.Pool.QueryRow(context.Background(), SelectSummaryForSettingSql, setting))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
 
+func scanSummary(row pgx.Row) (Summary, error) {
+	var s Summary
+	var valueChanges []Change
+	var descriptionChanges []Change
+	err := row.Scan(&s.Variable, &s.Value, &s.Type,
+		&s.Public, &s.Description, &s.DefaultValue,
+		&s.Origin, &s.Key, &s.FirstReleases,
+		&s.LastReleases, &s.HostDependent, &valueChanges,
+		&descriptionChanges)
+	if err != nil {
+		return s, err
+	}
+	s.ValueChanges = valueChanges
+	s.DescriptionChanges = descriptionChanges
+	return s, nil
+}
+
+// SaveRawSettings saves raw settings in a single transaction
 func (db *Db) SaveRawSettings(rs RawSettings) error {
+	return pgx.BeginFunc(context.Background(), db.Pool, func(tx pgx.Tx) error {
+		return SaveRawSettingsTx(tx, rs)
+	})
+}
+
+// SaveRawSettingsTx saves raw settings in an existing transaction, so that they can be saved with other artifacts
+func SaveRawSettingsTx(tx pgx.Tx, rs RawSettings) error {
 	for _, r := range rs {
-		err := db.upsertRawSetting(r)
-		if err != nil {
+		if err := upsertRawSetting(tx, r); err != nil {
 			return err
 		}
 	}
@@ -319,62 +384,79 @@ func (db *Db) SaveRawSettings(rs RawSettings) error {
 }
 
 func (db *Db) SaveSettingsSummaries(ss Summaries) error {
-	for _, s := range ss {
-		err := db.upsertSummary(s)
-		if err != nil {
-			return err
+	return pgx.BeginFunc(context.Background(), db.Pool, func(tx pgx.Tx) error {
+		for _, s := range ss {
+			if err := upsertSummary(tx, s); err != nil {
+				return err
+			}
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
-func (db *Db) createRawTable() error {
-	_, err := db.Pool.Exec(context.Background(), CreateRawTable)
-	return err
+// ReplaceSettingsSummaries rebuilds the summary table in a single transaction, removing summaries that are no
+// longer present
+func (db *Db) ReplaceSettingsSummaries(ss Summaries) error {
+	return pgx.BeginFunc(context.Background(), db.Pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(context.Background(), DeleteSummariesSql); err != nil {
+			return err
+		}
+		for _, s := range ss {
+			if err := upsertSummary(tx, s); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-func (db *Db) upsertRawSetting(r RawSetting) error {
-	_, err := db.Pool.Exec(context.Background(), UpsertRaw,
+func upsertRawSetting(tx pgx.Tx, r RawSetting) error {
+	_, err := tx.Exec(context.Background(), UpsertRaw,
 		r.ReleaseName, r.Cpu, r.MemoryBytes,
-		r.Variable, r.Value, r.Type,
-		r.Public, r.Description, r.DefaultValue,
-		r.Origin, r.Key,
+		r.Nodes, r.Variable, r.Value,
+		r.Type, r.Public, r.Description,
+		r.DefaultValue, r.Origin, r.Key,
 	)
 	return err
 }
 
-func (db *Db) createSaveRunTable() error {
-	_, err := db.Pool.Exec(context.Background(), CreateSaveRunTable)
-	return err
-}
-
-func (db *Db) SaveRunExists(releaseName string, cpu int, memoryBytes int64) (bool, error) {
+func (db *Db) SaveRunExists(releaseName string, cpu int, memoryBytes int64, nodes int) (bool, error) {
 	var cnt int
-	err := db.Pool.QueryRow(context.Background(), CountSaveRun, releaseName, cpu, memoryBytes).Scan(&cnt)
+	err := db.Pool.QueryRow(context.Background(), CountSaveRun, releaseName, cpu, memoryBytes, nodes).Scan(&cnt)
 	if err != nil {
 		return false, err
 	}
 	return cnt > 0, nil
 }
 
-func (db *Db) SaveRun(release string, cpu int, memory int64) error {
+func (db *Db) SaveRun(release string, cpu int, memory int64, nodes int) error {
 	_, err := db.Pool.Exec(context.Background(), UpsertSaveRun,
-		release, cpu, memory)
+		release, cpu, memory, nodes)
+	return err
+}
+
+// SaveRunTx records a save run in an existing transaction, so that it is saved with the settings of the run
+func SaveRunTx(tx pgx.Tx, release string, cpu int, memory int64, nodes int) error {
+	_, err := tx.Exec(context.Background(), UpsertSaveRun, release, cpu, memory, nodes)
 	return err
 }
 
-func (db *Db) upsertSummary(summary Summary) error {
+func upsertSummary(tx pgx.Tx, summary Summary) error {
 
 	valueChangesB, err := json.Marshal(summary.ValueChanges)
+	if err != nil {
+		return err
+	}
 	descriptionChangesB, err := json.Marshal(summary.DescriptionChanges)
 	if err != nil {
 		return err
 	}
-	_, err = db.Pool.Exec(context.Background(), UpsertSummarySql,
+	_, err = tx.Exec(context.Background(), UpsertSummarySql,
 		summary.Variable, summary.Value, summary.Type,
 		summary.Public, summary.Description, summary.DefaultValue,
 		summary.Origin, summary.Key, summary.FirstReleases,
-		summary.LastReleases, valueChangesB, descriptionChangesB)
+		summary.LastReleases, summary.HostDependent, valueChangesB,
+		descriptionChangesB)
 	return err
 }
 
@@ -390,6 +472,7 @@ ORDER BY major DESC, minor DESC, patch DESC, beta_rc DESC, beta_rc_version DESC
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	var releaseNames []string
 
@@ -401,7 +484,7 @@ ORDER BY major DESC, minor DESC, patch DESC, beta_rc DESC, beta_rc_version DESC
 		}
 		releaseNames = append(releaseNames, releaseName)
 	}
-	return releaseNames, nil
+	return releaseNames, rows.Err()
 }
 
 func (db *Db) GetRecentDescriptionForSetting(setting string) (string, error) {
@@ -416,6 +499,7 @@ LIMIT 1
 	if err != nil {
 		return "", err
 	}
+	defer rows.Close()
 
 	var description string
 
@@ -425,7 +509,7 @@ LIMIT 1
 			return "", err
 		}
 	}
-	return description, nil
+	return description, rows.Err()
 }
 
 /*
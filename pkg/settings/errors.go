@@ -0,0 +1,12 @@
+package settings
+
+import "fmt"
+
+// UnknownSettingError is returned when a setting has not been captured for any release
+type UnknownSettingError struct {
+	Variable string
+}
+
+func (e *UnknownSettingError) Error() string {
+	return fmt.Sprintf("unknown setting '%s'", e.Variable)
+}
@@ -1,24 +1,142 @@
 package settings
 
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jonstjohn/crdb-settings/pkg/releases"
+	"github.com/jonstjohn/crdb-settings/pkg/textsim"
+)
+
 type SettingChangeType int
 
 const (
 	FirstSeen SettingChangeType = iota
 	LastSeen
 	Changed
+	Renamed
 )
 
+func (t SettingChangeType) String() string {
+	switch t {
+	case FirstSeen:
+		return "first_seen"
+	case LastSeen:
+		return "last_seen"
+	case Changed:
+		return "changed"
+	case Renamed:
+		return "renamed"
+	}
+	return fmt.Sprintf("unknown(%d)", int(t))
+}
+
+func (t SettingChangeType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
 type SettingHistory struct {
-	Change SettingHistoryChange
+	Variable string                 `json:"variable"`
+	Changes  []SettingHistoryChange `json:"changes"`
 }
 
+// SettingHistoryChange is a single entry in the timeline of a setting. FirstSeen entries only have an After setting
+// and LastSeen entries only have a Before setting, where ToRelease is the first release the setting was missing from.
 type SettingHistoryChange struct {
-	FromRelease string
-	ToRelease   string
-	Before      *ReleaseSetting
-	After       *ReleaseSetting
+	Type        SettingChangeType `json:"type"`
+	FromRelease string            `json:"from_release"`
+	ToRelease   string            `json:"to_release"`
+	Fields      []string          `json:"fields"`
+	Before      *ReleaseSetting   `json:"before"`
+	After       *ReleaseSetting   `json:"after"`
+}
+
+// GenerateSettingHistory builds a chronological timeline for a single setting. The settings are the values of the
+// setting for each release it appears in, including under other names with the same key, and rels is every release
+// that settings have been captured for, which is needed to find the release where the setting disappeared. Releases
+// are ordered by version, not release date.
+func GenerateSettingHistory(variable string, settings ReleaseSettings, rels releases.Releases) (SettingHistory, error) {
+	h := SettingHistory{Variable: variable, Changes: make([]SettingHistoryChange, 0)}
+
+	// Index settings by release, preferring the requested name and otherwise keeping the first setting if there is
+	// more than one for a release
+	byRelease := make(map[string]ReleaseSetting)
+	for _, s := range settings {
+		if rels.GetReleaseForName(s.ReleaseName) == nil {
+			return h, fmt.Errorf("release '%s' for setting '%s' not found", s.ReleaseName, variable)
+		}
+		if existing, ok := byRelease[s.ReleaseName]; !ok || (existing.Variable != variable && s.Variable == variable) {
+			byRelease[s.ReleaseName] = s
+		}
+	}
+
+	ordered := make(releases.Releases, len(rels))
+	copy(ordered, rels)
+	ordered.SortBy(releases.SortByVersion)
+
+	var previous *ReleaseSetting
+	for _, r := range ordered {
+		current, ok := byRelease[r.Name]
+
+		switch {
+		case ok && previous == nil: // appeared (or re-appeared) in this release
+			h.Changes = append(h.Changes, SettingHistoryChange{
+				Type:      FirstSeen,
+				ToRelease: r.Name,
+				After:     &current,
+			})
+		case !ok && previous != nil: // disappeared in this release
+			h.Changes = append(h.Changes, SettingHistoryChange{
+				Type:        LastSeen,
+				FromRelease: previous.ReleaseName,
+				ToRelease:   r.Name,
+				Before:      previous,
+			})
+		case ok && previous != nil && previous.Variable != current.Variable:
+			h.Changes = append(h.Changes, SettingHistoryChange{
+				Type:        Renamed,
+				FromRelease: previous.ReleaseName,
+				ToRelease:   r.Name,
+				Fields:      append([]string{"variable"}, changedFields(*previous, current)...),
+				Before:      previous,
+				After:       &current,
+			})
+		case ok && previous != nil:
+			if fields := changedFields(*previous, current); len(fields) > 0 {
+				h.Changes = append(h.Changes, SettingHistoryChange{
+					Type:        Changed,
+					FromRelease: previous.ReleaseName,
+					ToRelease:   r.Name,
+					Fields:      fields,
+					Before:      previous,
+					After:       &current,
+				})
+			}
+		}
+
+		if ok {
+			previous = &current
+		} else {
+			previous = nil
+		}
+	}
+
+	return h, nil
 }
 
-func GenerateSettingHistory(settings ReleaseSettings) (SettingHistory, error) {
-	return SettingHistory{}, nil
+// changedFields returns the names of the fields that differ between two versions of the same setting
+func changedFields(before ReleaseSetting, after ReleaseSetting) []string {
+	fields := make([]string, 0)
+	if before.Value != after.Value {
+		fields = append(fields, "value")
+	}
+	if before.Type != after.Type {
+		fields = append(fields, "type")
+	}
+	if before.Public != after.Public {
+		fields = append(fields, "public")
+	}
+	if textsim.Clean(before.Description) != textsim.Clean(after.Description) {
+		fields = append(fields, "description")
+	}
+	return fields
 }
@@ -0,0 +1,92 @@
+package settings
+
+import (
+	"github.com/jonstjohn/crdb-settings/pkg/releases"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGenerateSettingHistory(t *testing.T) {
+	rels, err := releasesFromFile()
+	assert.Nil(t, err)
+
+	all := releases.Releases(rels)
+	captured := all.FilterForNames(
+		[]string{"v22.1.9", "v22.1.10", "v22.2.0", "v23.1.0", "v23.1.15", "v23.2.0"})
+
+	variable := "kv.snapshot_recovery.max_rate"
+	s := ReleaseSettings{
+		// out of order on purpose, history should be ordered by version
+		{ReleaseName: "v22.2.0", Variable: variable, Value: "32 MiB", Type: "z", Public: true, Description: "the rate limit"},
+		{ReleaseName: "v22.1.9", Variable: variable, Value: "8.0 MiB", Type: "z", Public: true, Description: "the rate limit"},
+		{ReleaseName: "v22.1.10", Variable: variable, Value: "32 MiB", Type: "z", Public: true, Description: "the rate limit."},
+		{ReleaseName: "v23.1.0", Variable: variable, Value: "32 MiB", Type: "z", Public: false, Description: "the new rate limit"},
+		{ReleaseName: "v23.1.15", Variable: variable, Value: "32 MiB", Type: "z", Public: false, Description: "the new rate limit"},
+	}
+
+	h, err := GenerateSettingHistory(variable, s, captured)
+	assert.Nil(t, err)
+	assert.Equal(t, variable, h.Variable)
+	assert.Equal(t, 4, len(h.Changes))
+
+	assert.Equal(t, FirstSeen, h.Changes[0].Type)
+	assert.Equal(t, "v22.1.9", h.Changes[0].ToRelease)
+	assert.Nil(t, h.Changes[0].Before)
+
+	// The trailing period on the description is not a change
+	assert.Equal(t, Changed, h.Changes[1].Type)
+	assert.Equal(t, "v22.1.9", h.Changes[1].FromRelease)
+	assert.Equal(t, "v22.1.10", h.Changes[1].ToRelease)
+	assert.Equal(t, []string{"value"}, h.Changes[1].Fields)
+
+	assert.Equal(t, Changed, h.Changes[2].Type)
+	assert.Equal(t, "v22.2.0", h.Changes[2].FromRelease)
+	assert.Equal(t, "v23.1.0", h.Changes[2].ToRelease)
+	assert.Equal(t, []string{"public", "description"}, h.Changes[2].Fields)
+
+	assert.Equal(t, LastSeen, h.Changes[3].Type)
+	assert.Equal(t, "v23.1.15", h.Changes[3].FromRelease)
+	assert.Equal(t, "v23.2.0", h.Changes[3].ToRelease)
+	assert.Nil(t, h.Changes[3].After)
+}
+
+func TestGenerateSettingHistoryUnknownRelease(t *testing.T) {
+	rels, err := releasesFromFile()
+	assert.Nil(t, err)
+
+	s := ReleaseSettings{{ReleaseName: "v99.1.0", Variable: "sql.defaults.distsql"}}
+	_, err = GenerateSettingHistory("sql.defaults.distsql", s, rels)
+	assert.Error(t, err)
+}
+
+func TestGenerateSettingHistoryRenamed(t *testing.T) {
+	rels, err := releasesFromFile()
+	assert.Nil(t, err)
+
+	all := releases.Releases(rels)
+	captured := all.FilterForNames([]string{"v22.2.0", "v23.1.0", "v23.2.0"})
+
+	s := ReleaseSettings{
+		{ReleaseName: "v22.2.0", Variable: "kv.old_name", Value: "1", Type: "i", Key: "kv.old_name"},
+		{ReleaseName: "v23.1.0", Variable: "kv.new_name", Value: "1", Type: "i", Key: "kv.old_name"},
+		{ReleaseName: "v23.2.0", Variable: "kv.new_name", Value: "2", Type: "i", Key: "kv.old_name"},
+		// an alias of the old name in the same release is ignored in favour of the requested name
+		{ReleaseName: "v23.2.0", Variable: "kv.old_name", Value: "2", Type: "i", Key: "kv.old_name"},
+	}
+
+	h, err := GenerateSettingHistory("kv.new_name", s, captured)
+	assert.Nil(t, err)
+	assert.Len(t, h.Changes, 3)
+
+	assert.Equal(t, FirstSeen, h.Changes[0].Type)
+	assert.Equal(t, "kv.old_name", h.Changes[0].After.Variable)
+
+	assert.Equal(t, Renamed, h.Changes[1].Type)
+	assert.Equal(t, "v22.2.0", h.Changes[1].FromRelease)
+	assert.Equal(t, "v23.1.0", h.Changes[1].ToRelease)
+	assert.Equal(t, []string{"variable"}, h.Changes[1].Fields)
+	assert.Equal(t, "kv.new_name", h.Changes[1].After.Variable)
+
+	assert.Equal(t, Changed, h.Changes[2].Type)
+	assert.Equal(t, []string{"value"}, h.Changes[2].Fields)
+}
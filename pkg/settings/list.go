@@ -0,0 +1,200 @@
+package settings
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MaxListLimit is the largest page size for listing settings
+const MaxListLimit = 1000
+
+// listSortKeys are the SQL expressions for each sort field. Keys are strings so that the cursor can hold any of
+// them, and the public key sorts public settings first.
+var listSortKeys = map[string]string{
+	"public":   "(NOT settings_raw.public)::STRING",
+	"variable": "settings_raw.variable",
+	"type":     "settings_raw.type",
+	"value":    "settings_raw.value",
+}
+
+// ListOptions filters, sorts and pages the settings of a release. The zero value lists every setting with public
+// settings first, then by variable.
+type ListOptions struct {
+	Public      *bool  // only public or non-public settings
+	Type        string // setting type, such as 'b' or 'd'
+	Variable    string // variable prefix, or a glob such as 'kv.rangefeed.*' when it contains '*' or '?'
+	Description string // case-insensitive description substring
+	Sort        string // public (default), variable, type or value
+	Desc        bool   // reverse the sort order
+	Limit       int    // maximum number of settings, or 0 for all
+	Next        string // cursor from a previous page
+}
+
+// SettingsPage is a page of settings, where Next is the cursor for the next page if there are more settings
+type SettingsPage struct {
+	Settings ReleaseSettings `json:"settings"`
+	Next     string          `json:"next,omitempty"`
+}
+
+// InvalidListOptionError is returned for list options that cannot be used, such as an unknown sort field
+type InvalidListOptionError struct {
+	Option  string
+	Message string
+}
+
+func (e *InvalidListOptionError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Option, e.Message)
+}
+
+// listCursor is the position after the last setting of a page, encoded in the next token
+type listCursor struct {
+	Sort     string `json:"s"`
+	Key      string `json:"k"`
+	Variable string `json:"v"`
+	Value    string `json:"x"`
+}
+
+func (o ListOptions) sortField() string {
+	if o.Sort == "" {
+		return "public"
+	}
+	return o.Sort
+}
+
+func (o ListOptions) Validate() error {
+	if _, ok := listSortKeys[o.sortField()]; !ok {
+		return &InvalidListOptionError{Option: "sort", Message: fmt.Sprintf(
+			"'%s' is not one of public, variable, type or value", o.Sort)}
+	}
+	if o.Limit < 0 || o.Limit > MaxListLimit {
+		return &InvalidListOptionError{Option: "limit", Message: fmt.Sprintf(
+			"must be between 0 and %d, got %d", MaxListLimit, o.Limit)}
+	}
+	if _, err := o.cursor(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// cursor decodes the next token, returning nil for the first page
+func (o ListOptions) cursor() (*listCursor, error) {
+	if o.Next == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(o.Next)
+	if err != nil {
+		return nil, &InvalidListOptionError{Option: "next", Message: "malformed token"}
+	}
+	var c listCursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, &InvalidListOptionError{Option: "next", Message: "malformed token"}
+	}
+	if c.Sort != o.sortField() {
+		return nil, &InvalidListOptionError{Option: "next", Message: "token was created with a different sort"}
+	}
+	return &c, nil
+}
+
+// nextToken returns the token for the page after a setting
+func (o ListOptions) nextToken(s RawSetting) string {
+	c := listCursor{Sort: o.sortField(), Variable: s.Variable, Value: s.Value}
+	switch c.Sort {
+	case "public":
+		c.Key = fmt.Sprintf("%t", !s.Public)
+	case "variable":
+		c.Key = s.Variable
+	case "type":
+		c.Key = s.Type
+	case "value":
+		c.Key = s.Value
+	}
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// listCaptureSql selects the capture of the release that settings are listed from
+const listCaptureSql = `(settings_raw.cpu, settings_raw.memory_bytes, settings_raw.nodes) = (
+		SELECT cpu, memory_bytes, nodes FROM settings_raw WHERE release_name = $1
+		ORDER BY nodes ASC, cpu DESC, memory_bytes DESC LIMIT 1
+	)`
+
+// listSettingsSql builds the query for a page of settings. Pages use the sort key with the variable and value as
+// tie breakers, so that settings are never skipped or repeated between pages. One more row than the limit is
+// selected to find out whether there is a next page. A release may be captured with several host shapes and node
+// counts, so only the settings of a single capture are listed: a single node on the largest host shape.
+func listSettingsSql(version string, o ListOptions) (string, []any, error) {
+	if err := o.Validate(); err != nil {
+		return "", nil, err
+	}
+	key := listSortKeys[o.sortField()]
+
+	args := []any{version}
+	where := []string{"settings_raw.release_name = $1", listCaptureSql}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if o.Public != nil {
+		where = append(where, "settings_raw.public = "+arg(*o.Public))
+	}
+	if o.Type != "" {
+		where = append(where, "settings_raw.type = "+arg(o.Type))
+	}
+	if o.Variable != "" {
+		where = append(where, "settings_raw.variable LIKE "+arg(variableToLike(o.Variable)))
+	}
+	if o.Description != "" {
+		where = append(where, "settings_raw.description ILIKE "+arg("%"+escapeLike(o.Description)+"%"))
+	}
+
+	dir, cmp := "ASC", ">"
+	if o.Desc {
+		dir, cmp = "DESC", "<"
+	}
+	c, _ := o.cursor()
+	if c != nil {
+		where = append(where, fmt.Sprintf("(%s, settings_raw.variable, settings_raw.value) %s (%s, %s, %s)",
+			key, cmp, arg(c.Key), arg(c.Variable), arg(c.Value)))
+	}
+
+	sql := fmt.Sprintf(`
+SELECT
+	settings_raw.release_name,
+	settings_raw.variable,
+	settings_raw.value,
+	settings_raw.type,
+	settings_raw.public,
+	settings_raw.description,
+	settings_raw.default_value,
+	settings_raw.origin,
+	settings_raw.key,
+	%s AS sort_key
+FROM
+	settings_raw
+WHERE
+	%s
+ORDER BY sort_key %s, settings_raw.variable %s, settings_raw.value %s
+`, key, strings.Join(where, " AND\n\t"), dir, dir, dir)
+
+	if o.Limit > 0 {
+		sql += "LIMIT " + arg(o.Limit+1) + "\n"
+	}
+	return sql, args, nil
+}
+
+// variableToLike converts a variable prefix or glob to a LIKE pattern
+func variableToLike(variable string) string {
+	escaped := escapeLike(variable)
+	if !strings.ContainsAny(variable, "*?") {
+		return escaped + "%"
+	}
+	return strings.NewReplacer("*", "%", "?", "_").Replace(escaped)
+}
+
+// escapeLike escapes the LIKE wildcards so they match literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
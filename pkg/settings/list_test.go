@@ -0,0 +1,67 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVariableToLike(t *testing.T) {
+	assert.Equal(t, "kv.rangefeed.%", variableToLike("kv.rangefeed.*"))
+	assert.Equal(t, "kv.rangefeed%", variableToLike("kv.rangefeed"))
+	assert.Equal(t, "sql._.enabled", variableToLike("sql.?.enabled"))
+	assert.Equal(t, `sql.stats.\_%`, variableToLike("sql.stats._"))
+}
+
+func TestListOptions_Validate(t *testing.T) {
+	assert.NoError(t, ListOptions{}.Validate())
+	assert.NoError(t, ListOptions{Sort: "value", Desc: true, Limit: 50}.Validate())
+
+	for _, o := range []ListOptions{
+		{Sort: "color"},
+		{Limit: -1},
+		{Limit: MaxListLimit + 1},
+		{Next: "not a token"},
+	} {
+		err := o.Validate()
+		var invalid *InvalidListOptionError
+		assert.ErrorAs(t, err, &invalid)
+	}
+}
+
+func TestListOptions_NextToken(t *testing.T) {
+	o := ListOptions{Sort: "type", Limit: 10}
+	o.Next = o.nextToken(RawSetting{Variable: "kv.rangefeed.enabled", Type: "b", Value: "false"})
+
+	c, err := o.cursor()
+	assert.NoError(t, err)
+	assert.Equal(t, &listCursor{Sort: "type", Key: "b", Variable: "kv.rangefeed.enabled", Value: "false"}, c)
+
+	// A token can only be used with the sort it was created for
+	o.Sort = "variable"
+	assert.Error(t, o.Validate())
+}
+
+func TestListSettingsSql(t *testing.T) {
+	public := true
+	o := ListOptions{Public: &public, Type: "b", Variable: "kv.*", Description: "100%", Limit: 20}
+	o.Next = o.nextToken(RawSetting{Variable: "kv.a", Value: "x", Public: true})
+
+	sql, args, err := listSettingsSql("v23.2.10", o)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{"v23.2.10", true, "b", "kv.%", `%100\%%`, "false", "kv.a", "x", 21}, args)
+	assert.Contains(t, sql, listCaptureSql)
+	assert.Contains(t, sql, "settings_raw.public = $2")
+	assert.Contains(t, sql, "settings_raw.type = $3")
+	assert.Contains(t, sql, "settings_raw.variable LIKE $4")
+	assert.Contains(t, sql, "settings_raw.description ILIKE $5")
+	assert.Contains(t, sql, "((NOT settings_raw.public)::STRING, settings_raw.variable, settings_raw.value) > ($6, $7, $8)")
+	assert.Contains(t, sql, "ORDER BY sort_key ASC, settings_raw.variable ASC, settings_raw.value ASC")
+	assert.Contains(t, sql, "LIMIT $9")
+
+	sql, args, err = listSettingsSql("v23.2.10", ListOptions{Sort: "variable", Desc: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{"v23.2.10"}, args)
+	assert.Contains(t, sql, "ORDER BY sort_key DESC, settings_raw.variable DESC, settings_raw.value DESC")
+	assert.NotContains(t, sql, "LIMIT $")
+}
@@ -1,18 +1,25 @@
 package settings
 
 import (
+	"context"
 	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jonstjohn/crdb-settings/pkg/crdbcluster"
+	"github.com/jonstjohn/crdb-settings/pkg/dbpgx"
 	"github.com/jonstjohn/crdb-settings/pkg/gh"
 	"github.com/jonstjohn/crdb-settings/pkg/host"
 	"github.com/jonstjohn/crdb-settings/pkg/releases"
 	"github.com/sirupsen/logrus"
 	"math"
+	"slices"
 	"strconv"
 	"strings"
 )
 
 type Manager struct {
-	Db *Db
+	Db          *Db
+	Binaries    crdbcluster.BinaryProvider // optional, cockroach binaries used to capture settings
+	Parallelism int                        // number of releases captured at once, one if not set
 }
 
 func NewSettingsManager(url string) (*Manager, error) {
@@ -24,57 +31,124 @@ func NewSettingsManager(url string) (*Manager, error) {
 	return &Manager{Db: db}, err
 }
 
+// NewSettingsManagerFromPool returns a manager that uses an existing pool, which is owned and closed by the caller
+func NewSettingsManagerFromPool(pool *pgxpool.Pool) *Manager {
+	return &Manager{Db: NewDbFromPool(pool)}
+}
+
+// GetSettingsForRelease gets all the settings captured for a release, returning a releases.UnknownReleaseError if
+// the release does not exist
 func (sm *Manager) GetSettingsForRelease(version string) (ReleaseSettings, error) {
-	raws, err := sm.Db.GetRawSettingsForVersion(version)
-	s := make(ReleaseSettings, len(raws))
+	page, err := sm.ListSettingsForRelease(version, ListOptions{})
+	return page.Settings, err
+}
+
+// ListSettingsForRelease gets a filtered and sorted page of the settings captured for a release. An
+// InvalidListOptionError is returned for options that cannot be used and a releases.UnknownReleaseError if the
+// release does not exist.
+func (sm *Manager) ListSettingsForRelease(version string, opts ListOptions) (SettingsPage, error) {
+	page := SettingsPage{Settings: make(ReleaseSettings, 0)}
+	if err := opts.Validate(); err != nil {
+		return page, err
+	}
+
+	rm := releases.NewReleasesManagerFromPool(sm.Db.Pool)
+	if _, err := rm.GetRelease(version); err != nil {
+		return page, err
+	}
+
+	raws, err := sm.Db.GetRawSettingsForVersion(version, opts)
 	if err != nil {
-		return s, err // TODO
+		return page, err
+	}
+	if opts.Limit > 0 && len(raws) > opts.Limit {
+		raws = raws[:opts.Limit]
+		page.Next = opts.nextToken(raws[len(raws)-1])
 	}
-	for i, raw := range raws {
-		s[i] = ReleaseSetting{
+
+	for _, raw := range raws {
+		page.Settings = append(page.Settings, ReleaseSetting{
 			ReleaseName: raw.ReleaseName,
 			Variable:    raw.Variable,
 			Value:       raw.Value,
 			Type:        raw.Type,
 			Public:      raw.Public,
 			Description: raw.Description,
-		}
+			Key:         raw.Key,
+		})
 	}
-	return s, nil
+	return page, nil
 }
 
 // SaveClusterSettingsForVersion saves all the cluster settings for a specific CRDB version, but only
-// if the combination of release, cpu and memory has not been previously run - otherwise it bails early.
-func (sm *Manager) SaveClusterSettingsForVersion(release string, url string) error {
+// if the combination of release, cpu, memory and nodes has not been previously run - otherwise it bails early.
+// Settings are captured once for each host shape, or once for the current host if no shapes are provided, using a
+// test cluster with the given number of nodes. Releases are captured by Parallelism workers, and a release that
+// fails does not stop the others; a crdbcluster.CaptureError lists the releases that failed.
+func (sm *Manager) SaveClusterSettingsForVersion(ctx context.Context, release string, url string, shapes []host.Shape, nodes int) error {
+	if _, err := crdbcluster.NodesOpts(nodes); err != nil {
+		return err
+	}
 
 	// Get host memory and CPU
-	cpu := host.GetCpu()
-	memoryBytes, err := host.GetMemory()
+	hostShape, err := host.GetShape()
 	if err != nil {
 		return err
 	}
+	if len(shapes) == 0 {
+		shapes = []host.Shape{hostShape}
+	}
+	// Reject shapes larger than the host before any cluster is started
+	for _, shape := range shapes {
+		if _, err := crdbcluster.ShapeOpts(shape, hostShape.MemoryBytes); err != nil {
+			return err
+		}
+	}
 
 	rs, err := sm.getReleasesNames(release)
 	if err != nil {
 		return err
 	}
-	logrus.Info(fmt.Sprintf("Found %d releases that are candidate for updating", len(rs)))
+	logrus.Info(fmt.Sprintf("Found %d releases that are candidate for updating with %d host shapes and %d node(s)",
+		len(rs), len(shapes), nodes))
 
-	// Iterate over releases
-	for _, r := range rs {
+	pool := crdbcluster.NewPool(sm.Parallelism, nodes, sm.Binaries)
+	results := pool.Run(ctx, rs, func(ctx context.Context, cm *crdbcluster.Manager, r string) error {
+		return sm.saveClusterSettingsForRelease(ctx, cm, r, shapes, hostShape)
+	})
+	return crdbcluster.ResultsError(results)
+}
+
+// saveClusterSettingsForRelease captures and saves the settings of a release for each host shape that has not been
+// captured yet, using the cluster manager of a worker
+func (sm *Manager) saveClusterSettingsForRelease(ctx context.Context, cm *crdbcluster.Manager, r string, shapes []host.Shape, hostShape host.Shape) error {
+	nodes := cm.NodeCount()
+	defer func() { cm.StartArgs = nil }()
+	for _, shape := range shapes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		cpu := shape.Cpu
+		memoryBytes := shape.MemoryBytes
 
 		// Check to see if save run already exists, if it does, bail early - we've already captured the settings
-		exists, err := sm.Db.SaveRunExists(r, cpu, memoryBytes) // TODO
+		exists, err := sm.Db.SaveRunExists(r, cpu, memoryBytes, nodes)
 		if err != nil {
 			return err
 		}
 		if exists {
-			logrus.Info(fmt.Sprintf("Save run already exists for '%s' with cpu/memory %d/%d", r, cpu, memoryBytes))
+			logrus.Info(fmt.Sprintf("Save run already exists for '%s' with cpu/memory/nodes %d/%d/%d",
+				r, cpu, memoryBytes, nodes))
 			continue
 		}
+		opts, err := crdbcluster.ShapeOpts(shape, hostShape.MemoryBytes)
+		if err != nil {
+			return err
+		}
+		cm.StartArgs = crdbcluster.ShapeArgs(shape)
 
 		// Get the cluster settings for this release
-		settings, err := ClusterSettingsFromRelease(r)
+		settings, err := ClusterSettingsFromCluster(cm, r, opts...)
 		if err != nil {
 			return err
 		}
@@ -82,23 +156,19 @@ func (sm *Manager) SaveClusterSettingsForVersion(release string, url string) err
 
 		// Convert the cluster settings into raw settings to be saved
 		for i, s := range settings {
-			rawSettings[i] = *NewRawSetting(r, cpu, memoryBytes, s)
+			rawSettings[i] = *NewRawSetting(r, cpu, memoryBytes, nodes, s)
 		}
 
-		err = sm.Db.SaveRawSettings(rawSettings) // TODO
-		if err != nil {
+		if err := sm.Db.SaveRawSettings(rawSettings); err != nil {
 			return err
 		}
 
 		// Save the save run so we don't have to re-run later
-		err = sm.Db.SaveRun(r, cpu, memoryBytes) // TODO
-		if err != nil {
+		if err := sm.Db.SaveRun(r, cpu, memoryBytes, nodes); err != nil {
 			return err
 		}
 	}
-
 	return nil
-
 }
 
 func (sm *Manager) getReleasesNames(release string) ([]string, error) {
@@ -112,10 +182,7 @@ func (sm *Manager) getReleasesNames(release string) ([]string, error) {
 				return nil, err
 			}
 		}
-		rm, err := releases.NewReleasesManager(sm.Db.Url)
-		if err != nil {
-			return nil, err
-		}
+		rm := releases.NewReleasesManagerFromPool(sm.Db.Pool)
 		return rm.GetRecentReleaseNames(cnt)
 	} else {
 		return []string{release}, nil
@@ -137,34 +204,176 @@ func (sm *Manager) CompareSettingsForReleases(r1 string, r2 string) (ComparedRel
 
 }
 
+// CompareSettingsForReleaseList compares the settings of several releases, given as a comma separated list or a range
+// such as v23.1.0...v24.1.0. A range includes its first and last release and every captured production release
+// between them. An InvalidReleaseListError is returned for lists that cannot be compared.
+func (sm *Manager) CompareSettingsForReleaseList(expr string) (MultiReleaseComparison, error) {
+	names, err := sm.resolveReleaseList(expr)
+	if err != nil {
+		return MultiReleaseComparison{}, err
+	}
+
+	rss := make([]ReleaseSettings, len(names))
+	for i, name := range names {
+		rss[i], err = sm.GetSettingsForRelease(name)
+		if err != nil {
+			return MultiReleaseComparison{}, err
+		}
+	}
+	return CompareMultipleReleaseSettings(names, rss), nil
+}
+
+// resolveReleaseList gets the names of the releases to compare, in the order they are compared
+func (sm *Manager) resolveReleaseList(expr string) ([]string, error) {
+	names, isRange, err := ParseReleaseList(expr)
+	if err != nil || !isRange {
+		return names, err
+	}
+
+	rm := releases.NewReleasesManagerFromPool(sm.Db.Pool)
+	from, err := rm.GetRelease(names[0])
+	if err != nil {
+		return nil, err
+	}
+	to, err := rm.GetRelease(names[1])
+	if err != nil {
+		return nil, err
+	}
+	all, err := rm.GetReleases()
+	if err != nil {
+		return nil, err
+	}
+	captured, err := sm.Db.GetCapturedReleaseNames()
+	if err != nil {
+		return nil, err
+	}
+
+	names = make([]string, 0)
+	for _, r := range all.Between(from, to) {
+		if r.Name == from.Name || r.Name == to.Name || slices.Contains(captured, r.Name) {
+			names = append(names, r.Name)
+		}
+	}
+	if err := validateReleaseList(expr, names); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// UpgradeReportForCluster checks the settings overridden on a running cluster against the settings captured for a
+// target release
+func (sm *Manager) UpgradeReportForCluster(clusterUrl string, target string) (UpgradeReport, error) {
+	rm := releases.NewReleasesManagerFromPool(sm.Db.Pool)
+	if _, err := rm.GetRelease(target); err != nil {
+		return UpgradeReport{}, err
+	}
+	targetRaws, err := sm.Db.GetRawSettingsForVersion(target, ListOptions{})
+	if err != nil {
+		return UpgradeReport{}, err
+	}
+	if len(targetRaws) == 0 {
+		return UpgradeReport{}, fmt.Errorf("no settings have been captured for release '%s'", target)
+	}
+
+	pool, err := dbpgx.NewPoolFromUrl(clusterUrl)
+	if err != nil {
+		return UpgradeReport{}, err
+	}
+	defer pool.Close()
+	cluster, err := GetLocalClusterSettings(pool)
+	if err != nil {
+		return UpgradeReport{}, err
+	}
+
+	return GenerateUpgradeReport(cluster, target, targetRaws), nil
+}
+
+// HistoryForSetting generates a timeline of when a setting first appeared, changed, was renamed and disappeared.
+// Renames are found by the key of the setting.
 func (sm *Manager) HistoryForSetting(setting string) (SettingHistory, error) {
-	return GenerateSettingHistory(ReleaseSettings{})
+	raws, err := sm.Db.GetRawSettingsForSettingKey(setting)
+	if err != nil {
+		return SettingHistory{}, err
+	}
+	if len(raws) == 0 {
+		return SettingHistory{}, &UnknownSettingError{Variable: setting}
+	}
+
+	names, err := sm.Db.GetCapturedReleaseNames()
+	if err != nil {
+		return SettingHistory{}, err
+	}
+
+	rm := releases.NewReleasesManagerFromPool(sm.Db.Pool)
+	rels, err := rm.GetReleases()
+	if err != nil {
+		return SettingHistory{}, err
+	}
+	captured := rels.FilterForNames(names)
+
+	// Only include settings for releases that we know about
+	s := make(ReleaseSettings, 0)
+	for _, raw := range raws {
+		if captured.GetReleaseForName(raw.ReleaseName) == nil {
+			continue
+		}
+		s = append(s, ReleaseSetting{
+			ReleaseName: raw.ReleaseName,
+			Variable:    raw.Variable,
+			Value:       raw.Value,
+			Type:        raw.Type,
+			Public:      raw.Public,
+			Description: raw.Description,
+			Key:         raw.Key,
+		})
+	}
+
+	return GenerateSettingHistory(setting, s, captured)
 }
 
-func (sm *Manager) GetSettingDetail(setting string) (Detail, error) {
+// SummarizeSettings rebuilds the settings summary table from all raw settings
+func (sm *Manager) SummarizeSettings() error {
+	return summarizeAndSaveSettings(sm.Db)
+}
 
-	d := Detail{Name: setting}
+func (sm *Manager) GetSettingSummaries() (Summaries, error) {
+	return sm.Db.GetSettingSummaries()
+}
 
-	// Get recent description
-	desc, err := sm.Db.GetRecentDescriptionForSetting(setting)
+func (sm *Manager) GetSettingSummary(setting string) (Summary, error) {
+	s, err := sm.Db.GetSettingSummary(setting)
 	if err != nil {
-		return d, err
+		return Summary{}, err
 	}
-	d.Description = desc
+	if s == nil {
+		return Summary{}, &UnknownSettingError{Variable: setting}
+	}
+	return *s, nil
+}
+
+func (sm *Manager) GetSettingDetail(setting string) (Detail, error) {
+
+	d := Detail{Name: setting}
 
 	// Add list of releases
 	names, err := sm.Db.GetReleaseNamesForSetting(setting)
 	if err != nil {
 		return d, err
 	}
+	if len(names) == 0 {
+		return d, &UnknownSettingError{Variable: setting}
+	}
 	d.ReleaseNames = names
 
-	// Add Github issues
-	ghm, err := gh.NewManager(nil, sm.Db.Url)
+	// Get recent description
+	desc, err := sm.Db.GetRecentDescriptionForSetting(setting)
 	if err != nil {
 		return d, err
 	}
+	d.Description = desc
 
+	// Add Github issues
+	ghm := gh.NewManagerFromPool(nil, sm.Db.Pool)
 	issues, err := ghm.GetIssuesForSetting(setting)
 	if err != nil {
 		return d, err
@@ -1,6 +1,7 @@
 package settings
 
 import (
+	"cmp"
 	"github.com/jonstjohn/crdb-settings/pkg/releases"
 	"slices"
 	"time"
@@ -12,6 +13,7 @@ type RawSetting struct {
 	ReleaseName  string
 	Cpu          int
 	MemoryBytes  int64
+	Nodes        int
 	Variable     string
 	Value        string
 	Type         string
@@ -41,11 +43,12 @@ type Meta struct {
 	descriptionChanges []Change
 }
 
-func NewRawSetting(releaseName string, cpu int, memoryBytes int64, cs ClusterSetting) *RawSetting {
+func NewRawSetting(releaseName string, cpu int, memoryBytes int64, nodes int, cs ClusterSetting) *RawSetting {
 	return &RawSetting{
 		ReleaseName:  releaseName,
 		Cpu:          cpu,
 		MemoryBytes:  memoryBytes,
+		Nodes:        nodes,
 		Variable:     cs.Variable,
 		Value:        cs.Value,
 		Type:         cs.Type,
@@ -57,16 +60,27 @@ func NewRawSetting(releaseName string, cpu int, memoryBytes int64, cs ClusterSet
 	}
 }
 
+// captureShape is the host shape and node count that a raw setting was captured with
+type captureShape struct {
+	cpu         int
+	memoryBytes int64
+	nodes       int
+}
+
+func (r *RawSetting) captureShape() captureShape {
+	return captureShape{cpu: r.Cpu, memoryBytes: r.MemoryBytes, nodes: r.Nodes}
+}
+
+// compare orders multi-node captures first, then by CPU and memory, so that a single node on the largest host
+// shape sorts last
+func (s captureShape) compare(s2 captureShape) int {
+	return cmp.Or(cmp.Compare(s2.nodes, s.nodes), cmp.Compare(s.cpu, s2.cpu), cmp.Compare(s.memoryBytes, s2.memoryBytes))
+}
+
 func (r *RawSetting) Compare(r2 *RawSetting) int {
 	if r.Variable == r2.Variable {
 		if r.ReleaseName == r2.ReleaseName {
-			if r.Cpu == r2.Cpu {
-				return 0
-			} else if r.Cpu < r2.Cpu {
-				return -1
-			} else {
-				return 1
-			}
+			return r.captureShape().compare(r2.captureShape())
 		} else if r.ReleaseName < r2.ReleaseName {
 			return -1
 		} else {
@@ -176,64 +190,71 @@ func (rs *RawSettings) MetaForVariable(v string, rels releases.Releases) *Meta {
 		Release     string
 		Description string
 	}
-	currentValueForCpu := make(map[int]releaseValue)             // map of CPU to value
-	currentDescriptionForCpu := make(map[int]releaseDescription) // map of CPU to description
+	type releaseNodes struct {
+		Release string
+		Nodes   int
+	}
+	currentValueForShape := make(map[captureShape]releaseValue)             // map of capture shape to value
+	currentDescriptionForShape := make(map[captureShape]releaseDescription) // map of capture shape to description
 	valueChanges := make([]Change, 0)
 	descriptionChanges := make([]Change, 0)
-	valuesForRelease := make(map[string][]string)
+	valuesForRelease := make(map[releaseNodes][]string)
 	hostDependent := false
 	for _, rswr := range rswrs {
+		shape := rswr.RawSetting.captureShape()
+		release := releaseNodes{Release: rswr.RawSetting.ReleaseName, Nodes: rswr.RawSetting.Nodes}
 		// Initialize current value if it hasn't been set
-		if _, ok := currentValueForCpu[rswr.RawSetting.Cpu]; !ok {
-			currentValueForCpu[rswr.RawSetting.Cpu] = releaseValue{
+		if _, ok := currentValueForShape[shape]; !ok {
+			currentValueForShape[shape] = releaseValue{
 				Release: rswr.RawSetting.ReleaseName, Value: rswr.RawSetting.Value}
 			continue
 		}
 
 		// Initialize current description if it hasn't been set
-		if _, ok := currentDescriptionForCpu[rswr.RawSetting.Cpu]; !ok {
-			currentDescriptionForCpu[rswr.RawSetting.Cpu] = releaseDescription{Release: rswr.RawSetting.ReleaseName, Description: rswr.RawSetting.Description}
+		if _, ok := currentDescriptionForShape[shape]; !ok {
+			currentDescriptionForShape[shape] = releaseDescription{Release: rswr.RawSetting.ReleaseName, Description: rswr.RawSetting.Description}
 			continue
 		}
 
-		// If the value has changed for the same CPU, record it as a value change
-		if rswr.RawSetting.Value != currentValueForCpu[rswr.RawSetting.Cpu].Value {
+		// If the value has changed for the same capture shape, record it as a value change
+		if rswr.RawSetting.Value != currentValueForShape[shape].Value {
 			if !slices.ContainsFunc(valueChanges, func(c Change) bool {
 				return c.Release == rswr.RawSetting.ReleaseName
 			}) {
 				valueChanges = append(valueChanges,
 					Change{
 						Release: rswr.RawSetting.ReleaseName,
-						From:    currentValueForCpu[rswr.RawSetting.Cpu].Value,
+						From:    currentValueForShape[shape].Value,
 						To:      rswr.RawSetting.Value,
 					})
 			}
-			currentValueForCpu[rswr.RawSetting.Cpu] = releaseValue{Release: rswr.RawSetting.ReleaseName, Value: rswr.RawSetting.Value}
+			currentValueForShape[shape] = releaseValue{Release: rswr.RawSetting.ReleaseName, Value: rswr.RawSetting.Value}
 		}
 
-		// If the description has changed for the same CPU, record it as a value change
-		if rswr.RawSetting.Description != currentDescriptionForCpu[rswr.RawSetting.Cpu].Description {
+		// If the description has changed for the same capture shape, record it as a value change
+		if rswr.RawSetting.Description != currentDescriptionForShape[shape].Description {
 			if !slices.ContainsFunc(descriptionChanges, func(c Change) bool {
 				return c.Release == rswr.RawSetting.ReleaseName
 			}) {
 				descriptionChanges = append(descriptionChanges,
 					Change{
 						Release: rswr.RawSetting.ReleaseName,
-						From:    currentDescriptionForCpu[rswr.RawSetting.Cpu].Description,
+						From:    currentDescriptionForShape[shape].Description,
 						To:      rswr.RawSetting.Description,
 					})
 			}
-			currentDescriptionForCpu[rswr.RawSetting.Cpu] = releaseDescription{Release: rswr.RawSetting.ReleaseName, Description: rswr.RawSetting.Description}
+			currentDescriptionForShape[shape] = releaseDescription{Release: rswr.RawSetting.ReleaseName, Description: rswr.RawSetting.Description}
 		}
 
-		// If the value for the same release and a different CPU is not the same, mark it as host dependent
-		if _, ok := valuesForRelease[rswr.RawSetting.ReleaseName]; !ok {
-			valuesForRelease[rswr.RawSetting.ReleaseName] = []string{rswr.RawSetting.Value}
+		// If the value for the same release and node count on a different host shape is not the same, mark it as host
+		// dependent
+		if _, ok := valuesForRelease[release]; !ok {
+			valuesForRelease[release] = []string{rswr.RawSetting.Value}
 		} else { // otherwise, check to see if there is a different value for this release
-			if !slices.Contains(valuesForRelease[rswr.RawSetting.ReleaseName], rswr.RawSetting.Value) {
+			if !slices.Contains(valuesForRelease[release], rswr.RawSetting.Value) {
 				hostDependent = true
 			}
-			valuesForRelease[rswr.RawSetting.ReleaseName] = append(valuesForRelease[rswr.RawSetting.ReleaseName], rswr.RawSetting.Value)
+			valuesForRelease[release] = append(valuesForRelease[release], rswr.RawSetting.Value)
 		}
 
 	}
@@ -267,6 +288,6 @@ func NewRawSettingsWithReleases(rawSettings RawSettings, rels releases.Releases)
 
 func (rss RawSettingsWithReleases) SortByRelease() {
 	slices.SortFunc(rss, func(a, b RawSettingWithRelease) int {
-		return a.Release.CompareVersion(b.Release)
+		return cmp.Or(a.Release.CompareVersion(b.Release), a.RawSetting.captureShape().compare(b.RawSetting.captureShape()))
 	})
 }
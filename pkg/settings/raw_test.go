@@ -120,3 +120,26 @@ func TestRawSettingsMetaForVariable(t *testing.T) {
 	assert.Equal(t, true, runnersMeta.hostDependent)
 
 }
+
+func TestRawSettingsMetaForVariableNodes(t *testing.T) {
+	rels, err := releasesFromFile()
+	assert.Nil(t, err)
+
+	// The value differs between single node and three node clusters, but not between releases or host shapes
+	variable := "kv.allocator.load_based_rebalancing"
+	rawSettings := RawSettings{
+		{ReleaseName: "v22.1.10", Cpu: 4, MemoryBytes: 16 << 30, Nodes: 3, Variable: variable, Value: "b"},
+		{ReleaseName: "v22.1.10", Cpu: 4, MemoryBytes: 16 << 30, Nodes: 1, Variable: variable, Value: "a"},
+		{ReleaseName: "v23.1.15", Cpu: 4, MemoryBytes: 16 << 30, Nodes: 1, Variable: variable, Value: "a"},
+		{ReleaseName: "v23.1.15", Cpu: 2, MemoryBytes: 4 << 30, Nodes: 1, Variable: variable, Value: "a"},
+		{ReleaseName: "v23.1.15", Cpu: 4, MemoryBytes: 16 << 30, Nodes: 3, Variable: variable, Value: "b"},
+		{ReleaseName: "v22.1.10", Cpu: 2, MemoryBytes: 4 << 30, Nodes: 1, Variable: variable, Value: "a"},
+	}
+
+	meta := rawSettings.MetaForVariable(variable, rels)
+	assert.False(t, meta.hostDependent)
+	assert.Empty(t, meta.valueChanges)
+	// A single node on the largest host shape is the most recent value
+	assert.Equal(t, RawSetting{ReleaseName: "v23.1.15", Cpu: 4, MemoryBytes: 16 << 30, Nodes: 1, Variable: variable,
+		Value: "a"}, meta.mostRecent)
+}
@@ -2,53 +2,53 @@ package settings
 
 import (
 	"github.com/cockroachdb/cockroach-go/v2/testserver"
+	"github.com/jonstjohn/crdb-settings/pkg/crdbcluster"
 	"github.com/jonstjohn/crdb-settings/pkg/dbpgx"
 	"github.com/jonstjohn/crdb-settings/pkg/releases"
-	"os"
-	"path/filepath"
 )
 
 type ReleaseSettings []ReleaseSetting
 
 type ReleaseSetting struct {
-	ReleaseName string `json:"release_name""`
+	ReleaseName string `json:"release_name"`
 	Variable    string `json:"variable"`
 	Value       string `json:"value"`
 	Type        string `json:"type"`
 	Public      bool   `json:"public"`
 	Description string `json:"description"`
+	Key         string `json:"key"`
 }
 
 var IgnoredSettings = []string{
 	"cluster.secret",
 }
 
-func ClusterSettingsFromRelease(release string) ([]ClusterSetting, error) {
-	t, err := testserver.NewTestServer(
-		testserver.CustomVersionOpt(release))
-	if err != nil {
+// ClusterSettingsFromCluster starts a test cluster for the release with an existing cluster manager, gets its
+// cluster settings and stops the cluster, so that the manager can be used for another release
+func ClusterSettingsFromCluster(cm *crdbcluster.Manager, release string, opts ...testserver.TestServerOpt) ([]ClusterSetting, error) {
+	if err := cm.StartTestCluster(release, opts...); err != nil {
 		return nil, err
 	}
-	pool, err := dbpgx.NewPoolFromUrl(t.PGURL().String())
-	if err != nil {
-		return nil, err
+	settings, err := clusterSettingsFromRunningCluster(cm)
+
+	if cleanupErr := cm.CleanupTestCluster(); cleanupErr != nil && err == nil {
+		return settings, cleanupErr
 	}
-	settings, err := GetLocalClusterSettings(pool)
 
-	t.Stop()
+	return settings, err
+}
 
-	// Delete files
-	files, err := filepath.Glob(filepath.Join(os.TempDir(), "cockroach-v*"))
+func clusterSettingsFromRunningCluster(cm *crdbcluster.Manager) ([]ClusterSetting, error) {
+	pgurl, err := cm.GetPGUrl()
 	if err != nil {
-		return settings, err
+		return nil, err
 	}
-	for _, f := range files {
-		if err := os.Remove(f); err != nil {
-			return settings, err
-		}
+	pool, err := dbpgx.NewPoolFromUrl(pgurl.String())
+	if err != nil {
+		return nil, err
 	}
-
-	return settings, err
+	defer pool.Close()
+	return GetLocalClusterSettings(pool)
 }
 
 /*
@@ -161,22 +161,24 @@ func SaveClusterSettingsForVersion(release string, url string) error {
 
 */
 
-// SummarizeSettings gets the raw settings and summarizes them into the settings_summary table
+// SummarizeAndSaveSettings gets the raw settings and summarizes them into the settings_summary table, replacing
+// any existing summaries
 func SummarizeAndSaveSettings(url string) error {
 	rsDs, err := NewDbDatasource(url)
 	if err != nil {
 		return err
 	}
-	rawSettings, err := rsDs.GetRawSettings()
-	if err != nil {
-		return err
-	}
+	defer rsDs.Pool.Close()
+	return summarizeAndSaveSettings(rsDs)
+}
 
-	rm, err := releases.NewReleasesManager(url)
+func summarizeAndSaveSettings(rsDs *Db) error {
+	rawSettings, err := rsDs.GetRawSettings()
 	if err != nil {
 		return err
 	}
 
+	rm := releases.NewReleasesManagerFromPool(rsDs.Pool)
 	rels, err := rm.GetReleases()
 	if err != nil {
 		return err
@@ -189,5 +191,5 @@ func SummarizeAndSaveSettings(url string) error {
 		return err
 	}
 
-	return rsDs.SaveSettingsSummaries(summaries)
+	return rsDs.ReplaceSettingsSummaries(summaries)
 }
@@ -28,27 +28,27 @@ type Summarizer struct {
 }
 
 type Change struct {
-	Release string
-	From    string
-	To      string
+	Release string `json:"release"`
+	From    string `json:"from"`
+	To      string `json:"to"`
 }
 
 type Summaries []Summary
 
 type Summary struct {
-	Variable           string
-	Value              string
-	Type               string
-	Public             bool
-	Description        string
-	DefaultValue       string
-	Origin             string
-	Key                string
-	FirstReleases      []string
-	LastReleases       []string
-	HostDependent      bool
-	ValueChanges       []Change
-	DescriptionChanges []Change
+	Variable           string   `json:"variable"`
+	Value              string   `json:"value"`
+	Type               string   `json:"type"`
+	Public             bool     `json:"public"`
+	Description        string   `json:"description"`
+	DefaultValue       string   `json:"default_value"`
+	Origin             string   `json:"origin"`
+	Key                string   `json:"key"`
+	FirstReleases      []string `json:"first_releases"`
+	LastReleases       []string `json:"last_releases"`
+	HostDependent      bool     `json:"host_dependent"`
+	ValueChanges       []Change `json:"value_changes"`
+	DescriptionChanges []Change `json:"description_changes"`
 }
 
 func NewSummarizer(rawSettings RawSettings, rels releases.Releases) *Summarizer {
@@ -0,0 +1,26 @@
+package settings
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSummarizerSummarize(t *testing.T) {
+	rels, err := releasesFromFile()
+	assert.Nil(t, err)
+
+	rawSettings, err := rawSettingsFromFile("kv.snapshot_recovery.max_rate", "sql.distsql.num_runners")
+	assert.Nil(t, err)
+
+	summaries, err := NewSummarizer(*rawSettings, rels).Summarize()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(summaries))
+
+	assert.Equal(t, "kv.snapshot_recovery.max_rate", summaries[0].Variable)
+	assert.False(t, summaries[0].HostDependent)
+	assert.Equal(t, 4, len(summaries[0].FirstReleases))
+	assert.Equal(t, 1, len(summaries[0].ValueChanges))
+
+	assert.Equal(t, "sql.distsql.num_runners", summaries[1].Variable)
+	assert.True(t, summaries[1].HostDependent)
+}
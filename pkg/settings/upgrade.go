@@ -0,0 +1,146 @@
+package settings
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Issues found for an overridden setting when upgrading to a target release
+const (
+	IssueRemoved    = "removed"     // the setting does not exist in the target release
+	IssueRenamed    = "renamed"     // the setting has a new name in the target release, with the same key
+	IssueRetyped    = "retyped"     // the setting has a different type in the target release
+	IssueNowDefault = "now_default" // the override is the default in the target release and can be reset
+)
+
+// UpgradeReport checks every setting overridden on a running cluster against the defaults of a target release
+type UpgradeReport struct {
+	ClusterVersion string           `json:"cluster_version"`
+	Target         string           `json:"target"`
+	Overrides      []OverrideImpact `json:"overrides"`
+}
+
+// OverrideImpact is an overridden cluster setting and the issues it has in the target release. Target is the
+// setting in the target release, which is nil if it has been removed.
+type OverrideImpact struct {
+	Variable string          `json:"variable"`
+	Value    string          `json:"value"`
+	Default  string          `json:"default"`
+	Type     string          `json:"type"`
+	Origin   string          `json:"origin"`
+	Target   *ReleaseSetting `json:"target"`
+	Issues   []string        `json:"issues"`
+}
+
+// IsOverridden returns whether a setting has been changed on the cluster. The origin column is used when the
+// cluster has it, otherwise the value is compared with the default. Clusters too old to have either column never
+// report overrides.
+func (cs ClusterSetting) IsOverridden() bool {
+	if cs.Origin != "" {
+		return cs.Origin != "default"
+	}
+	return cs.DefaultValue != "" && cs.Value != cs.DefaultValue
+}
+
+// GenerateUpgradeReport finds the overridden cluster settings and flags those that are removed, renamed, retyped or
+// the default in the target release. A setting is renamed when a target setting with another name has the same key.
+func GenerateUpgradeReport(cluster []ClusterSetting, target string, targetRaws RawSettings) UpgradeReport {
+	byVariable := make(map[string]RawSetting)
+	byKey := make(map[string]RawSetting)
+	for _, r := range targetRaws {
+		if _, ok := byVariable[r.Variable]; !ok {
+			byVariable[r.Variable] = r
+		}
+		if _, ok := byKey[r.Key]; r.Key != "" && !ok {
+			byKey[r.Key] = r
+		}
+	}
+
+	report := UpgradeReport{Target: target, Overrides: make([]OverrideImpact, 0)}
+	for _, cs := range cluster {
+		if cs.Variable == "version" {
+			report.ClusterVersion = cs.Value
+		}
+		if slices.Contains(ignoreList, cs.Variable) || !cs.IsOverridden() {
+			continue
+		}
+
+		impact := OverrideImpact{Variable: cs.Variable, Value: cs.Value, Default: cs.DefaultValue, Type: cs.Type,
+			Origin: cs.Origin, Issues: make([]string, 0)}
+
+		r, ok := byVariable[cs.Variable]
+		if !ok {
+			key := cs.Key
+			if key == "" {
+				key = cs.Variable // the key was the name before settings could be renamed
+			}
+			r, ok = byKey[key]
+			if ok {
+				impact.Issues = append(impact.Issues, IssueRenamed)
+			}
+		}
+		if !ok {
+			impact.Issues = append(impact.Issues, IssueRemoved)
+			report.Overrides = append(report.Overrides, impact)
+			continue
+		}
+
+		impact.Target = &ReleaseSetting{ReleaseName: r.ReleaseName, Variable: r.Variable, Value: r.Value,
+			Type: r.Type, Public: r.Public, Description: r.Description, Key: r.Key}
+		if r.Type != cs.Type {
+			impact.Issues = append(impact.Issues, IssueRetyped)
+		}
+		if r.Value == cs.Value {
+			impact.Issues = append(impact.Issues, IssueNowDefault)
+		}
+		report.Overrides = append(report.Overrides, impact)
+	}
+
+	sort.Slice(report.Overrides, func(i, j int) bool {
+		return report.Overrides[i].Variable < report.Overrides[j].Variable
+	})
+	return report
+}
+
+// Markdown formats the report as a Markdown table, with the overrides that have issues listed first
+func (r UpgradeReport) Markdown() string {
+	overrides := slices.Clone(r.Overrides)
+	sort.SliceStable(overrides, func(i, j int) bool {
+		return len(overrides[i].Issues) > 0 && len(overrides[j].Issues) == 0
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Upgrade report: %s to %s\n\n", r.ClusterVersion, r.Target)
+	if len(overrides) == 0 {
+		b.WriteString("No settings are overridden on the cluster.\n")
+		return b.String()
+	}
+
+	issues := 0
+	for _, o := range overrides {
+		if len(o.Issues) > 0 {
+			issues++
+		}
+	}
+	fmt.Fprintf(&b, "%d of %d overridden settings have issues.\n\n", issues, len(overrides))
+
+	b.WriteString("| Setting | Value | Default | Target setting | Target default | Issues |\n")
+	b.WriteString("|---------|-------|---------|----------------|----------------|--------|\n")
+	for _, o := range overrides {
+		targetVariable, targetValue := "", ""
+		if o.Target != nil {
+			targetVariable, targetValue = o.Target.Variable, o.Target.Value
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s | %s |\n", o.Variable, markdownCell(o.Value),
+			markdownCell(o.Default), markdownCell(targetVariable), markdownCell(targetValue),
+			strings.Join(o.Issues, ", "))
+	}
+	return b.String()
+}
+
+// markdownCell escapes the characters that would break a table cell
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
@@ -0,0 +1,69 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClusterSetting_IsOverridden(t *testing.T) {
+	assert.True(t, ClusterSetting{Value: "1", DefaultValue: "1", Origin: "override"}.IsOverridden())
+	assert.False(t, ClusterSetting{Value: "2", DefaultValue: "1", Origin: "default"}.IsOverridden())
+	assert.True(t, ClusterSetting{Value: "2", DefaultValue: "1"}.IsOverridden())
+	assert.False(t, ClusterSetting{Value: "1", DefaultValue: "1"}.IsOverridden())
+	assert.False(t, ClusterSetting{Value: "1"}.IsOverridden())
+}
+
+func TestGenerateUpgradeReport(t *testing.T) {
+	cluster := []ClusterSetting{
+		{Variable: "version", Value: "23.1", Origin: "override"},
+		{Variable: "sql.kept", Value: "10", DefaultValue: "5", Type: "i", Origin: "override"},
+		{Variable: "sql.not_overridden", Value: "5", DefaultValue: "5", Type: "i", Origin: "default"},
+		{Variable: "sql.gone", Value: "true", DefaultValue: "false", Type: "b", Origin: "override"},
+		{Variable: "sql.old_name", Value: "1s", DefaultValue: "2s", Type: "d", Origin: "override", Key: "sql.key"},
+		{Variable: "sql.retyped", Value: "100", DefaultValue: "10", Type: "i", Origin: "override"},
+		{Variable: "sql.caught_up", Value: "64 MiB", DefaultValue: "32 MiB", Type: "z"},
+	}
+	target := RawSettings{
+		{ReleaseName: "v24.1.0", Variable: "sql.kept", Value: "5", Type: "i", Key: "sql.kept"},
+		{ReleaseName: "v24.1.0", Variable: "sql.new_name", Value: "2s", Type: "d", Key: "sql.key"},
+		{ReleaseName: "v24.1.0", Variable: "sql.retyped", Value: "10", Type: "z", Key: "sql.retyped"},
+		{ReleaseName: "v24.1.0", Variable: "sql.caught_up", Value: "64 MiB", Type: "z", Key: "sql.caught_up"},
+	}
+
+	r := GenerateUpgradeReport(cluster, "v24.1.0", target)
+	assert.Equal(t, "23.1", r.ClusterVersion)
+	assert.Equal(t, "v24.1.0", r.Target)
+
+	issues := make(map[string][]string)
+	for _, o := range r.Overrides {
+		issues[o.Variable] = o.Issues
+	}
+	assert.Equal(t, map[string][]string{
+		"sql.caught_up": {IssueNowDefault},
+		"sql.gone":      {IssueRemoved},
+		"sql.kept":      {},
+		"sql.old_name":  {IssueRenamed},
+		"sql.retyped":   {IssueRetyped},
+	}, issues)
+
+	assert.Equal(t, "sql.caught_up", r.Overrides[0].Variable)
+	assert.Nil(t, r.Overrides[1].Target)
+	assert.Equal(t, "sql.new_name", r.Overrides[3].Target.Variable)
+}
+
+func TestUpgradeReport_Markdown(t *testing.T) {
+	r := UpgradeReport{ClusterVersion: "23.1", Target: "v24.1.0", Overrides: []OverrideImpact{
+		{Variable: "sql.kept", Value: "a|b", Default: "c", Issues: []string{},
+			Target: &ReleaseSetting{Variable: "sql.kept", Value: "c"}},
+		{Variable: "sql.gone", Value: "true", Default: "false", Issues: []string{IssueRemoved}},
+	}}
+
+	md := r.Markdown()
+	assert.Contains(t, md, "# Upgrade report: 23.1 to v24.1.0")
+	assert.Contains(t, md, "1 of 2 overridden settings have issues.")
+	assert.Contains(t, md, "| `sql.gone` | true | false |  |  | removed |\n| `sql.kept` | a\\|b | c | sql.kept | c |  |")
+
+	empty := UpgradeReport{ClusterVersion: "23.1", Target: "v24.1.0"}
+	assert.Contains(t, empty.Markdown(), "No settings are overridden")
+}
@@ -0,0 +1,38 @@
+// Package textsim compares the descriptions of settings and the help text of metrics, which are used to detect
+// renames between releases
+package textsim
+
+import "strings"
+
+// RenameThreshold is the minimum similarity of the text of a removed and an added item of the same type for them to
+// be reported as a rename
+const RenameThreshold = 0.8
+
+// Jaccard is the Jaccard similarity of the lower case words of two texts, from 0 for no words in common to 1 for the
+// same words. Punctuation around words is ignored, and empty text is never similar.
+func Jaccard(t1 string, t2 string) float64 {
+	w1, w2 := words(t1), words(t2)
+	if len(w1) == 0 || len(w2) == 0 {
+		return 0
+	}
+	common := 0
+	for w := range w1 {
+		if w2[w] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(w1)+len(w2)-common)
+}
+
+// Clean removes surrounding spaces and periods, so that a trailing period is not a change
+func Clean(text string) string {
+	return strings.Trim(strings.TrimSpace(text), ".")
+}
+
+func words(text string) map[string]bool {
+	w := make(map[string]bool)
+	for _, f := range strings.Fields(strings.ToLower(Clean(text))) {
+		w[strings.Trim(f, ".,;:()'\"")] = true
+	}
+	return w
+}
@@ -0,0 +1,30 @@
+package textsim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJaccard(t *testing.T) {
+	tests := []struct {
+		t1, t2     string
+		similarity float64
+	}{
+		{"Enables the feature.", "enables the feature", 1},
+		{"enables the feature", "enables the thing", 0.5},
+		{"Used slots.", "used slots", 1},
+		{"Used slots for kv", "Used slots for sql", 0.6},
+		{"(Used) slots;", "used slots", 1},
+		{"", "", 0},
+		{"Used slots", "", 0},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.similarity, Jaccard(tt.t1, tt.t2), "%s / %s", tt.t1, tt.t2)
+	}
+}
+
+func TestClean(t *testing.T) {
+	assert.Equal(t, "Enables the feature", Clean(" Enables the feature. "))
+	assert.Equal(t, "", Clean("..."))
+}